@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	keelmongo "github.com/foomo/keel/persistence/mongo"
+	redirectdefinitionx "github.com/foomo/redirects/v2/domain/redirectdefinition"
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	"github.com/foomo/redirects/v2/domain/redirectdefinition/service"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	natsx "github.com/foomo/redirects/v2/pkg/nats"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"go.uber.org/zap"
+)
+
+// errNotSupported is returned for commands the gotsrpc services do not expose
+var errNotSupported = errors.New("not supported by the gotsrpc backend, use -mongo")
+
+type (
+	// backend runs the commands against a Mongo repository or the gotsrpc services
+	backend interface {
+		// Redirects returns the active redirects by dimension and source
+		Redirects(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error)
+		// Create stores a new redirect
+		Create(ctx context.Context, def *storex.RedirectDefinition) error
+		// Update replaces the stored redirect with the same ID
+		Update(ctx context.Context, def *storex.RedirectDefinition) error
+		Flatten(ctx context.Context, dryRun bool) ([]*storex.RedirectDefinition, error)
+		PurgeStale(ctx context.Context, cutoff time.Time, dryRun bool) (*storex.PurgeRecord, error)
+		// MigrateUpdatedToDate converts the updated timestamps stored as strings by previous versions
+		MigrateUpdatedToDate(ctx context.Context) (int64, error)
+		// Canonicalize stores the sources and targets stored by previous versions in canonical form
+		Canonicalize(ctx context.Context, dryRun bool) (*commandx.CanonicalizeRedirectsResult, error)
+		// Snapshot stores the snapshot or writes it to w if set
+		Snapshot(ctx context.Context, name string, dimension storex.Dimension, w io.Writer) (*storex.Snapshot, error)
+		Close(ctx context.Context) error
+	}
+	mongoBackend struct {
+		api       *redirectdefinitionx.API
+		persistor *keelmongo.Persistor
+		user      string
+	}
+	rpcBackend struct {
+		internal *service.HTTPInternalServiceGoTSRPCClient
+		admin    *service.HTTPAdminServiceGoTSRPCClient
+		site     string
+	}
+)
+
+// newMongoBackend connects to the database of the URI, the update signal is only published with a NATS URI
+func newMongoBackend(ctx context.Context, l *zap.Logger, mongoURI, natsURI, user string) (*mongoBackend, error) {
+	persistor, err := keelmongo.New(ctx, mongoURI)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := repositoryx.NewBaseRedirectsDefinitionRepository(l, persistor)
+	if err != nil {
+		return nil, err
+	}
+
+	// the lease keeps the commands from running concurrently with the automatic pipeline of the service
+	leaseRepo, err := repositoryx.NewBaseLeaseRepository(l, persistor)
+	if err != nil {
+		return nil, err
+	}
+
+	var updateSignal *natsx.UpdateSignal
+	if natsURI != "" {
+		updateSignal, err = natsx.NewUpdateSignal(ctx, l, natsURI, "redirects-cli", natsx.DefaultNatsTopic().String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	api, err := redirectdefinitionx.NewAPI(l, repo, updateSignal,
+		redirectdefinitionx.WithLeaseRepository(leaseRepo),
+		redirectdefinitionx.WithUserProvider(func(_ context.Context) string {
+			return user
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mongoBackend{api: api, persistor: persistor, user: user}, nil
+}
+
+func (b *mongoBackend) Redirects(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	return b.api.GetRedirects(ctx)
+}
+
+func (b *mongoBackend) Create(ctx context.Context, def *storex.RedirectDefinition) error {
+	def.LastUpdatedBy = b.user
+
+	return b.api.CreateRedirect(ctx, commandx.CreateRedirect{RedirectDefinition: def})
+}
+
+func (b *mongoBackend) Update(ctx context.Context, def *storex.RedirectDefinition) error {
+	def.Updated = storex.NewDateTime(time.Now())
+	def.LastUpdatedBy = b.user
+
+	return b.api.UpdateRedirect(ctx, commandx.UpdateRedirect{RedirectDefinition: def})
+}
+
+func (b *mongoBackend) Flatten(ctx context.Context, dryRun bool) ([]*storex.RedirectDefinition, error) {
+	result, err := b.api.FlattenAllRedirects(ctx, commandx.FlattenAllRedirects{DryRun: dryRun})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Changed, nil
+}
+
+func (b *mongoBackend) PurgeStale(ctx context.Context, cutoff time.Time, dryRun bool) (*storex.PurgeRecord, error) {
+	return b.api.PurgeObsoleteRedirects(ctx, commandx.PurgeObsoleteRedirects{Cutoff: cutoff, DryRun: dryRun})
+}
+
+func (b *mongoBackend) MigrateUpdatedToDate(ctx context.Context) (int64, error) {
+	return b.api.MigrateUpdatedToDate(ctx)
+}
+
+func (b *mongoBackend) Canonicalize(ctx context.Context, dryRun bool) (*commandx.CanonicalizeRedirectsResult, error) {
+	return b.api.CanonicalizeRedirects(ctx, commandx.CanonicalizeRedirects{DryRun: dryRun})
+}
+
+func (b *mongoBackend) Snapshot(ctx context.Context, name string, dimension storex.Dimension, w io.Writer) (*storex.Snapshot, error) {
+	return b.api.CreateSnapshot(ctx, commandx.CreateSnapshot{Name: name, Dimension: dimension, Writer: w})
+}
+
+func (b *mongoBackend) Close(ctx context.Context) error {
+	return b.persistor.Close(ctx)
+}
+
+// newRPCBackend reads through the internal service and writes through the admin service,
+// the site is the one the admin service resolves the dimension of created redirects with
+func newRPCBackend(internalURL, adminURL, site string) *rpcBackend {
+	b := &rpcBackend{site: site}
+	if internalURL != "" {
+		b.internal = service.NewDefaultInternalServiceGoTSRPCClient(internalURL)
+	}
+
+	if adminURL != "" {
+		b.admin = service.NewDefaultAdminServiceGoTSRPCClient(adminURL)
+	}
+
+	return b
+}
+
+func (b *rpcBackend) Redirects(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	if b.internal == nil {
+		return nil, errors.New("missing -internal service URL")
+	}
+
+	redirects, err, clientErr := b.internal.GetRedirects(ctx)
+	if clientErr != nil {
+		return nil, clientErr
+	}
+
+	return redirects, err
+}
+
+func (b *rpcBackend) Create(ctx context.Context, def *storex.RedirectDefinition) error {
+	if b.admin == nil {
+		return errors.New("missing -admin service URL")
+	}
+
+	// the admin service prefixes the locale with the site of the request
+	locale, ok := strings.CutPrefix(string(def.Dimension), b.site+"-")
+	if b.site == "" || !ok {
+		return fmt.Errorf("dimension %q does not belong to site %q, set -site", def.Dimension, b.site)
+	}
+
+	_, rdErr, clientErr := b.admin.Create(ctx, def, locale)
+
+	return rpcError(rdErr, clientErr)
+}
+
+func (b *rpcBackend) Update(ctx context.Context, def *storex.RedirectDefinition) error {
+	if b.admin == nil {
+		return errors.New("missing -admin service URL")
+	}
+
+	rdErr, clientErr := b.admin.Update(ctx, def)
+
+	return rpcError(rdErr, clientErr)
+}
+
+func (b *rpcBackend) Flatten(_ context.Context, _ bool) ([]*storex.RedirectDefinition, error) {
+	return nil, errNotSupported
+}
+
+func (b *rpcBackend) PurgeStale(_ context.Context, _ time.Time, _ bool) (*storex.PurgeRecord, error) {
+	return nil, errNotSupported
+}
+
+func (b *rpcBackend) MigrateUpdatedToDate(_ context.Context) (int64, error) {
+	return 0, errNotSupported
+}
+
+func (b *rpcBackend) Canonicalize(_ context.Context, _ bool) (*commandx.CanonicalizeRedirectsResult, error) {
+	return nil, errNotSupported
+}
+
+func (b *rpcBackend) Snapshot(ctx context.Context, name string, dimension storex.Dimension, w io.Writer) (*storex.Snapshot, error) {
+	if w != nil {
+		return nil, fmt.Errorf("writing snapshot files is %w", errNotSupported)
+	}
+
+	if b.admin == nil {
+		return nil, errors.New("missing -admin service URL")
+	}
+
+	snapshot, rdErr, clientErr := b.admin.CreateSnapshot(ctx, name, dimension)
+	if err := rpcError(rdErr, clientErr); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+func (b *rpcBackend) Close(_ context.Context) error {
+	return nil
+}
+
+// rpcError returns the transport error or the error of the service
+func rpcError(rdErr *storex.RedirectDefinitionError, clientErr error) error {
+	if clientErr != nil {
+		return clientErr
+	}
+
+	if rdErr != nil {
+		return rdErr
+	}
+
+	return nil
+}
+
+// redirectsProviderFunc adapts the backend to the redirects provider
+func redirectsProviderFunc(b backend) providerx.RedirectsProviderFunc {
+	return func(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+		redirects, err := b.Redirects(ctx)
+
+		return redirects, err, nil
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+)
+
+// runImport creates the redirects of the file and updates the existing ones with the same dimension and source
+func runImport(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("import")
+	f := fs.String("format", string(formatCSV), "format of the file: csv, json or nginx")
+	in := fs.String("in", "", "file to import, stdin if empty")
+	dimension := fs.String("dimension", "", "dimension of the redirects without one, e.g. for nginx files")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if !format(*f).Valid() {
+		return fmt.Errorf("unknown format %q", *f)
+	}
+
+	r := e.stdin
+
+	if *in != "" {
+		file, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	defs, err := readDefinitions(r, format(*f), storex.Dimension(*dimension))
+	if err != nil {
+		return err
+	}
+
+	existing, err := e.backend.Redirects(ctx)
+	if err != nil {
+		return err
+	}
+
+	var created, updated, unchanged, failed int
+
+	for _, imported := range defs {
+		var importErr error
+
+		def := imported.RedirectDefinition
+
+		current, exists := existing[def.Dimension][def.Source]
+
+		if exists {
+			// keep the fields the file does not set
+			def = imported.apply(current)
+
+			if storex.DefinitionsEquivalent(current, def) {
+				unchanged++
+				continue
+			}
+		}
+
+		switch {
+		case *dryRun && exists:
+			_, _ = fmt.Fprintf(e.stdout, "update\t%s\t%s\t%d\t%s\n", def.Dimension, def.Source, def.Code, def.Target)
+		case *dryRun:
+			_, _ = fmt.Fprintf(e.stdout, "create\t%s\t%s\t%d\t%s\n", def.Dimension, def.Source, def.Code, def.Target)
+		case exists:
+			importErr = e.backend.Update(ctx, def)
+		default:
+			importErr = e.backend.Create(ctx, def)
+		}
+
+		switch {
+		case importErr != nil:
+			failed++
+
+			_, _ = fmt.Fprintf(e.stderr, "failed to import %s %s: %v\n", def.Dimension, def.Source, importErr)
+		case exists:
+			updated++
+		default:
+			created++
+		}
+	}
+
+	_, _ = fmt.Fprintf(e.stderr, "created %d, updated %d, unchanged %d, failed %d%s\n", created, updated, unchanged, failed, dryRunSuffix(*dryRun))
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d redirects failed", failed, len(defs))
+	}
+
+	return nil
+}
+
+// runExport writes the active redirects sorted by dimension and source, csv and nginx with their intended target
+func runExport(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("export")
+	f := fs.String("format", string(formatCSV), "format of the file: csv, json or nginx")
+	out := fs.String("out", "", "file to export to, stdout if empty")
+	dimension := fs.String("dimension", "", "only export the redirects of the dimension")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if !format(*f).Valid() {
+		return fmt.Errorf("unknown format %q", *f)
+	}
+
+	redirects, err := e.backend.Redirects(ctx)
+	if err != nil {
+		return err
+	}
+
+	if *dimension != "" {
+		redirects = map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+			storex.Dimension(*dimension): redirects[storex.Dimension(*dimension)],
+		}
+	}
+
+	return writeOutput(e, *out, func(w io.Writer) error {
+		return writeDefinitions(w, format(*f), commandx.SortedDefinitions(redirects))
+	})
+}
+
+// errLintIssues is returned by lint if it reported issues, e.g. to fail a CI job
+var errLintIssues = errors.New("lint issues found")
+
+func runLint(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("lint")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	redirects, err := e.backend.Redirects(ctx)
+	if err != nil {
+		return err
+	}
+
+	issues := lintRedirects(redirects)
+	for _, issue := range issues {
+		_, _ = fmt.Fprintln(e.stdout, issue)
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%w: %d", errLintIssues, len(issues))
+	}
+
+	return nil
+}
+
+func runResolve(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("resolve")
+	dimension := fs.String("dimension", "", "dimension to resolve the URL in")
+	maxHops := fs.Int("max-hops", 10, "max redirects to follow")
+
+	urls, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	} else if len(urls) != 1 || *dimension == "" {
+		return errors.New("usage: resolve <url> -dimension <dimension>")
+	}
+
+	hops, err := resolveURL(ctx, e.l, e.backend, storex.Dimension(*dimension), urls[0], *maxHops)
+	printHops(e.stdout, urls[0], hops)
+
+	return err
+}
+
+func runFlatten(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("flatten")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	changed, err := e.backend.Flatten(ctx, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range changed {
+		_, _ = fmt.Fprintf(e.stdout, "%s\t%s\t%s\n", def.Dimension, def.Source, def.Target)
+	}
+
+	_, _ = fmt.Fprintf(e.stderr, "flattened %d redirects%s\n", len(changed), dryRunSuffix(*dryRun))
+
+	return nil
+}
+
+func runCanonicalize(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("canonicalize")
+	dryRun := fs.Bool("dry-run", false, "only print the changes")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	result, err := e.backend.Canonicalize(ctx, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range result.Changed {
+		_, _ = fmt.Fprintf(e.stdout, "%s\t%s\t%s\n", def.Dimension, def.Source, def.Target)
+	}
+
+	// conflicting redirects have to be merged or deleted by hand
+	for _, def := range result.Conflicts {
+		_, _ = fmt.Fprintf(e.stderr, "skipped %s\t%s, its canonical source already exists\n", def.Dimension, def.Source)
+	}
+
+	_, _ = fmt.Fprintf(e.stderr, "canonicalized %d redirects, skipped %d%s\n", len(result.Changed), len(result.Conflicts), dryRunSuffix(*dryRun))
+
+	return nil
+}
+
+func runPurgeStale(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("purge-stale")
+	olderThan := fs.Duration("older-than", 0, "purge the redirects soft deleted before this duration, e.g. 720h for the retention of 30 days (required)")
+	dryRun := fs.Bool("dry-run", false, "only print the redirects")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if *olderThan <= 0 {
+		// without a retention all soft deleted redirects would be purged at once
+		return errors.New("usage: purge-stale -older-than <duration>, e.g. 720h")
+	}
+
+	record, err := e.backend.PurgeStale(ctx, time.Now().Add(-*olderThan), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	for _, def := range record.Definitions {
+		_, _ = fmt.Fprintf(e.stdout, "%s\t%s\t%s\n", def.Dimension, def.Source, def.Obsolete)
+	}
+
+	_, _ = fmt.Fprintf(e.stderr, "purged %d redirects%s\n", len(record.Definitions), dryRunSuffix(*dryRun))
+
+	return nil
+}
+
+// runMigrate converts the redirects stored by previous versions, it is safe to run repeatedly
+func runMigrate(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("migrate")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	migrated, err := e.backend.MigrateUpdatedToDate(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(e.stderr, "converted the updated timestamp of %d redirects to a date\n", migrated)
+
+	return nil
+}
+
+func runSnapshot(ctx context.Context, e *env, args []string) error {
+	fs := e.newFlagSet("snapshot")
+	name := fs.String("name", "", "name of the snapshot")
+	dimension := fs.String("dimension", "", "only snapshot the redirects of the dimension")
+	out := fs.String("out", "", "write the snapshot to the file instead of storing it, - for stdout")
+
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if *name == "" {
+		return errors.New("missing -name")
+	}
+
+	if *out == "" {
+		snapshot, err := e.backend.Snapshot(ctx, *name, storex.Dimension(*dimension), nil)
+		if err != nil {
+			return err
+		}
+
+		_, _ = fmt.Fprintf(e.stderr, "stored snapshot %s with %d redirects\n", snapshot.Name, snapshot.Count)
+
+		return nil
+	}
+
+	if *out == "-" {
+		*out = ""
+	}
+
+	return writeOutput(e, *out, func(w io.Writer) error {
+		_, err := e.backend.Snapshot(ctx, *name, storex.Dimension(*dimension), w)
+		return err
+	})
+}
+
+// writeOutput writes to the file or stdout if empty, the file is removed if writing failed
+func writeOutput(e *env, path string, write func(w io.Writer) error) error {
+	if path == "" {
+		return write(e.stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file); err != nil {
+		_ = file.Close()
+		_ = os.Remove(path)
+
+		return err
+	}
+
+	return file.Close()
+}
+
+func dryRunSuffix(dryRun bool) string {
+	if dryRun {
+		return " (dry run)"
+	}
+
+	return ""
+}
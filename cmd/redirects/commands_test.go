@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// recordingBackend serves fixed redirects and records the written ones
+type recordingBackend struct {
+	*staticBackend
+	created []*storex.RedirectDefinition
+	updated []*storex.RedirectDefinition
+	dryRun  bool
+}
+
+func (b *recordingBackend) Create(_ context.Context, def *storex.RedirectDefinition) error {
+	b.created = append(b.created, def)
+	return nil
+}
+
+func (b *recordingBackend) MigrateUpdatedToDate(_ context.Context) (int64, error) {
+	return 2, nil
+}
+
+func (b *recordingBackend) Canonicalize(_ context.Context, dryRun bool) (*commandx.CanonicalizeRedirectsResult, error) {
+	b.dryRun = dryRun
+
+	return &commandx.CanonicalizeRedirectsResult{
+		Changed:   []*storex.RedirectDefinition{{Dimension: "de", Source: "/%C3%BCber", Target: "/about"}},
+		Conflicts: []*storex.RedirectDefinition{{Dimension: "de", Source: "/%c3%bcber"}},
+	}, nil
+}
+
+func (b *recordingBackend) Update(_ context.Context, def *storex.RedirectDefinition) error {
+	b.updated = append(b.updated, def)
+	return nil
+}
+
+func newTestEnv(b backend) (*env, *bytes.Buffer) {
+	var out bytes.Buffer
+	return &env{l: zap.NewNop(), backend: b, stdin: &bytes.Buffer{}, stdout: &out, stderr: &bytes.Buffer{}}, &out
+}
+
+func TestRunPurgeStale_RequiresOlderThan(t *testing.T) {
+	t.Parallel()
+
+	// the static backend panics if the purge is run
+	e, _ := newTestEnv(newStaticBackend())
+
+	require.ErrorContains(t, runPurgeStale(context.Background(), e, nil), "-older-than")
+	require.ErrorContains(t, runPurgeStale(context.Background(), e, []string{"-older-than", "0s", "-dry-run"}), "-older-than")
+}
+
+// the fields the CSV file has no columns for are kept on update
+func TestRunImport_KeepsFields(t *testing.T) {
+	t.Parallel()
+
+	current := &storex.RedirectDefinition{
+		ID: "1", ContentID: "content", Dimension: "de", Source: "/a", Target: "/c", OriginalTarget: "/b",
+		Code: storex.RedirectCodePermanent, ParamPolicy: &storex.ParamPolicy{}, NeedsReview: true,
+		RedirectionType: storex.RedirectionTypeManual,
+	}
+	b := &recordingBackend{staticBackend: newStaticBackend(current)}
+	e, _ := newTestEnv(b)
+	e.stdin = strings.NewReader("dimension,source,target,code\nde,/a,/b,301\nde,/x,/y,302\nde,/z,/y,301\n")
+
+	require.NoError(t, runImport(context.Background(), e, nil))
+	require.Len(t, b.created, 2)
+	assert.Empty(t, b.updated)
+
+	e.stdin = strings.NewReader("dimension,source,target,code\nde,/a,/b,302\n")
+
+	require.NoError(t, runImport(context.Background(), e, nil))
+	require.Len(t, b.updated, 1)
+
+	expected := *current
+	expected.Code = storex.RedirectCodeFound
+	assert.Equal(t, &expected, b.updated[0])
+}
+
+func TestRunMigrate(t *testing.T) {
+	t.Parallel()
+
+	e, _ := newTestEnv(&recordingBackend{staticBackend: newStaticBackend()})
+	stderr := &bytes.Buffer{}
+	e.stderr = stderr
+
+	require.NoError(t, runMigrate(context.Background(), e, nil))
+	assert.Contains(t, stderr.String(), "of 2 redirects")
+}
+
+func TestRunCanonicalize(t *testing.T) {
+	t.Parallel()
+
+	b := &recordingBackend{staticBackend: newStaticBackend()}
+	e, out := newTestEnv(b)
+	stderr := &bytes.Buffer{}
+	e.stderr = stderr
+
+	require.NoError(t, runCanonicalize(context.Background(), e, []string{"-dry-run"}))
+	assert.True(t, b.dryRun)
+	assert.Equal(t, "de\t/%C3%BCber\t/about\n", out.String())
+	assert.Contains(t, stderr.String(), "skipped de\t/%c3%bcber")
+	assert.Contains(t, stderr.String(), "canonicalized 1 redirects, skipped 1")
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+)
+
+// format of imported and exported redirects
+type format string
+
+const (
+	formatCSV   format = "csv"
+	formatJSON  format = "json"
+	formatNginx format = "nginx"
+)
+
+// csvHeader lists the columns of the CSV format, only dimension, source and code are required on import
+var csvHeader = []string{
+	"dimension", "source", "target", "code", "matchType", "redirectType",
+	"respectParams", "transferParams", "stale", "locked", "noFlatten",
+}
+
+// jsonColumns maps the JSON keys of a definition which differ from the CSV columns
+var jsonColumns = map[string]string{
+	"respectparams":  "respectParams",
+	"transferparams": "transferParams",
+}
+
+// nginxColumns lists the columns of the nginx format
+var nginxColumns = []string{"dimension", "source", "target", "code", "matchType"}
+
+// importedDefinition is a definition read from a file with the columns the file sets,
+// all other fields of an existing definition are kept on import
+type importedDefinition struct {
+	*storex.RedirectDefinition
+	columns []string
+}
+
+var (
+	nginxDimensionRe = regexp.MustCompile(`^# dimension: (\S+)$`)
+	nginxExactRe     = regexp.MustCompile(`^location = (\S+) \{ return (\d{3})(?: (\S+))?; \}$`)
+	nginxPrefixRe    = regexp.MustCompile(`^location ~ \^(\S+)\(/\.\*\)\?\$ \{ return (\d{3})(?: (\S+?)\$1)?; \}$`)
+	nginxUnquoteRe   = regexp.MustCompile(`\\(.)`)
+)
+
+func (f format) Valid() bool {
+	return f == formatCSV || f == formatJSON || f == formatNginx
+}
+
+// writeDefinitions writes the definitions sorted by dimension and source in the format
+func writeDefinitions(w io.Writer, f format, defs []*storex.RedirectDefinition) error {
+	switch f {
+	case formatCSV:
+		return writeCSV(w, defs)
+	case formatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(defs)
+	case formatNginx:
+		return writeNginx(w, defs)
+	default:
+		return fmt.Errorf("unknown format %q", f)
+	}
+}
+
+// readDefinitions reads the definitions in the format, definitions without a dimension get the default dimension
+func readDefinitions(r io.Reader, f format, dimension storex.Dimension) ([]*importedDefinition, error) {
+	var (
+		defs []*importedDefinition
+		err  error
+	)
+
+	switch f {
+	case formatCSV:
+		defs, err = readCSV(r)
+	case formatJSON:
+		defs, err = readJSON(r)
+	case formatNginx:
+		defs, err = readNginx(r)
+	default:
+		err = fmt.Errorf("unknown format %q", f)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, def := range defs {
+		if def.Dimension == "" {
+			def.Dimension = dimension
+		}
+
+		if def.Dimension == "" || def.Source == "" {
+			return nil, fmt.Errorf("redirect %d: missing dimension or source", i+1)
+		}
+
+		if def.RedirectionType == "" {
+			def.RedirectionType = storex.RedirectionTypeManual
+		}
+	}
+
+	return defs, nil
+}
+
+// apply returns a copy of the current definition with the imported columns. An imported target replaces
+// the intended target of the current definition unless it is the same, files without the original target
+// contain the intended target, see writeCSV.
+func (d *importedDefinition) apply(current *storex.RedirectDefinition) *storex.RedirectDefinition {
+	def := *current
+
+	switch {
+	case slices.Contains(d.columns, "originalTarget"):
+		def.Target, def.OriginalTarget = d.Target, d.OriginalTarget
+	case slices.Contains(d.columns, "target") && d.Target != current.IntendedTarget():
+		def.SetIntendedTarget(d.Target)
+	}
+
+	for _, column := range d.columns {
+		switch column {
+		case "code":
+			def.Code = d.Code
+		case "matchType":
+			def.MatchType = d.MatchType
+		case "redirectType":
+			def.RedirectionType = d.RedirectionType
+		case "respectParams":
+			def.RespectParams = d.RespectParams
+		case "transferParams":
+			def.TransferParams = d.TransferParams
+		case "paramPolicy":
+			def.ParamPolicy = d.ParamPolicy
+		case "contentId":
+			def.ContentID = d.ContentID
+		case "stale":
+			def.Stale = d.Stale
+		case "locked":
+			def.Locked = d.Locked
+		case "noFlatten":
+			def.NoFlatten = d.NoFlatten
+		case "needsReview":
+			def.NeedsReview = d.NeedsReview
+		}
+	}
+
+	return &def
+}
+
+// readJSON reads the definitions written by writeDefinitions, the columns are the keys of each definition
+func readJSON(r io.Reader) ([]*importedDefinition, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	defs := make([]*importedDefinition, 0, len(raw))
+
+	for i, data := range raw {
+		var (
+			def  storex.RedirectDefinition
+			keys map[string]json.RawMessage
+		)
+
+		if err := json.Unmarshal(data, &def); err != nil {
+			return nil, fmt.Errorf("redirect %d: %w", i+1, err)
+		} else if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, fmt.Errorf("redirect %d: %w", i+1, err)
+		}
+
+		columns := make([]string, 0, len(keys))
+		for key := range keys {
+			if column, ok := jsonColumns[key]; ok {
+				key = column
+			}
+
+			columns = append(columns, key)
+		}
+
+		slices.Sort(columns)
+		defs = append(defs, &importedDefinition{RedirectDefinition: &def, columns: columns})
+	}
+
+	return defs, nil
+}
+
+// writeCSV writes a line per definition with the intended target, the original target has no column
+func writeCSV(w io.Writer, defs []*storex.RedirectDefinition) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, def := range defs {
+		if err := writer.Write([]string{
+			string(def.Dimension),
+			string(def.Source),
+			string(def.IntendedTarget()),
+			strconv.Itoa(int(def.Code)),
+			string(def.MatchType),
+			string(def.RedirectionType),
+			strconv.FormatBool(def.RespectParams),
+			strconv.FormatBool(def.TransferParams),
+			strconv.FormatBool(def.Stale),
+			strconv.FormatBool(def.Locked),
+			strconv.FormatBool(def.NoFlatten),
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func readCSV(r io.Reader) ([]*importedDefinition, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	imported := []string{}
+
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		columns[name] = i
+
+		if slices.Contains(csvHeader, name) {
+			imported = append(imported, name)
+		}
+	}
+
+	for _, required := range []string{"source", "code"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	defs := []*importedDefinition{}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return defs, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		value := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+
+			return ""
+		}
+		flag := func(name string) (bool, error) {
+			if v := value(name); v != "" {
+				return strconv.ParseBool(v)
+			}
+
+			return false, nil
+		}
+
+		code, err := strconv.Atoi(value("code"))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid code: %w", line, err)
+		}
+
+		def := &storex.RedirectDefinition{
+			Dimension:       storex.Dimension(value("dimension")),
+			Source:          storex.RedirectSource(value("source")),
+			Target:          storex.RedirectTarget(value("target")),
+			Code:            storex.RedirectCode(code),
+			MatchType:       storex.MatchType(value("matchType")),
+			RedirectionType: storex.RedirectionType(value("redirectType")),
+		}
+
+		for name, field := range map[string]*bool{
+			"respectParams":  &def.RespectParams,
+			"transferParams": &def.TransferParams,
+			"stale":          &def.Stale,
+			"locked":         &def.Locked,
+			"noFlatten":      &def.NoFlatten,
+		} {
+			if *field, err = flag(name); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", line, name, err)
+			}
+		}
+
+		defs = append(defs, &importedDefinition{RedirectDefinition: def, columns: imported})
+	}
+}
+
+// writeNginx writes a location block per definition with the intended target, grouped by dimension comments.
+// Stale definitions and sources with query parameters have no nginx equivalent and are skipped.
+func writeNginx(w io.Writer, defs []*storex.RedirectDefinition) error {
+	bw := bufio.NewWriter(w)
+
+	var dimension storex.Dimension
+
+	for _, def := range defs {
+		if def.Stale || strings.Contains(string(def.Source), "?") {
+			continue
+		}
+
+		if def.Dimension != dimension {
+			dimension = def.Dimension
+			if _, err := fmt.Fprintf(bw, "# dimension: %s\n", dimension); err != nil {
+				return err
+			}
+		}
+
+		target := ""
+		if def.Target != "" && def.Code.IsRedirection() {
+			target = " " + string(def.IntendedTarget())
+		}
+
+		var err error
+		if def.IsPrefix() {
+			if target != "" {
+				target += "$1"
+			}
+
+			_, err = fmt.Fprintf(bw, "location ~ ^%s(/.*)?$ { return %d%s; }\n", regexp.QuoteMeta(string(def.Source)), def.Code, target)
+		} else {
+			_, err = fmt.Fprintf(bw, "location = %s { return %d%s; }\n", def.Source, def.Code, target)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// readNginx reads the location blocks written by writeNginx, other lines are ignored
+func readNginx(r io.Reader) ([]*importedDefinition, error) {
+	defs := []*importedDefinition{}
+	scanner := bufio.NewScanner(r)
+
+	var dimension storex.Dimension
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := nginxDimensionRe.FindStringSubmatch(line); m != nil {
+			dimension = storex.Dimension(m[1])
+			continue
+		}
+
+		def := &storex.RedirectDefinition{Dimension: dimension}
+
+		m := nginxExactRe.FindStringSubmatch(line)
+		if m == nil {
+			if m = nginxPrefixRe.FindStringSubmatch(line); m == nil {
+				continue
+			}
+
+			m[1] = nginxUnquoteRe.ReplaceAllString(m[1], "$1")
+			def.MatchType = storex.MatchTypePrefix
+		}
+
+		code, err := strconv.Atoi(m[2])
+		if err != nil {
+			return nil, err
+		}
+
+		def.Source = storex.RedirectSource(m[1])
+		def.Code = storex.RedirectCode(code)
+		def.Target = storex.RedirectTarget(m[3])
+		defs = append(defs, &importedDefinition{RedirectDefinition: def, columns: nginxColumns})
+	}
+
+	return defs, scanner.Err()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDefinitions() []*storex.RedirectDefinition {
+	return []*storex.RedirectDefinition{
+		{Dimension: "de", Source: "/gone", Code: storex.RedirectCodeGone, RedirectionType: storex.RedirectionTypeManual},
+		{Dimension: "de", Source: "/old", Target: "/new", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+		{Dimension: "fr", Source: "/shop.v1", Target: "/shop", Code: storex.RedirectCodeFound, MatchType: storex.MatchTypePrefix, RedirectionType: storex.RedirectionTypeManual},
+	}
+}
+
+func definitionsOf(imported []*importedDefinition) []*storex.RedirectDefinition {
+	defs := make([]*storex.RedirectDefinition, 0, len(imported))
+	for _, def := range imported {
+		defs = append(defs, def.RedirectDefinition)
+	}
+
+	return defs
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	for _, f := range []format{formatCSV, formatJSON, formatNginx} {
+		t.Run(string(f), func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			require.NoError(t, writeDefinitions(&buf, f, testDefinitions()))
+
+			defs, err := readDefinitions(&buf, f, "")
+			require.NoError(t, err)
+			assert.Equal(t, testDefinitions(), definitionsOf(defs))
+		})
+	}
+}
+
+func TestWriteNginx(t *testing.T) {
+	t.Parallel()
+
+	defs := append(testDefinitions(),
+		&storex.RedirectDefinition{Dimension: "fr", Source: "/query?a=1", Target: "/x", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "fr", Source: "/stale", Target: "/x", Code: storex.RedirectCodePermanent, Stale: true},
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeNginx(&buf, defs))
+	assert.Equal(t, strings.Join([]string{
+		"# dimension: de",
+		"location = /gone { return 410; }",
+		"location = /old { return 301 /new; }",
+		"# dimension: fr",
+		`location ~ ^/shop\.v1(/.*)?$ { return 302 /shop$1; }`,
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestReadCSV(t *testing.T) {
+	t.Parallel()
+
+	defs, err := readDefinitions(strings.NewReader("source,target,code,unknown\n/a,/b,301,x\n"), formatCSV, "de")
+	require.NoError(t, err)
+	assert.Equal(t, []*storex.RedirectDefinition{
+		{Dimension: "de", Source: "/a", Target: "/b", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+	}, definitionsOf(defs))
+	assert.Equal(t, []string{"source", "target", "code"}, defs[0].columns)
+
+	_, err = readDefinitions(strings.NewReader("source,target,code\n/a,/b,301\n"), formatCSV, "")
+	require.Error(t, err)
+
+	_, err = readDefinitions(strings.NewReader("source,target\n/a,/b\n"), formatCSV, "de")
+	require.Error(t, err)
+}
+
+func TestReadJSON_Columns(t *testing.T) {
+	t.Parallel()
+
+	defs, err := readDefinitions(strings.NewReader(`[{"dimension":"de","source":"/a","target":"/b","code":301,"respectparams":true}]`), formatJSON, "")
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+	assert.Equal(t, []string{"code", "dimension", "respectParams", "source", "target"}, defs[0].columns)
+	assert.True(t, defs[0].RespectParams)
+}
+
+func TestImportedDefinition_Apply(t *testing.T) {
+	t.Parallel()
+
+	current := &storex.RedirectDefinition{
+		ID: "1", ContentID: "content", Dimension: "de", Source: "/a", Target: "/c", OriginalTarget: "/b",
+		Code: storex.RedirectCodePermanent, ParamPolicy: &storex.ParamPolicy{}, NeedsReview: true,
+		RedirectionType: storex.RedirectionTypeAutomatic, Obsolete: "2024-05-01T00:00:00.000Z",
+	}
+
+	// the intended target is kept, the flattened target with it
+	unchanged := &importedDefinition{
+		RedirectDefinition: &storex.RedirectDefinition{Dimension: "de", Source: "/a", Target: "/b", Code: storex.RedirectCodePermanent},
+		columns:            []string{"source", "target", "code"},
+	}
+	assert.Equal(t, current, unchanged.apply(current))
+
+	changed := &importedDefinition{
+		RedirectDefinition: &storex.RedirectDefinition{Dimension: "de", Source: "/a", Target: "/d", Code: storex.RedirectCodeFound, Locked: true},
+		columns:            []string{"source", "target", "code", "locked"},
+	}
+	expected := *current
+	expected.Target, expected.OriginalTarget = "/d", ""
+	expected.Code, expected.Locked = storex.RedirectCodeFound, true
+	assert.Equal(t, &expected, changed.apply(current))
+	assert.Equal(t, storex.RedirectTarget("/c"), current.Target)
+}
+
+func TestWriteCSV_IntendedTarget(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, writeCSV(&buf, []*storex.RedirectDefinition{
+		{Dimension: "de", Source: "/a", Target: "/c", OriginalTarget: "/b", Code: storex.RedirectCodePermanent},
+	}))
+	assert.Contains(t, buf.String(), "de,/a,/b,301,")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	utilsx "github.com/foomo/redirects/v2/domain/redirectdefinition/utils"
+)
+
+// lintKind of a lint issue
+type lintKind string
+
+const (
+	lintKindCycle         lintKind = "cycle"
+	lintKindChain         lintKind = "chain"
+	lintKindInvalidCode   lintKind = "invalid-code"
+	lintKindMissingTarget lintKind = "missing-target"
+)
+
+// lintIssue of a redirect definition
+type lintIssue struct {
+	Kind      lintKind
+	Dimension storex.Dimension
+	Source    storex.RedirectSource
+	Message   string
+}
+
+func (i lintIssue) String() string {
+	return fmt.Sprintf("%s\t%s\t%s\t%s", i.Kind, i.Dimension, i.Source, i.Message)
+}
+
+// lintRedirects reports cycles, chains which were not flattened and invalid codes,
+// the issues are sorted by dimension and source
+func lintRedirects(redirects map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition) []lintIssue {
+	issues := []lintIssue{}
+
+	for dimension, bySource := range redirects {
+		for source, def := range bySource {
+			add := func(kind lintKind, format string, args ...any) {
+				issues = append(issues, lintIssue{Kind: kind, Dimension: dimension, Source: source, Message: fmt.Sprintf(format, args...)})
+			}
+
+			if !def.Code.Valid() {
+				add(lintKindInvalidCode, "code %d is not supported", def.Code)
+			}
+
+			if !def.Code.IsRedirection() {
+				continue
+			}
+
+			if def.Target == "" {
+				add(lintKindMissingTarget, "code %d without target", def.Code)
+				continue
+			}
+
+			next, nextTarget := nextHop(def.Target, bySource)
+
+			switch {
+			case utilsx.HasCycle(source, def.Target, bySource):
+				add(lintKindCycle, "%s → %s runs into a cycle", source, def.Target)
+			case next != nil && !def.NoFlatten && !next.NoFlatten:
+				// hops with NoFlatten are kept as chains on purpose
+				add(lintKindChain, "%s → %s → %s", source, def.Target, nextTarget)
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Dimension != issues[j].Dimension {
+			return issues[i].Dimension < issues[j].Dimension
+		}
+
+		if issues[i].Source != issues[j].Source {
+			return issues[i].Source < issues[j].Source
+		}
+
+		return issues[i].Kind < issues[j].Kind
+	})
+
+	return issues
+}
+
+// nextHop returns the definition the target is redirected by and its target for the target,
+// either the definition of the target itself or the longest prefix definition above it as in the flattening
+func nextHop(target storex.RedirectTarget, bySource map[storex.RedirectSource]*storex.RedirectDefinition) (*storex.RedirectDefinition, storex.RedirectTarget) {
+	if next, ok := bySource[storex.RedirectSource(target)]; ok {
+		return next, next.Target
+	}
+
+	path, _, _ := strings.Cut(string(target), "?")
+
+	return storex.ResolvePrefix(path, func(source storex.RedirectSource) *storex.RedirectDefinition {
+		return bySource[source]
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+)
+
+// staticBackend serves fixed redirects, writes are not supported
+type staticBackend struct {
+	backend
+	redirects map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition
+}
+
+func (b *staticBackend) Redirects(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	return b.redirects, nil
+}
+
+func newStaticBackend(defs ...*storex.RedirectDefinition) *staticBackend {
+	b := &staticBackend{redirects: map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{}}
+	for _, def := range defs {
+		if _, ok := b.redirects[def.Dimension]; !ok {
+			b.redirects[def.Dimension] = map[storex.RedirectSource]*storex.RedirectDefinition{}
+		}
+
+		b.redirects[def.Dimension][def.Source] = def
+	}
+
+	return b
+}
+
+func TestLintRedirects(t *testing.T) {
+	t.Parallel()
+
+	b := newStaticBackend(
+		&storex.RedirectDefinition{Dimension: "de", Source: "/a", Target: "/b", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "de", Source: "/b", Target: "/c", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "de", Source: "/x", Target: "/y", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "de", Source: "/y", Target: "/x", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "fr", Source: "/a", Target: "/b", Code: 303},
+		&storex.RedirectDefinition{Dimension: "fr", Source: "/e", Code: storex.RedirectCodeFound},
+		&storex.RedirectDefinition{Dimension: "fr", Source: "/gone", Code: storex.RedirectCodeGone},
+		// prefix chains are resolved as in the flattening
+		&storex.RedirectDefinition{Dimension: "it", Source: "/old", Target: "/shop/shoes", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "it", Source: "/shop", Target: "/store", Code: storex.RedirectCodePermanent, MatchType: storex.MatchTypePrefix},
+		// chains with NoFlatten are kept on purpose
+		&storex.RedirectDefinition{Dimension: "nl", Source: "/a", Target: "/b", Code: storex.RedirectCodePermanent, NoFlatten: true},
+		&storex.RedirectDefinition{Dimension: "nl", Source: "/b", Target: "/c", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "nl", Source: "/c", Target: "/d", Code: storex.RedirectCodePermanent, NoFlatten: true},
+	)
+
+	issues := lintRedirects(b.redirects)
+	kinds := make([]string, 0, len(issues))
+
+	for _, issue := range issues {
+		kinds = append(kinds, string(issue.Dimension)+" "+string(issue.Source)+" "+string(issue.Kind))
+	}
+
+	assert.Equal(t, []string{
+		"de /a chain",
+		"de /x cycle",
+		"de /y cycle",
+		"fr /a invalid-code",
+		"fr /e missing-target",
+		"it /old chain",
+	}, kinds)
+}
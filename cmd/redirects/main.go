@@ -0,0 +1,167 @@
+// Command redirects operates the redirect definitions, either directly on the Mongo repository
+// or through the gotsrpc internal and admin services.
+//
+//	redirects [options] <command> [command options]
+//
+// Run redirects -h for the options and commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+type (
+	// env of a running command
+	env struct {
+		l       *zap.Logger
+		backend backend
+		stdin   io.Reader
+		stdout  io.Writer
+		stderr  io.Writer
+	}
+	// command of the tool
+	command struct {
+		name  string
+		usage string
+		run   func(ctx context.Context, e *env, args []string) error
+	}
+)
+
+var commands = []command{
+	{name: "import", usage: "import redirects from a csv, json or nginx file", run: runImport},
+	{name: "export", usage: "export the active redirects as csv, json or nginx file", run: runExport},
+	{name: "lint", usage: "report cycles, chains and invalid codes", run: runLint},
+	{name: "resolve", usage: "resolve <url> -dimension <dimension>, print the redirects the URL is answered with", run: runResolve},
+	{name: "flatten", usage: "flatten the redirect chains of all dimensions (mongo only)", run: runFlatten},
+	{name: "purge-stale", usage: "purge the redirects soft deleted by the consolidation (mongo only)", run: runPurgeStale},
+	{name: "canonicalize", usage: "store the sources and targets of previous versions in canonical form (mongo only)", run: runCanonicalize},
+	{name: "migrate", usage: "migrate the redirects stored by previous versions (mongo only)", run: runMigrate},
+	{name: "snapshot", usage: "snapshot the redirects of all dimensions or of a single one", run: runSnapshot},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			_, _ = fmt.Fprintln(os.Stderr, "error:", err)
+		}
+
+		stop()
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("redirects", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var (
+		mongoURI    = fs.String("mongo", os.Getenv("REDIRECTS_MONGO_URI"), "Mongo URI including the database, e.g. mongodb://localhost:27017/redirects")
+		natsURI     = fs.String("nats", os.Getenv("REDIRECTS_NATS_URI"), "NATS URI to publish the update signal to after changes with -mongo")
+		internalURL = fs.String("internal", os.Getenv("REDIRECTS_INTERNAL_URL"), "base URL of the gotsrpc internal service, used for reading")
+		adminURL    = fs.String("admin", os.Getenv("REDIRECTS_ADMIN_URL"), "base URL of the gotsrpc admin service, used for writing")
+		site        = fs.String("site", os.Getenv("REDIRECTS_SITE"), "site the admin service prefixes the dimension of created redirects with")
+		user        = fs.String("user", "redirects-cli", "user recorded as last updater with -mongo")
+		verbose     = fs.Bool("v", false, "log to stderr")
+	)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "usage: redirects [options] <command> [command options]\n\noptions:\n")
+		fs.PrintDefaults()
+		_, _ = fmt.Fprintf(stderr, "\ncommands:\n")
+
+		for _, cmd := range commands {
+			_, _ = fmt.Fprintf(stderr, "  %-12s %s\n", cmd.name, cmd.usage)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	var cmd *command
+
+	for i := range commands {
+		if commands[i].name == fs.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+
+	if cmd == nil {
+		fs.Usage()
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+
+	l := zap.NewNop()
+
+	if *verbose {
+		var err error
+		if l, err = zap.NewDevelopment(); err != nil {
+			return err
+		}
+	}
+
+	var b backend
+
+	switch {
+	case *mongoURI != "":
+		mb, err := newMongoBackend(ctx, l, *mongoURI, *natsURI, *user)
+		if err != nil {
+			return err
+		}
+
+		b = mb
+	case *internalURL != "" || *adminURL != "":
+		b = newRPCBackend(*internalURL, *adminURL, *site)
+	default:
+		return errors.New("either -mongo or the -internal and -admin service URLs are required")
+	}
+
+	defer func() {
+		_ = b.Close(context.WithoutCancel(ctx))
+	}()
+
+	return cmd.run(ctx, &env{l: l, backend: b, stdin: stdin, stdout: stdout, stderr: stderr}, fs.Args()[1:])
+}
+
+// newFlagSet returns the flag set of the command
+func (e *env) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+
+	return fs
+}
+
+// parseArgs parses the flags of the command, they may also follow its arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
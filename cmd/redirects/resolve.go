@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"go.uber.org/zap"
+)
+
+// resolveHop is a redirect the URL was answered with
+type resolveHop struct {
+	Request  string
+	Code     storex.RedirectCode
+	Response storex.RedirectResponse
+}
+
+// resolveURL follows the redirects of the dimension for the URL the way the redirects provider answers them,
+// it stops at the first URL without redirect, a non-3xx code, a loop or after maxHops
+func resolveURL(ctx context.Context, l *zap.Logger, b backend, dimension storex.Dimension, rawURL string, maxHops int) ([]resolveHop, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	provider := providerx.NewProvider(l, redirectsProviderFunc(b), func(_ *http.Request) (storex.Dimension, error) {
+		return dimension, nil
+	}, nil)
+	if err := provider.Start(ctx); err != nil {
+		return nil, err
+	}
+
+	hops := []resolveHop{}
+	visited := map[string]struct{}{}
+
+	for current := rawURL; len(hops) < maxHops; {
+		if _, ok := visited[current]; ok {
+			return hops, fmt.Errorf("redirect loop at %s", current)
+		}
+
+		visited[current] = struct{}{}
+
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, current, nil)
+		if err != nil {
+			return hops, err
+		}
+
+		redirect, err := provider.Process(r)
+		if err != nil {
+			return hops, err
+		} else if redirect == nil {
+			return hops, nil
+		}
+
+		hops = append(hops, resolveHop{Request: current, Code: redirect.Code, Response: redirect.Response})
+
+		if !redirect.Code.IsRedirection() {
+			return hops, nil
+		}
+
+		current = string(redirect.Response)
+	}
+
+	return hops, fmt.Errorf("more than %d redirects", maxHops)
+}
+
+func printHops(w io.Writer, rawURL string, hops []resolveHop) {
+	if len(hops) == 0 {
+		_, _ = fmt.Fprintf(w, "%s\tno redirect\n", rawURL)
+		return
+	}
+
+	for _, hop := range hops {
+		if hop.Code.IsRedirection() {
+			_, _ = fmt.Fprintf(w, "%s\t%d\t%s\n", hop.Request, hop.Code, hop.Response)
+		} else {
+			_, _ = fmt.Fprintf(w, "%s\t%d\n", hop.Request, hop.Code)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestResolveURL(t *testing.T) {
+	t.Parallel()
+
+	b := newStaticBackend(
+		&storex.RedirectDefinition{Dimension: "de", Source: "/a", Target: "/b", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "de", Source: "/b", Target: "/c", Code: storex.RedirectCodeFound},
+		&storex.RedirectDefinition{Dimension: "de", Source: "/gone", Code: storex.RedirectCodeGone},
+		&storex.RedirectDefinition{Dimension: "de", Source: "/x", Target: "/y", Code: storex.RedirectCodePermanent},
+		&storex.RedirectDefinition{Dimension: "de", Source: "/y", Target: "/x", Code: storex.RedirectCodePermanent},
+	)
+
+	hops, err := resolveURL(context.Background(), zap.NewNop(), b, "de", "/a", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []resolveHop{
+		{Request: "/a", Code: storex.RedirectCodePermanent, Response: "/b"},
+		{Request: "/b", Code: storex.RedirectCodeFound, Response: "/c"},
+	}, hops)
+
+	hops, err = resolveURL(context.Background(), zap.NewNop(), b, "de", "/gone", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []resolveHop{{Request: "/gone", Code: storex.RedirectCodeGone}}, hops)
+
+	hops, err = resolveURL(context.Background(), zap.NewNop(), b, "fr", "/a", 10)
+	require.NoError(t, err)
+	assert.Empty(t, hops)
+
+	_, err = resolveURL(context.Background(), zap.NewNop(), b, "de", "/x", 10)
+	require.Error(t, err)
+}
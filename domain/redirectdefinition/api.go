@@ -3,6 +3,7 @@ package redirectdefinition
 import (
 	"context"
 	"errors"
+	"io"
 	"time"
 
 	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
@@ -24,7 +25,16 @@ type (
 		getSiteIdentifierProvider                 providerx.SiteIdentifierProviderFunc
 		restrictedSourcesProvider                 providerx.RestrictedSourcesProviderFunc
 		userProvider                              providerx.UserProviderFunc
+		privilegedUserProvider                    providerx.PrivilegedUserProviderFunc
 		isAutomaticRedirectInitiallyStaleProvider providerx.IsAutomaticRedirectInitiallyStaleProviderFunc
+		allowedHostsProvider                      providerx.AllowedHostsProviderFunc
+		deletedContentPolicyProvider              providerx.DeletedContentPolicyProviderFunc
+		autoRedirectPolicyProvider                providerx.AutoRedirectPolicyProviderFunc
+		deletePolicy                              storex.DeletePolicy
+		leaseRepository                           repositoryx.LeaseRepository
+		lockPolicy                                storex.LockPolicy
+		jobRepository                             repositoryx.JobRepository
+		jobPolicy                                 storex.JobPolicy
 	}
 	Option func(api *API)
 )
@@ -40,7 +50,10 @@ func NewAPI(
 		repo:                      repo,
 		restrictedSourcesProvider: defaultRestrictedSourcesProvider,
 		userProvider:              defaultUserProvider,
+		privilegedUserProvider:    defaultPrivilegedUserProvider,
 		isAutomaticRedirectInitiallyStaleProvider: defaultIsAutomaticRedirectInitiallyStaleProvider,
+		allowedHostsProvider:                      defaultAllowedHostsProvider,
+		deletedContentPolicyProvider:              defaultDeletedContentPolicyProvider,
 	}
 	if inst.l == nil {
 		return nil, errors.New("missing logger")
@@ -50,21 +63,32 @@ func NewAPI(
 		opt(inst)
 	}
 
+	if err := inst.deletePolicy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if inst.autoRedirectPolicyProvider == nil {
+		inst.autoRedirectPolicyProvider = inst.defaultAutoRedirectPolicyProvider
+	}
+
 	inst.cmd = Commands{
 		CreateRedirects: commandx.CreateRedirectsHandlerComposed(
 			commandx.CreateRedirectsHandler(inst.repo),
-			commandx.CreateRedirectsConsolidateMiddleware(repo, false),
-			commandx.CreateRedirectsAutoCreateMiddleware(inst.isAutomaticRedirectInitiallyStaleProvider()),
+			commandx.CreateRedirectsBatchMiddleware(repo),
+			commandx.CreateRedirectsConsolidateMiddleware(repo, inst.deletePolicy),
+			commandx.CreateRedirectsDeletedContentMiddleware(inst.autoRedirectPolicyProvider, inst.deletedContentPolicyProvider),
+			commandx.CreateRedirectsAutoCreateMiddleware(inst.autoRedirectPolicyProvider),
 			commandx.CreateRedirectsPublishMiddleware(updateSignal, repo),
+			commandx.CreateRedirectsLockMiddleware(inst.leaseRepository, inst.lockPolicy),
 		),
 		CreateRedirect: commandx.CreateRedirectHandlerComposed(
 			commandx.CreateRedirectHandler(inst.repo),
-			commandx.ValidateRedirectMiddleware(inst.restrictedSourcesProvider, inst.repo),
+			commandx.ValidateRedirectMiddleware(inst.restrictedSourcesProvider, inst.allowedHostsProvider, inst.repo),
 			commandx.CreateRedirectPublishMiddleware(updateSignal, repo),
 		),
 		UpdateRedirect: commandx.UpdateRedirectHandlerComposed(
 			commandx.UpdateRedirectHandler(inst.repo),
-			commandx.ValidateUpdateRedirectMiddleware(inst.restrictedSourcesProvider, inst.repo),
+			commandx.ValidateUpdateRedirectMiddleware(inst.restrictedSourcesProvider, inst.allowedHostsProvider, inst.repo),
 			commandx.UpdateRedirectPublishMiddleware(updateSignal, repo),
 		),
 		DeleteRedirect: commandx.DeleteRedirectHandlerComposed(
@@ -75,6 +99,31 @@ func NewAPI(
 			commandx.UpdateRedirectsStateHandler(inst.repo),
 			commandx.UpdateRedirectsStatePublishMiddleware(updateSignal, repo),
 		),
+		CanonicalizeRedirects: commandx.CanonicalizeRedirectsHandlerComposed(
+			commandx.CanonicalizeRedirectsHandler(inst.repo),
+			commandx.CanonicalizeRedirectsPublishMiddleware(updateSignal),
+		),
+		FlattenAllRedirects: commandx.FlattenAllRedirectsHandlerComposed(
+			commandx.FlattenAllRedirectsHandler(inst.repo),
+			commandx.FlattenAllRedirectsPublishMiddleware(updateSignal),
+			commandx.FlattenAllRedirectsLockMiddleware(inst.leaseRepository, inst.lockPolicy),
+		),
+		PurgeObsoleteRedirects: commandx.PurgeObsoleteRedirectsHandlerComposed(
+			commandx.PurgeObsoleteRedirectsHandler(inst.repo),
+		),
+		RollbackBatch: commandx.RollbackBatchHandlerComposed(
+			commandx.RollbackBatchHandler(inst.repo),
+			commandx.RollbackBatchPublishMiddleware(updateSignal, repo),
+			commandx.RollbackBatchLockMiddleware(inst.leaseRepository, inst.lockPolicy),
+		),
+		CreateSnapshot: commandx.CreateSnapshotHandlerComposed(
+			commandx.CreateSnapshotHandler(inst.repo),
+		),
+		RestoreSnapshot: commandx.RestoreSnapshotHandlerComposed(
+			commandx.RestoreSnapshotHandler(inst.repo),
+			commandx.RestoreSnapshotPublishMiddleware(updateSignal),
+			commandx.RestoreSnapshotLockMiddleware(inst.leaseRepository, inst.lockPolicy),
+		),
 	}
 	inst.qry = Queries{
 		GetRedirects: queryx.GetRedirectsHandlerComposed(
@@ -82,6 +131,16 @@ func NewAPI(
 		),
 		Search: queryx.SearchHandlerComposed(
 			queryx.SearchHandler(inst.repo),
+			queryx.SearchPrivilegedModeMiddleware(inst.privilegedUserProvider),
+		),
+		GetBatches: queryx.GetBatchesHandlerComposed(
+			queryx.GetBatchesHandler(inst.repo),
+		),
+		GetSnapshots: queryx.GetSnapshotsHandlerComposed(
+			queryx.GetSnapshotsHandler(inst.repo),
+		),
+		GetSnapshot: queryx.GetSnapshotHandlerComposed(
+			queryx.GetSnapshotHandler(inst.repo),
 		),
 	}
 
@@ -92,8 +151,16 @@ func NewAPI(
 // ~ Public methods
 // ------------------------------------------------------------------------------------------------
 
-func (a *API) CreateRedirects(ctx context.Context, cmd commandx.CreateRedirects) error {
-	return a.cmd.CreateRedirects(ctx, a.l, cmd)
+func (a *API) CreateRedirects(ctx context.Context, cmd commandx.CreateRedirects) (*commandx.CreateRedirectsResult, error) {
+	if cmd.Result == nil {
+		cmd.Result = &commandx.CreateRedirectsResult{}
+	}
+
+	if err := a.cmd.CreateRedirects(ctx, a.l, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Result, nil
 }
 
 func (a *API) CreateRedirect(ctx context.Context, cmd commandx.CreateRedirect) error {
@@ -112,8 +179,157 @@ func (a *API) DeleteRedirect(ctx context.Context, cmd commandx.DeleteRedirect) e
 	return a.cmd.DeleteRedirect(ctx, a.l, cmd)
 }
 
+// CanonicalizeRedirects migrates the stored sources and targets to their canonical form
+// and returns the changed redirects, it is safe to run repeatedly
+func (a *API) CanonicalizeRedirects(ctx context.Context, cmd commandx.CanonicalizeRedirects) (*commandx.CanonicalizeRedirectsResult, error) {
+	if cmd.Result == nil {
+		cmd.Result = &commandx.CanonicalizeRedirectsResult{}
+	}
+
+	if err := a.cmd.CanonicalizeRedirects(ctx, a.l, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Result, nil
+}
+
+// MigrateUpdatedToDate converts the updated timestamps stored as strings by previous versions into dates
+// and returns the number of converted redirects, it is safe to run repeatedly
+func (a *API) MigrateUpdatedToDate(ctx context.Context) (int64, error) {
+	return a.repo.MigrateUpdatedToDate(ctx)
+}
+
+// FlattenAllRedirects flattens the redirect chains of all dimensions and returns the changed redirects,
+// commands changing redirects only flatten the chains they affect
+func (a *API) FlattenAllRedirects(ctx context.Context, cmd commandx.FlattenAllRedirects) (*commandx.FlattenAllRedirectsResult, error) {
+	if cmd.Result == nil {
+		cmd.Result = &commandx.FlattenAllRedirectsResult{}
+	}
+
+	if err := a.cmd.FlattenAllRedirects(ctx, a.l, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Result, nil
+}
+
+// PurgeObsoleteRedirects deletes the redirects soft deleted before the cutoff and returns the purge record
+func (a *API) PurgeObsoleteRedirects(ctx context.Context, cmd commandx.PurgeObsoleteRedirects) (*storex.PurgeRecord, error) {
+	if cmd.Result == nil {
+		cmd.Result = &storex.PurgeRecord{}
+	}
+
+	if err := a.cmd.PurgeObsoleteRedirects(ctx, a.l, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Result, nil
+}
+
+// RunPurgeJob purges the obsolete redirects older than the retention period of the delayed delete
+// strategy in the given interval until the context is done. It returns immediately for other strategies.
+func (a *API) RunPurgeJob(ctx context.Context, interval time.Duration) error {
+	if a.deletePolicy.Strategy != storex.DeleteStrategyDelayed {
+		a.l.Info("purge job not started, delete strategy is not delayed")
+		return nil
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := a.PurgeObsoleteRedirects(ctx, commandx.PurgeObsoleteRedirects{
+			Cutoff: a.deletePolicy.Cutoff(time.Now()),
+		}); err != nil {
+			a.l.Error("failed to purge obsolete redirects", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RollbackBatch restores the redirects changed or deleted by the batch of an automatic run and deletes
+// the ones it created, redirects changed after the batch are skipped
+func (a *API) RollbackBatch(ctx context.Context, cmd commandx.RollbackBatch) (*commandx.RollbackBatchResult, error) {
+	if cmd.Result == nil {
+		cmd.Result = &commandx.RollbackBatchResult{}
+	}
+
+	if cmd.User == "" {
+		cmd.User = a.userProvider(ctx)
+	}
+
+	if err := a.cmd.RollbackBatch(ctx, a.l, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Result, nil
+}
+
+// GetBatches returns the latest batches of the automatic runs first
+func (a *API) GetBatches(ctx context.Context, qry queryx.GetBatches) ([]*storex.Batch, error) {
+	return a.qry.GetBatches(ctx, a.l, qry)
+}
+
+// CreateSnapshot snapshots all definitions or the ones of a dimension, the snapshot is stored
+// or written to the writer of the command
+func (a *API) CreateSnapshot(ctx context.Context, cmd commandx.CreateSnapshot) (*storex.Snapshot, error) {
+	if cmd.Result == nil {
+		cmd.Result = &storex.Snapshot{}
+	}
+
+	if cmd.User == "" {
+		cmd.User = a.userProvider(ctx)
+	}
+
+	if err := a.cmd.CreateSnapshot(ctx, a.l, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Result, nil
+}
+
+// RestoreSnapshot restores the definitions in the scope of the snapshot and returns the diff,
+// with DryRun only the diff is returned
+func (a *API) RestoreSnapshot(ctx context.Context, cmd commandx.RestoreSnapshot) (*storex.DefinitionsDiff, error) {
+	if cmd.Result == nil {
+		cmd.Result = &storex.DefinitionsDiff{}
+	}
+
+	if err := a.cmd.RestoreSnapshot(ctx, a.l, cmd); err != nil {
+		return nil, err
+	}
+
+	return cmd.Result, nil
+}
+
+// GetSnapshots returns the stored snapshots without their definitions
+func (a *API) GetSnapshots(ctx context.Context) ([]*storex.Snapshot, error) {
+	return a.qry.GetSnapshots(ctx, a.l, queryx.GetSnapshots{})
+}
+
+// ExportSnapshot writes the stored snapshot as file
+func (a *API) ExportSnapshot(ctx context.Context, name string, w io.Writer) error {
+	snapshot, err := a.qry.GetSnapshot(ctx, a.l, queryx.GetSnapshot{Name: name})
+	if err != nil {
+		return err
+	}
+
+	return storex.WriteSnapshot(w, snapshot)
+}
+
+// GetRedirects returns all active redirects
 func (a *API) GetRedirects(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
-	return a.qry.GetRedirects(ctx, a.l)
+	return a.qry.GetRedirects(ctx, a.l, queryx.GetRedirects{})
+}
+
+// GetFilteredRedirects returns the redirects in the scope of the filter
+func (a *API) GetFilteredRedirects(ctx context.Context, qry queryx.GetRedirects) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	return a.qry.GetRedirects(ctx, a.l, qry)
 }
 
 func (a *API) Search(ctx context.Context, qry queryx.Search) (*storex.PaginatedResult, error) {
@@ -0,0 +1,56 @@
+package redirectdefinition_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/foomo/contentserver/content"
+	redirectdefinitionx "github.com/foomo/redirects/v2/domain/redirectdefinition"
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	repositorytestx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository/repositorytest"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestAPI(t *testing.T, repo *repositorytestx.RedirectsDefinitionRepository, opts ...redirectdefinitionx.Option) *redirectdefinitionx.API {
+	t.Helper()
+
+	api, err := redirectdefinitionx.NewAPI(zap.NewNop(), repo, nil, opts...)
+	require.NoError(t, err)
+
+	return api
+}
+
+func contentState(uris map[string]string) map[string]*content.RepoNode {
+	root := &content.RepoNode{ID: "root", URI: "/", Nodes: map[string]*content.RepoNode{}}
+	for id, uri := range uris {
+		root.Nodes[id] = &content.RepoNode{ID: id, URI: uri, MimeType: "application/x-node"}
+	}
+
+	return map[string]*content.RepoNode{"de": root}
+}
+
+// the automatic pipeline flattens the chains created by the moves of a run
+func TestCreateRedirectsFlattensChains(t *testing.T) {
+	t.Parallel()
+
+	repo := repositorytestx.NewRedirectsDefinitionRepository(
+		&storex.RedirectDefinition{ID: "a", Dimension: "de", Source: "/a", Target: "/b", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeAutomatic},
+	)
+	api := newTestAPI(t, repo)
+
+	result, err := api.CreateRedirects(context.Background(), commandx.CreateRedirects{
+		OldState: contentState(map[string]string{"b": "/b", "c": "/c"}),
+		NewState: contentState(map[string]string{"b": "/c", "c": "/d"}),
+	})
+	require.NoError(t, err)
+	assert.Positive(t, result.Upserted)
+
+	for _, source := range []storex.RedirectSource{"/a", "/b", "/c"} {
+		def := repo.BySource("de", source)
+		require.NotNil(t, def, source)
+		assert.Equal(t, storex.RedirectTarget("/d"), def.Target, source)
+	}
+}
@@ -0,0 +1,118 @@
+package redirectcommand
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"time"
+
+	keellog "github.com/foomo/keel/log"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.uber.org/zap"
+)
+
+// CreateRedirectsBatchMiddleware stamps the created and changed redirects of the run with a batch ID
+// and stores their before-images, so that the run can be rolled back with RollbackBatch
+func CreateRedirectsBatchMiddleware(repo repositoryx.RedirectsDefinitionRepository) CreateRedirectsMiddlewareFn {
+	return func(next CreateRedirectsHandlerFn) CreateRedirectsHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) error {
+			ids := make([]*storex.EntityID, 0, len(cmd.RedirectsToUpsert)+len(cmd.RedirectsToDelete))
+			for _, def := range cmd.RedirectsToUpsert {
+				ids = append(ids, &def.ID)
+			}
+
+			for i := range cmd.RedirectsToDelete {
+				ids = append(ids, &cmd.RedirectsToDelete[i])
+			}
+
+			previous, err := repo.FindByIDs(ctx, ids)
+			if err != nil {
+				keellog.WithError(l, err).Error("failed to fetch the before-images of the batch")
+				return err
+			}
+
+			batch := &storex.Batch{
+				ID:   storex.NewEntityID(),
+				Time: storex.NewDateTime(time.Now()),
+			}
+			entries := NewBatchEntries(batch, cmd.RedirectsToUpsert, cmd.RedirectsToDelete, previous)
+
+			if len(entries) == 0 {
+				return next(ctx, l, cmd)
+			}
+
+			// the batch is stored first, a failed run can then be rolled back as well
+			if err := repo.InsertBatch(ctx, batch, entries); err != nil {
+				keellog.WithError(l, err).Error("failed to store batch")
+				return err
+			}
+
+			cmd.Batch = batch.ID
+
+			return next(ctx, l.With(zap.String("batch", string(batch.ID))), cmd)
+		}
+	}
+}
+
+// NewBatchEntries stamps the created and changed definitions with the batch and returns their
+// before-images, unchanged definitions are neither stamped nor recorded
+func NewBatchEntries(
+	batch *storex.Batch,
+	upserts []*storex.RedirectDefinition,
+	deletes []storex.EntityID,
+	previous []*storex.RedirectDefinition,
+) []*storex.BatchEntry {
+	previousByID := make(map[storex.EntityID]*storex.RedirectDefinition, len(previous))
+	for _, def := range previous {
+		previousByID[def.ID] = def
+	}
+
+	entries := []*storex.BatchEntry{}
+	add := func(id storex.EntityID, dimension storex.Dimension, operation storex.BatchOperation, before *storex.RedirectDefinition) {
+		entry := &storex.BatchEntry{Batch: batch.ID, ID: id, Operation: operation, Before: before}
+		entries = append(entries, entry)
+		batch.Add(entry)
+
+		if !slices.Contains(batch.Dimensions, dimension) {
+			batch.Dimensions = append(batch.Dimensions, dimension)
+		}
+	}
+
+	for _, def := range upserts {
+		if def.ID == "" {
+			def.ID = storex.NewEntityID()
+		}
+
+		before, ok := previousByID[def.ID]
+
+		switch {
+		case !ok:
+			add(def.ID, def.Dimension, storex.BatchOperationCreated, nil)
+		case !equalIgnoringBatch(before, def):
+			add(def.ID, def.Dimension, storex.BatchOperationChanged, before)
+		default:
+			continue
+		}
+
+		def.Batch = batch.ID
+	}
+
+	for _, id := range deletes {
+		if before, ok := previousByID[id]; ok {
+			add(id, before.Dimension, storex.BatchOperationDeleted, before)
+		}
+	}
+
+	slices.Sort(batch.Dimensions)
+
+	return entries
+}
+
+// equalIgnoringBatch compares the definitions without their batch
+func equalIgnoringBatch(a, b *storex.RedirectDefinition) bool {
+	x, y := *a, *b
+	x.Batch, y.Batch = "", ""
+
+	return reflect.DeepEqual(x, y)
+}
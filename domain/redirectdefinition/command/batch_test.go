@@ -0,0 +1,71 @@
+package redirectcommand_test
+
+import (
+	"testing"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewBatchEntries(t *testing.T) {
+	t.Parallel()
+
+	previous := []*storex.RedirectDefinition{
+		{ID: "changed", Source: "/a", Target: "/b", Dimension: "de", Batch: "old"},
+		{ID: "unchanged", Source: "/c", Target: "/d", Dimension: "de", Batch: "old"},
+		{ID: "deleted", Source: "/e", Target: "/f", Dimension: "fr"},
+	}
+	upserts := []*storex.RedirectDefinition{
+		{ID: "created", Source: "/g", Target: "/h", Dimension: "de"},
+		{ID: "changed", Source: "/a", Target: "/x", Dimension: "de", Batch: "old"},
+		{ID: "unchanged", Source: "/c", Target: "/d", Dimension: "de", Batch: "old"},
+	}
+
+	batch := &storex.Batch{ID: "batch"}
+	entries := commandx.NewBatchEntries(batch, upserts, []storex.EntityID{"deleted"}, previous)
+
+	require.Len(t, entries, 3)
+	assert.Equal(t, storex.BatchOperationCreated, entries[0].Operation)
+	assert.Nil(t, entries[0].Before)
+	assert.Equal(t, storex.BatchOperationChanged, entries[1].Operation)
+	assert.Equal(t, storex.RedirectTarget("/b"), entries[1].Before.Target)
+	assert.Equal(t, storex.BatchOperationDeleted, entries[2].Operation)
+
+	assert.Equal(t, storex.EntityID("batch"), upserts[0].Batch)
+	assert.Equal(t, storex.EntityID("batch"), upserts[1].Batch)
+	assert.Equal(t, storex.EntityID("old"), upserts[2].Batch)
+
+	assert.Equal(t, []storex.Dimension{"de", "fr"}, batch.Dimensions)
+	assert.Equal(t, 1, batch.Created)
+	assert.Equal(t, 1, batch.Changed)
+	assert.Equal(t, 1, batch.Deleted)
+}
+
+func Test_RollbackBatchEntries(t *testing.T) {
+	t.Parallel()
+
+	entries := []*storex.BatchEntry{
+		{ID: "created", Operation: storex.BatchOperationCreated},
+		{ID: "changed", Operation: storex.BatchOperationChanged, Before: &storex.RedirectDefinition{ID: "changed", Target: "/b", Stale: true}},
+		{ID: "deleted", Operation: storex.BatchOperationDeleted, Before: &storex.RedirectDefinition{ID: "deleted", Target: "/f"}},
+		{ID: "edited", Operation: storex.BatchOperationChanged, Before: &storex.RedirectDefinition{ID: "edited", Target: "/y"}},
+		{ID: "gone", Operation: storex.BatchOperationCreated},
+	}
+	current := []*storex.RedirectDefinition{
+		{ID: "created", Target: "/h", Batch: "batch"},
+		{ID: "changed", Target: "/x", Batch: "batch"},
+		{ID: "edited", Target: "/z"},
+	}
+
+	result := commandx.RollbackBatchEntries("batch", entries, current)
+
+	require.Len(t, result.Restored, 2)
+	assert.Equal(t, storex.RedirectTarget("/b"), result.Restored[0].Target)
+	assert.True(t, result.Restored[0].Stale)
+	assert.Equal(t, storex.EntityID("deleted"), result.Restored[1].ID)
+	require.Len(t, result.Deleted, 1)
+	assert.Equal(t, storex.EntityID("created"), result.Deleted[0].ID)
+	assert.Equal(t, []storex.EntityID{"edited"}, result.Skipped)
+}
@@ -0,0 +1,188 @@
+package redirectcommand
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+
+	keellog "github.com/foomo/keel/log"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	natsx "github.com/foomo/redirects/v2/pkg/nats"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// CanonicalizeRedirects command, migrates stored sources and targets to their canonical form
+	CanonicalizeRedirects struct {
+		// DryRun only logs the changes
+		DryRun bool `json:"dryRun"`
+		// Result is filled by the handler if set
+		Result *CanonicalizeRedirectsResult `json:"-"`
+	}
+	// CanonicalizeRedirectsResult of the command
+	CanonicalizeRedirectsResult struct {
+		Changed []*storex.RedirectDefinition `json:"changed"`
+		// Conflicts are skipped as their canonical source already exists
+		Conflicts []*storex.RedirectDefinition `json:"conflicts"`
+	}
+	// CanonicalizeRedirectsHandlerFn handler
+	CanonicalizeRedirectsHandlerFn func(ctx context.Context, l *zap.Logger, cmd CanonicalizeRedirects) error
+	// CanonicalizeRedirectsMiddlewareFn middleware
+	CanonicalizeRedirectsMiddlewareFn func(next CanonicalizeRedirectsHandlerFn) CanonicalizeRedirectsHandlerFn
+)
+
+// CanonicalizeRedirectsHandler ...
+func CanonicalizeRedirectsHandler(repo repositoryx.RedirectsDefinitionRepository) CanonicalizeRedirectsHandlerFn {
+	return func(ctx context.Context, l *zap.Logger, cmd CanonicalizeRedirects) error {
+		allDefinitions, err := repo.FindAll(ctx, false)
+		if err != nil {
+			keellog.WithError(l, err).Error("failed to fetch definitions")
+			return err
+		}
+
+		changed := []*storex.RedirectDefinition{}
+		allConflicts := []*storex.RedirectDefinition{}
+
+		for dimension, definitions := range allDefinitions {
+			defs, conflicts := CanonicalizeDefinitions(l, definitions)
+			allConflicts = append(allConflicts, conflicts...)
+
+			for _, conflict := range conflicts {
+				l.Warn("skipping definition, its canonical source already exists",
+					zap.String("dimension", string(dimension)),
+					zap.String("id", string(conflict.ID)),
+					zap.String("source", string(conflict.Source)),
+				)
+			}
+
+			changed = append(changed, defs...)
+		}
+
+		l.Info("canonicalized redirect definitions", zap.Int("count", len(changed)), zap.Bool("dryRun", cmd.DryRun))
+
+		if cmd.Result != nil {
+			cmd.Result.Changed = changed
+			cmd.Result.Conflicts = allConflicts
+		}
+
+		if cmd.DryRun || len(changed) == 0 {
+			return nil
+		}
+
+		return repo.UpsertMany(ctx, changed)
+	}
+}
+
+// CanonicalizeDefinitions canonicalizes the sources and targets of the definitions of one dimension.
+// It returns the changed definitions and the definitions skipped because their canonical source
+// collides with another definition.
+func CanonicalizeDefinitions(
+	l *zap.Logger,
+	definitions map[storex.RedirectSource]*storex.RedirectDefinition,
+) ([]*storex.RedirectDefinition, []*storex.RedirectDefinition) {
+	var changed, conflicts []*storex.RedirectDefinition
+
+	// process definitions already in canonical form first, so they keep their source
+	sorted := slices.Collect(maps.Values(definitions))
+	slices.SortFunc(sorted, func(a, b *storex.RedirectDefinition) int {
+		return cmp.Or(
+			cmp.Compare(canonicalRank(a), canonicalRank(b)),
+			cmp.Compare(a.ID, b.ID),
+		)
+	})
+
+	sources := make(map[storex.RedirectSource]struct{}, len(sorted))
+
+	for _, def := range sorted {
+		source, err := def.Source.Canonical()
+		if err != nil {
+			keellog.WithError(l, err).Warn("could not canonicalize source", zap.String("source", string(def.Source)))
+			source = def.Source
+		}
+
+		target, err := def.Target.Canonical()
+		if err != nil {
+			keellog.WithError(l, err).Warn("could not canonicalize target", zap.String("target", string(def.Target)))
+			target = def.Target
+		}
+
+		originalTarget, err := def.OriginalTarget.Canonical()
+		if err != nil {
+			keellog.WithError(l, err).Warn("could not canonicalize original target", zap.String("originalTarget", string(def.OriginalTarget)))
+			originalTarget = def.OriginalTarget
+		}
+
+		if _, exists := sources[source]; exists {
+			conflicts = append(conflicts, def)
+			continue
+		}
+
+		sources[source] = struct{}{}
+
+		if source != def.Source || target != def.Target || originalTarget != def.OriginalTarget {
+			def.Source, def.Target, def.OriginalTarget = source, target, originalTarget
+			changed = append(changed, def)
+		}
+	}
+
+	return changed, conflicts
+}
+
+func canonicalRank(def *storex.RedirectDefinition) int {
+	if source, err := def.Source.Canonical(); err == nil && source == def.Source {
+		return 0
+	}
+
+	return 1
+}
+
+// CanonicalizeRedirectsHandlerComposed returns the handler with middleware applied to it
+func CanonicalizeRedirectsHandlerComposed(handler CanonicalizeRedirectsHandlerFn, middlewares ...CanonicalizeRedirectsMiddlewareFn) CanonicalizeRedirectsHandlerFn {
+	composed := func(next CanonicalizeRedirectsHandlerFn) CanonicalizeRedirectsHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, cmd CanonicalizeRedirects) error {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, cmd)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, cmd CanonicalizeRedirects) error {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, cmd)
+	})
+}
+
+// CanonicalizeRedirectsPublishMiddleware ...
+func CanonicalizeRedirectsPublishMiddleware(updateSignal *natsx.UpdateSignal) CanonicalizeRedirectsMiddlewareFn {
+	return func(next CanonicalizeRedirectsHandlerFn) CanonicalizeRedirectsHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd CanonicalizeRedirects) error {
+			err := next(ctx, l, cmd)
+			if err != nil {
+				return err
+			}
+
+			if cmd.DryRun {
+				return nil
+			}
+
+			err = updateSignal.Publish()
+			if err != nil {
+				return err
+			}
+
+			return nil
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package redirectcommand_test
+
+import (
+	"testing"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_CanonicalizeDefinitions(t *testing.T) {
+	t.Parallel()
+
+	definitions := map[storex.RedirectSource]*storex.RedirectDefinition{
+		"/%C3%BCber":     {ID: "1", Source: "/%C3%BCber", Target: "/about"},
+		"/über":          {ID: "2", Source: "/über", Target: "/about"},
+		"/größe?b=2&a=1": {ID: "3", Source: "/größe?b=2&a=1", Target: "/maße"},
+		"/ok":            {ID: "4", Source: "/ok", Target: "/fine"},
+	}
+
+	changed, conflicts := commandx.CanonicalizeDefinitions(zap.NewNop(), definitions)
+
+	require.Len(t, changed, 1)
+	assert.Equal(t, storex.EntityID("3"), changed[0].ID)
+	assert.Equal(t, storex.RedirectSource("/gr%C3%B6%C3%9Fe?a=1&b=2"), changed[0].Source)
+	assert.Equal(t, storex.RedirectTarget("/ma%C3%9Fe"), changed[0].Target)
+
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, storex.EntityID("2"), conflicts[0].ID)
+}
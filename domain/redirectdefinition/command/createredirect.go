@@ -63,7 +63,7 @@ func CreateRedirectPublishMiddleware(updateSignal *natsx.UpdateSignal, repo repo
 				return err
 			}
 
-			if err := applyFlattening(ctx, l, repo); err != nil {
+			if err := applyFlattening(ctx, l, repo, NewFlattenScope(cmd.RedirectDefinition)); err != nil {
 				return err
 			}
 
@@ -79,10 +79,11 @@ func CreateRedirectPublishMiddleware(updateSignal *natsx.UpdateSignal, repo repo
 
 func ValidateRedirectMiddleware(
 	restrictedSourcesProvider providerx.RestrictedSourcesProviderFunc,
+	allowedHostsProvider providerx.AllowedHostsProviderFunc,
 	repo repositoryx.RedirectsDefinitionRepository) CreateRedirectMiddlewareFn {
 	return func(next CreateRedirectHandlerFn) CreateRedirectHandlerFn {
 		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirect) error {
-			return validateRedirect(ctx, l, repo, restrictedSourcesProvider, cmd.RedirectDefinition, next)
+			return validateRedirect(ctx, l, repo, restrictedSourcesProvider, allowedHostsProvider, cmd.RedirectDefinition, next)
 		}
 	}
 }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/foomo/contentserver/content"
 	keellog "github.com/foomo/keel/log"
@@ -12,6 +14,10 @@ import (
 	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
 	utilsx "github.com/foomo/redirects/v2/domain/redirectdefinition/utils"
 	natsx "github.com/foomo/redirects/v2/pkg/nats"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	telemetryx "github.com/foomo/redirects/v2/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -24,6 +30,34 @@ type (
 		NewState          map[string]*content.RepoNode `json:"newState"`
 		RedirectsToUpsert []*storex.RedirectDefinition `json:"redirectsToUpsert,omitempty"`
 		RedirectsToDelete []storex.EntityID            `json:"redirectsToDeletee,omitempty"`
+		Conflicts         []*storex.RedirectConflict   `json:"conflicts,omitempty"`
+		// Dimensions limits the consolidation to the given dimensions, all dimensions if empty
+		Dimensions       []storex.Dimension                                   `json:"dimensions,omitempty"`
+		DimensionResults map[storex.Dimension]*CreateRedirectsDimensionResult `json:"dimensionResults,omitempty"`
+		// Batch stamped on the created and changed redirects, empty if nothing changed
+		Batch storex.EntityID `json:"batch,omitempty"`
+		// Result is filled by the handler if set
+		Result *CreateRedirectsResult `json:"-"`
+	}
+	// CreateRedirectsResult of the command
+	CreateRedirectsResult struct {
+		Upserted int             `json:"upserted"`
+		Deleted  int             `json:"deleted"`
+		Batch    storex.EntityID `json:"batch,omitempty"`
+		// Conflicts of automatic redirects rejected in favour of manual or locked ones
+		Conflicts []*storex.RedirectConflict `json:"conflicts,omitempty"`
+		// Dimensions counts the changes per consolidated dimension
+		Dimensions map[storex.Dimension]*CreateRedirectsDimensionResult `json:"dimensions,omitempty"`
+		// Scope holds the upserted and deleted definitions, the chains reaching them are flattened
+		Scope FlattenScope `json:"-"`
+	}
+	// CreateRedirectsDimensionResult counts the changes of a dimension
+	CreateRedirectsDimensionResult struct {
+		Created   int `json:"created"`
+		Updated   int `json:"updated"`
+		Staled    int `json:"staled"`
+		Deleted   int `json:"deleted"`
+		Conflicts int `json:"conflicts"`
 	}
 	// CreateRedirectsHandlerFn handler
 	CreateRedirectsHandlerFn func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) error
@@ -42,7 +76,23 @@ func CreateRedirectsHandler(repo repositoryx.RedirectsDefinitionRepository) Crea
 			}
 		}
 
+		scope := NewFlattenScope(cmd.RedirectsToUpsert...)
+
 		if len(cmd.RedirectsToDelete) > 0 {
+			ids := make([]*storex.EntityID, 0, len(cmd.RedirectsToDelete))
+			for i := range cmd.RedirectsToDelete {
+				ids = append(ids, &cmd.RedirectsToDelete[i])
+			}
+
+			// the chains through deleted redirects are flattened as well
+			deleted, findErr := repo.FindByIDs(ctx, ids)
+			if findErr != nil {
+				keellog.WithError(l, findErr).Error("failed to fetch definitions to delete")
+				return findErr
+			}
+
+			scope.Add(deleted...)
+
 			deleteErr := repo.DeleteMany(ctx, cmd.RedirectsToDelete)
 			if deleteErr != nil {
 				keellog.WithError(l, deleteErr).Error("failed to delete definitions")
@@ -50,7 +100,16 @@ func CreateRedirectsHandler(repo repositoryx.RedirectsDefinitionRepository) Crea
 			}
 		}
 
-		l.Info("successfully finished create automatic redirects")
+		if cmd.Result != nil {
+			cmd.Result.Upserted = len(cmd.RedirectsToUpsert)
+			cmd.Result.Deleted = len(cmd.RedirectsToDelete)
+			cmd.Result.Conflicts = cmd.Conflicts
+			cmd.Result.Dimensions = cmd.DimensionResults
+			cmd.Result.Batch = cmd.Batch
+			cmd.Result.Scope = scope
+		}
+
+		l.Info("successfully finished create automatic redirects", zap.Int("conflicts", len(cmd.Conflicts)))
 
 		return nil
 	}
@@ -78,16 +137,21 @@ func CreateRedirectsHandlerComposed(handler CreateRedirectsHandlerFn, middleware
 	})
 }
 
-// CreateRedirectsPublishMiddleware ...
+// CreateRedirectsPublishMiddleware flattens the chains affected by the run and publishes the update signal.
+// The inner middlewares add the definitions to their own copy of the command, the scope is returned by the handler.
 func CreateRedirectsPublishMiddleware(updateSignal *natsx.UpdateSignal, repo repositoryx.RedirectsDefinitionRepository) CreateRedirectsMiddlewareFn {
 	return func(next CreateRedirectsHandlerFn) CreateRedirectsHandlerFn {
 		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) error {
+			if cmd.Result == nil {
+				cmd.Result = &CreateRedirectsResult{}
+			}
+
 			err := next(ctx, l, cmd)
 			if err != nil {
 				return err
 			}
 
-			if err := applyFlattening(ctx, l, repo); err != nil {
+			if err := applyFlattening(ctx, l, repo, cmd.Result.Scope); err != nil {
 				return err
 			}
 
@@ -103,10 +167,18 @@ func CreateRedirectsPublishMiddleware(updateSignal *natsx.UpdateSignal, repo rep
 	}
 }
 
-// CreateRedirectsAutoCreateMiddleware ...
-func CreateRedirectsAutoCreateMiddleware(initialStaleState bool) CreateRedirectsMiddlewareFn {
+// CreateRedirectsAutoCreateMiddleware creates the automatic redirects with the policy per dimension and MimeType
+func CreateRedirectsAutoCreateMiddleware(policyProvider providerx.AutoRedirectPolicyProviderFunc) CreateRedirectsMiddlewareFn {
 	return func(next CreateRedirectsHandlerFn) CreateRedirectsHandlerFn {
-		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) error {
+		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) (err error) {
+			ctx, span := telemetryx.Tracer().Start(ctx, "CreateRedirectsAutoCreate")
+			start := time.Now()
+
+			defer func() {
+				metrics.autoCreateDuration.Record(ctx, time.Since(start).Seconds())
+				endSpan(ctx, span, "autocreate", err)
+			}()
+
 			l.Info("auto creating redirects")
 
 			dimensions := map[string]struct{}{}
@@ -122,18 +194,58 @@ func CreateRedirectsAutoCreateMiddleware(initialStaleState bool) CreateRedirects
 				oldNodeMap := utilsx.CreateFlatRepoNodeMap(cmd.OldState[dimension], make(map[string]*content.RepoNode))
 				newNodeMap := utilsx.CreateFlatRepoNodeMap(cmd.NewState[dimension], make(map[string]*content.RepoNode))
 
-				newDefinitions, err := utilsx.AutoCreateRedirectDefinitions(
+				newDefinitions, err := utilsx.AutoCreateRedirectDefinitionsWithPolicy(
 					l,
 					oldNodeMap,
 					newNodeMap,
 					storex.Dimension(dimension),
-					initialStaleState,
+					policyProvider,
 				)
 				if err != nil {
 					keellog.WithError(l, err).Error("failed to execute auto create redirects")
 					return err
 				}
 
+				metrics.autoCreated.Add(ctx, int64(len(newDefinitions)), metric.WithAttributes(telemetryx.AttributeDimension.String(dimension)))
+				span.AddEvent("dimension", trace.WithAttributes(
+					telemetryx.AttributeDimension.String(dimension),
+					attribute.Int("redirects.created", len(newDefinitions)),
+				))
+
+				cmd.RedirectsToUpsert = append(cmd.RedirectsToUpsert, newDefinitions...)
+			}
+
+			return next(ctx, l, cmd)
+		}
+	}
+}
+
+// CreateRedirectsDeletedContentMiddleware adds definitions for the URIs of deleted nodes according to the policy,
+// the automatic redirect policy sets their stale state and skips nodes as for moved ones
+func CreateRedirectsDeletedContentMiddleware(
+	autoRedirectPolicyProvider providerx.AutoRedirectPolicyProviderFunc,
+	policyProvider providerx.DeletedContentPolicyProviderFunc,
+) CreateRedirectsMiddlewareFn {
+	return func(next CreateRedirectsHandlerFn) CreateRedirectsHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) error {
+			l.Info("creating redirects for deleted content")
+
+			for dimension, oldState := range cmd.OldState {
+				newDefinitions := utilsx.DeletedContentRedirectDefinitions(
+					l,
+					utilsx.CreateFlatRepoNodeMap(oldState, make(map[string]*content.RepoNode)),
+					utilsx.CreateFlatRepoNodeMap(cmd.NewState[dimension], make(map[string]*content.RepoNode)),
+					utilsx.CreateParentIDMap(oldState, make(map[string]string)),
+					storex.Dimension(dimension),
+					autoRedirectPolicyProvider,
+					policyProvider,
+				)
+
+				metrics.autoCreated.Add(ctx, int64(len(newDefinitions)), metric.WithAttributes(
+					telemetryx.AttributeDimension.String(dimension),
+					telemetryx.AttributeOperation.String("deletedcontent"),
+				))
+
 				cmd.RedirectsToUpsert = append(cmd.RedirectsToUpsert, newDefinitions...)
 			}
 
@@ -142,14 +254,17 @@ func CreateRedirectsAutoCreateMiddleware(initialStaleState bool) CreateRedirects
 	}
 }
 
-// CreateRedirectsConsolidateMiddleware ...
-func CreateRedirectsConsolidateMiddleware(repo repositoryx.RedirectsDefinitionRepository, autoDelete bool) CreateRedirectsMiddlewareFn {
+// CreateRedirectsConsolidateMiddleware consolidates the new with the current definitions,
+// obsolete definitions are handled according to the delete policy
+func CreateRedirectsConsolidateMiddleware(repo repositoryx.RedirectsDefinitionRepository, deletePolicy storex.DeletePolicy) CreateRedirectsMiddlewareFn {
 	return func(next CreateRedirectsHandlerFn) CreateRedirectsHandlerFn {
 		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) error {
 			l.Info("consolidating redirect definitions")
 
 			redirectsToUpsert := []*storex.RedirectDefinition{}
 			redirectsToDelete := []storex.EntityID{}
+			dimensionResults := map[storex.Dimension]*CreateRedirectsDimensionResult{}
+			now := storex.NewDateTime(time.Now())
 
 			// get all current definitions for the dimension from the database
 			allCurrentDefinitions, err := repo.FindAll(ctx, true)
@@ -158,37 +273,105 @@ func CreateRedirectsConsolidateMiddleware(repo repositoryx.RedirectsDefinitionRe
 				return err
 			}
 
-			for dimension, currentDefinitions := range allCurrentDefinitions {
-				defs, ids := utilsx.ConsolidateRedirectDefinitions(
+			// consolidate the new definitions with the current ones of their dimension
+			newDefinitions := map[storex.Dimension][]*storex.RedirectDefinition{}
+			for _, def := range cmd.RedirectsToUpsert {
+				newDefinitions[def.Dimension] = append(newDefinitions[def.Dimension], def)
+			}
+
+			dimensions := map[storex.Dimension]struct{}{}
+			for dimension := range allCurrentDefinitions {
+				dimensions[dimension] = struct{}{}
+			}
+
+			for dimension := range newDefinitions {
+				dimensions[dimension] = struct{}{}
+			}
+
+			if len(cmd.Dimensions) > 0 {
+				dimensions = map[storex.Dimension]struct{}{}
+				for _, dimension := range cmd.Dimensions {
+					dimensions[dimension] = struct{}{}
+				}
+			}
+
+			for dimension := range dimensions {
+				currentDefinitions := allCurrentDefinitions[dimension]
+				previousDefinitions := copyDefinitions(currentDefinitions)
+
+				defs, ids, conflicts := utilsx.ConsolidateRedirectDefinitions(
 					l,
-					cmd.RedirectsToUpsert,
+					newDefinitions[dimension],
 					currentDefinitions,
 					utilsx.CreateFlatRepoNodeMap(cmd.NewState[string(dimension)], make(map[string]*content.RepoNode)),
 				)
-				redirectsToUpsert = append(redirectsToUpsert, defs...)
+				cmd.Conflicts = append(cmd.Conflicts, conflicts...)
+
+				result := countChanges(defs, ids, previousDefinitions)
+				result.Conflicts = len(conflicts)
+				dimensionResults[dimension] = result
 
-				// if we are in auto delete mode we add the ids to the delete list
+				// with the hard delete strategy we add the ids to the delete list
 				// otherwise we soft delete the definitions
-				if autoDelete {
+				if deletePolicy.Strategy == storex.DeleteStrategyHard {
 					redirectsToDelete = append(redirectsToDelete, ids...)
+					result.Deleted = len(ids)
 				} else {
-					softDeleteStrategy(ids, defs, currentDefinitions)
+					defs = softDeleteStrategy(ids, defs, currentDefinitions, now)
+					result.Staled = len(ids)
 				}
+
+				redirectsToUpsert = append(redirectsToUpsert, defs...)
 			}
 
 			cmd.RedirectsToUpsert = redirectsToUpsert
 			cmd.RedirectsToDelete = redirectsToDelete
+			cmd.DimensionResults = dimensionResults
 
 			return next(ctx, l, cmd)
 		}
 	}
 }
 
-// softDeleteStrategy ...
+// copyDefinitions copies the definitions by ID to detect the changes of the consolidation
+func copyDefinitions(definitions storex.RedirectDefinitions) map[storex.EntityID]storex.RedirectDefinition {
+	copied := make(map[storex.EntityID]storex.RedirectDefinition, len(definitions))
+	for _, def := range definitions {
+		copied[def.ID] = *def
+	}
+
+	return copied
+}
+
+// countChanges counts the created and updated definitions, definitions to delete are not counted
+func countChanges(
+	defs []*storex.RedirectDefinition,
+	idsToDelete []storex.EntityID,
+	previousDefinitions map[storex.EntityID]storex.RedirectDefinition,
+) *CreateRedirectsDimensionResult {
+	result := &CreateRedirectsDimensionResult{}
+
+	for _, def := range defs {
+		if slices.Contains(idsToDelete, def.ID) {
+			continue
+		}
+
+		if previous, ok := previousDefinitions[def.ID]; !ok {
+			result.Created++
+		} else if !reflect.DeepEqual(previous, *def) {
+			result.Updated++
+		}
+	}
+
+	return result
+}
+
+// softDeleteStrategy marks the definitions as stale and obsolete since now
 func softDeleteStrategy(
 	idsToDelete []storex.EntityID,
 	newRedirects []*storex.RedirectDefinition,
 	currentDefinitions map[storex.RedirectSource]*storex.RedirectDefinition,
+	now storex.DateTime,
 ) []*storex.RedirectDefinition {
 	additionalRedirects := []*storex.RedirectDefinition{}
 
@@ -196,6 +379,7 @@ func softDeleteStrategy(
 		for _, def := range newRedirects {
 			if def.ID == id {
 				def.Stale = true
+				def.Obsolete = now
 				continue
 			}
 		}
@@ -203,6 +387,7 @@ func softDeleteStrategy(
 		for _, def := range currentDefinitions {
 			if def.ID == id {
 				def.Stale = true
+				def.Obsolete = now
 				additionalRedirects = append(additionalRedirects, def)
 			}
 		}
@@ -0,0 +1,119 @@
+package redirectcommand
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	keellog "github.com/foomo/keel/log"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// CreateSnapshot command, snapshots all definitions or the ones of a dimension
+	CreateSnapshot struct {
+		Name string `json:"name"`
+		// Dimension limits the snapshot to a single dimension, all dimensions if empty
+		Dimension storex.Dimension `json:"dimension,omitempty"`
+		// User who created the snapshot
+		User string `json:"user,omitempty"`
+		// Writer receives the snapshot as file instead of storing it
+		Writer io.Writer `json:"-"`
+		// Result is filled by the handler if set
+		Result *storex.Snapshot `json:"-"`
+	}
+	// CreateSnapshotHandlerFn handler
+	CreateSnapshotHandlerFn func(ctx context.Context, l *zap.Logger, cmd CreateSnapshot) error
+	// CreateSnapshotMiddlewareFn middleware
+	CreateSnapshotMiddlewareFn func(next CreateSnapshotHandlerFn) CreateSnapshotHandlerFn
+)
+
+// CreateSnapshotHandler ...
+func CreateSnapshotHandler(repo repositoryx.RedirectsDefinitionRepository) CreateSnapshotHandlerFn {
+	return func(ctx context.Context, l *zap.Logger, cmd CreateSnapshot) error {
+		snapshot := &storex.Snapshot{
+			ID:        storex.NewEntityID(),
+			Name:      cmd.Name,
+			Time:      storex.NewDateTime(time.Now()),
+			Dimension: cmd.Dimension,
+			CreatedBy: cmd.User,
+		}
+
+		definitions, err := repo.FindAllByFilter(ctx, snapshot.Filter())
+		if err != nil {
+			keellog.WithError(l, err).Error("failed to fetch definitions for snapshot")
+			return err
+		}
+
+		snapshot.Definitions = SortedDefinitions(definitions)
+		snapshot.Count = len(snapshot.Definitions)
+
+		if cmd.Writer != nil {
+			err = storex.WriteSnapshot(cmd.Writer, snapshot)
+		} else {
+			err = repo.InsertSnapshot(ctx, snapshot)
+		}
+
+		if err != nil {
+			keellog.WithError(l, err).Error("failed to write snapshot", zap.String("name", cmd.Name))
+			return err
+		}
+
+		if cmd.Result != nil {
+			*cmd.Result = *snapshot
+		}
+
+		l.Info("created snapshot", zap.String("name", cmd.Name), zap.Int("count", snapshot.Count))
+
+		return nil
+	}
+}
+
+// SortedDefinitions returns the definitions sorted by dimension and source
+func SortedDefinitions(definitions map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition) []*storex.RedirectDefinition {
+	defs := []*storex.RedirectDefinition{}
+	for _, bySource := range definitions {
+		for _, def := range bySource {
+			defs = append(defs, def)
+		}
+	}
+
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].Dimension != defs[j].Dimension {
+			return defs[i].Dimension < defs[j].Dimension
+		}
+
+		return defs[i].Source < defs[j].Source
+	})
+
+	return defs
+}
+
+// CreateSnapshotHandlerComposed returns the handler with middleware applied to it
+func CreateSnapshotHandlerComposed(handler CreateSnapshotHandlerFn, middlewares ...CreateSnapshotMiddlewareFn) CreateSnapshotHandlerFn {
+	composed := func(next CreateSnapshotHandlerFn) CreateSnapshotHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, cmd CreateSnapshot) error {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, cmd)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, cmd CreateSnapshot) error {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, cmd)
+	})
+}
@@ -57,12 +57,17 @@ func DeleteRedirectHandlerComposed(handler DeleteRedirectHandlerFn, middlewares
 func DeleteRedirectPublishMiddleware(updateSignal *natsx.UpdateSignal, repo repositoryx.RedirectsDefinitionRepository) DeleteRedirectMiddlewareFn {
 	return func(next DeleteRedirectHandlerFn) DeleteRedirectHandlerFn {
 		return func(ctx context.Context, l *zap.Logger, cmd DeleteRedirect) error {
-			err := next(ctx, l, cmd)
+			deleted, err := repo.FindByIDs(ctx, []*storex.EntityID{&cmd.ID})
 			if err != nil {
 				return err
 			}
 
-			if err := applyFlattening(ctx, l, repo); err != nil {
+			err = next(ctx, l, cmd)
+			if err != nil {
+				return err
+			}
+
+			if err := applyFlattening(ctx, l, repo, NewFlattenScope(deleted...)); err != nil {
 				return err
 			}
 
@@ -0,0 +1,88 @@
+package redirectcommand
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	natsx "github.com/foomo/redirects/v2/pkg/nats"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// FlattenAllRedirects command, flattens the redirect chains of all dimensions
+	FlattenAllRedirects struct {
+		// DryRun only logs the changes
+		DryRun bool `json:"dryRun"`
+		// Result is filled by the handler if set
+		Result *FlattenAllRedirectsResult `json:"-"`
+	}
+	// FlattenAllRedirectsResult of the command
+	FlattenAllRedirectsResult struct {
+		Changed []*storex.RedirectDefinition `json:"changed"`
+	}
+	// FlattenAllRedirectsHandlerFn handler
+	FlattenAllRedirectsHandlerFn func(ctx context.Context, l *zap.Logger, cmd FlattenAllRedirects) error
+	// FlattenAllRedirectsMiddlewareFn middleware
+	FlattenAllRedirectsMiddlewareFn func(next FlattenAllRedirectsHandlerFn) FlattenAllRedirectsHandlerFn
+)
+
+// FlattenAllRedirectsHandler ...
+func FlattenAllRedirectsHandler(repo repositoryx.RedirectsDefinitionRepository) FlattenAllRedirectsHandlerFn {
+	return func(ctx context.Context, l *zap.Logger, cmd FlattenAllRedirects) error {
+		changed, err := applyFullFlattening(ctx, l, repo, cmd.DryRun)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Result != nil {
+			cmd.Result.Changed = changed
+		}
+
+		return nil
+	}
+}
+
+// FlattenAllRedirectsHandlerComposed returns the handler with middleware applied to it
+func FlattenAllRedirectsHandlerComposed(handler FlattenAllRedirectsHandlerFn, middlewares ...FlattenAllRedirectsMiddlewareFn) FlattenAllRedirectsHandlerFn {
+	composed := func(next FlattenAllRedirectsHandlerFn) FlattenAllRedirectsHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, cmd FlattenAllRedirects) error {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, cmd)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, cmd FlattenAllRedirects) error {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, cmd)
+	})
+}
+
+// FlattenAllRedirectsPublishMiddleware ...
+func FlattenAllRedirectsPublishMiddleware(updateSignal *natsx.UpdateSignal) FlattenAllRedirectsMiddlewareFn {
+	return func(next FlattenAllRedirectsHandlerFn) FlattenAllRedirectsHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd FlattenAllRedirects) error {
+			err := next(ctx, l, cmd)
+			if err != nil {
+				return err
+			}
+
+			if cmd.DryRun {
+				return nil
+			}
+
+			return updateSignal.Publish()
+		}
+	}
+}
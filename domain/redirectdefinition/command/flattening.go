@@ -2,57 +2,152 @@ package redirectcommand
 
 import (
 	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
 	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
 	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	telemetryx "github.com/foomo/redirects/v2/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
-// applyFlattening retrieves all active redirects, applies flattening to resolve final targets,
-// and updates only the changed redirects in the repository.
+// FlattenScope holds the changed sources and targets per dimension, flattening is limited to the
+// chains reaching them
+type FlattenScope map[storex.Dimension]map[string]struct{}
+
+// NewFlattenScope returns the scope of the given definitions
+func NewFlattenScope(defs ...*storex.RedirectDefinition) FlattenScope {
+	scope := FlattenScope{}
+	scope.Add(defs...)
+
+	return scope
+}
+
+// Add adds the sources and targets of the definitions to the scope
+func (s FlattenScope) Add(defs ...*storex.RedirectDefinition) {
+	for _, def := range defs {
+		if def == nil {
+			continue
+		}
+
+		keys, ok := s[def.Dimension]
+		if !ok {
+			keys = map[string]struct{}{}
+			s[def.Dimension] = keys
+		}
+
+		keys[string(def.Source)] = struct{}{}
+		if def.Target != "" {
+			keys[string(def.Target)] = struct{}{}
+		}
+	}
+}
+
+// applyFlattening retrieves the active redirects of the dimensions in scope, applies flattening to
+// the chains reaching the changed sources and targets and updates only the changed redirects in the repository.
 func applyFlattening(
 	ctx context.Context,
 	l *zap.Logger,
 	repo repositoryx.RedirectsDefinitionRepository,
-) error {
+	scope FlattenScope,
+) (err error) {
+	ctx, span := telemetryx.Tracer().Start(ctx, "applyFlattening")
+	start := time.Now()
+
+	defer func() {
+		metrics.flatteningDuration.Record(ctx, time.Since(start).Seconds())
+		endSpan(ctx, span, "flattening", err)
+	}()
+
+	var flattenedRedirects []*storex.RedirectDefinition
+
+	for dimension, keys := range scope {
+		// Fetch active redirects (non-stale) of the dimension
+		redirectsBySource, err := repo.FindAllByDimension(ctx, dimension, true)
+		if err != nil {
+			l.Error("Failed to fetch redirects for flattening", zap.Error(err), zap.String("dimension", string(dimension)))
+			return err
+		}
+
+		flattenedRedirects = append(flattenedRedirects, FlattenRedirectsInScope(redirectsBySource, keys)...)
+	}
+
+	span.SetAttributes(attribute.Int("redirects.dimensions", len(scope)))
+
+	return persistFlattened(ctx, l, repo, flattenedRedirects)
+}
+
+// applyFullFlattening retrieves all active redirects, applies flattening to resolve final targets,
+// and updates only the changed redirects in the repository.
+func applyFullFlattening(
+	ctx context.Context,
+	l *zap.Logger,
+	repo repositoryx.RedirectsDefinitionRepository,
+	dryRun bool,
+) (flattenedRedirects []*storex.RedirectDefinition, err error) {
+	ctx, span := telemetryx.Tracer().Start(ctx, "applyFullFlattening")
+	start := time.Now()
+
+	defer func() {
+		metrics.flatteningDuration.Record(ctx, time.Since(start).Seconds())
+		endSpan(ctx, span, "flattening", err)
+	}()
+
 	// Fetch active redirects (non-stale)
 	allRedirects, err := repo.FindAll(ctx, true)
 	if err != nil {
 		l.Error("Failed to fetch redirects for flattening", zap.Error(err))
-		return err
+		return nil, err
+	}
+
+	flattenedRedirects = FlattenRedirects(allRedirects)
+
+	if dryRun {
+		l.Info("Redirects changed by flattening", zap.Int("count", len(flattenedRedirects)), zap.Bool("dryRun", dryRun))
+		return flattenedRedirects, nil
 	}
 
-	flattenedRedirects := FlattenRedirects(allRedirects)
+	return flattenedRedirects, persistFlattened(ctx, l, repo, flattenedRedirects)
+}
 
+// persistFlattened persists only the changed redirects
+func persistFlattened(
+	ctx context.Context,
+	l *zap.Logger,
+	repo repositoryx.RedirectsDefinitionRepository,
+	flattenedRedirects []*storex.RedirectDefinition,
+) error {
 	// If no redirects changed, avoid unnecessary DB writes
 	if len(flattenedRedirects) == 0 {
 		l.Info("No redirects changed after flattening")
 		return nil
 	}
 
-	// Persist only changed redirects
 	if err := repo.UpsertMany(ctx, flattenedRedirects); err != nil {
 		l.Error("Failed to persist flattened redirects", zap.Error(err))
 		return err
 	}
 
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Int("redirects.changed", len(flattenedRedirects)))
+	metrics.flattenedRedirects.Add(ctx, int64(len(flattenedRedirects)))
+
 	l.Info("Successfully updated changed redirects", zap.Int("count", len(flattenedRedirects)))
 
 	return nil
 }
 
-// FlattenRedirects applies flattening logic to active redirects
+// FlattenRedirects applies flattening logic to active redirects,
+// manual and locked redirects are followed but never changed, chains stop at redirects with NoFlatten
 func FlattenRedirects(allRedirects map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition) []*storex.RedirectDefinition {
 	var flattened []*storex.RedirectDefinition
 
 	for _, redirectsBySource := range allRedirects {
 		for _, redirect := range redirectsBySource {
-			// Resolve final target by flattening the chain
-			finalTarget := resolveFinalTarget(redirect.Target, redirectsBySource)
-
-			// Only store changes (avoid unnecessary updates)
-			if finalTarget != redirect.Target {
-				redirect.Target = finalTarget
+			if flattenRedirect(redirect, redirectsBySource) {
 				flattened = append(flattened, redirect)
 			}
 		}
@@ -61,13 +156,106 @@ func FlattenRedirects(allRedirects map[storex.Dimension]map[storex.RedirectSourc
 	return flattened
 }
 
+// FlattenRedirectsInScope applies flattening logic to the redirects of one dimension whose chain
+// reaches one of the keys, manual and locked redirects are followed but never changed
+func FlattenRedirectsInScope(redirectsBySource map[storex.RedirectSource]*storex.RedirectDefinition, keys map[string]struct{}) []*storex.RedirectDefinition {
+	var flattened []*storex.RedirectDefinition
+
+	for _, redirect := range AffectedRedirects(redirectsBySource, keys) {
+		if flattenRedirect(redirect, redirectsBySource) {
+			flattened = append(flattened, redirect)
+		}
+	}
+
+	return flattened
+}
+
+// AffectedRedirects returns the redirects whose chain reaches one of the keys, walking the chains
+// backwards with a reverse target index. Keys which are sources of prefix redirects also reach the
+// redirects targeting paths below them.
+func AffectedRedirects(redirectsBySource map[storex.RedirectSource]*storex.RedirectDefinition, keys map[string]struct{}) []*storex.RedirectDefinition {
+	byTarget := make(map[string][]*storex.RedirectDefinition, len(redirectsBySource))
+	for _, redirect := range redirectsBySource {
+		path, _, _ := strings.Cut(string(redirect.Target), "?")
+		byTarget[path] = append(byTarget[path], redirect)
+
+		// flattened redirects are reached by their intended target as well
+		if redirect.OriginalTarget != "" {
+			originalPath, _, _ := strings.Cut(string(redirect.OriginalTarget), "?")
+			if originalPath != path {
+				byTarget[originalPath] = append(byTarget[originalPath], redirect)
+			}
+		}
+	}
+
+	affected := map[storex.RedirectSource]*storex.RedirectDefinition{}
+	queue := make([]string, 0, len(keys))
+
+	for key := range keys {
+		// the changed redirects themselves may now reach further
+		if redirect, ok := redirectsBySource[storex.RedirectSource(key)]; ok {
+			affected[redirect.Source] = redirect
+		}
+
+		path, _, _ := strings.Cut(key, "?")
+		queue = append(queue, path)
+	}
+
+	visited := map[string]struct{}{}
+
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+
+		if _, ok := visited[key]; ok {
+			continue
+		}
+
+		visited[key] = struct{}{}
+
+		reaching := byTarget[key]
+
+		if redirect, ok := redirectsBySource[storex.RedirectSource(key)]; ok && redirect.IsPrefix() {
+			for target, redirects := range byTarget {
+				if target != key && storex.IsBelow(target, key) {
+					reaching = append(reaching, redirects...)
+				}
+			}
+		}
+
+		for _, redirect := range reaching {
+			if _, ok := affected[redirect.Source]; !ok {
+				affected[redirect.Source] = redirect
+				queue = append(queue, string(redirect.Source))
+			}
+		}
+	}
+
+	return slices.Collect(maps.Values(affected))
+}
+
+// flattenRedirect resolves the final target of the redirect from its intended target, returns true if it changed.
+// Resolving from the intended target also unflattens chains whose intermediate redirects are gone.
+func flattenRedirect(redirect *storex.RedirectDefinition, redirectsBySource map[storex.RedirectSource]*storex.RedirectDefinition) bool {
+	if redirect.IsProtected() || redirect.NoFlatten {
+		return false
+	}
+
+	// Resolve final target by flattening the chain
+	target, originalTarget := redirect.Target, redirect.OriginalTarget
+	redirect.SetEffectiveTarget(resolveFinalTarget(redirect.IntendedTarget(), redirectsBySource))
+
+	// Only store changes (avoid unnecessary updates)
+	return redirect.Target != target || redirect.OriginalTarget != originalTarget
+}
+
 // resolveFinalTarget follows the redirect chain to find the final target
 func resolveFinalTarget(target storex.RedirectTarget, redirects map[storex.RedirectSource]*storex.RedirectDefinition) storex.RedirectTarget {
 	visited := make(map[string]struct{})
 
 	for {
-		nextRedirect, exists := redirects[storex.RedirectSource(target)]
-		if !exists || nextRedirect.Target == "" {
+		nextTarget, exists := nextRedirectTarget(target, redirects)
+		if !exists || nextTarget == "" {
 			return target
 		}
 
@@ -77,6 +265,38 @@ func resolveFinalTarget(target storex.RedirectTarget, redirects map[storex.Redir
 		}
 
 		visited[string(target)] = struct{}{}
-		target = nextRedirect.Target
+		target = nextTarget
 	}
 }
+
+// nextRedirectTarget returns the target the given target is redirected to, either by the definition
+// for the target itself or by the longest prefix definition above it. Definitions with NoFlatten end the chain.
+func nextRedirectTarget(target storex.RedirectTarget, redirects map[storex.RedirectSource]*storex.RedirectDefinition) (storex.RedirectTarget, bool) {
+	if nextRedirect, exists := redirects[storex.RedirectSource(target)]; exists {
+		if nextRedirect.NoFlatten {
+			return "", false
+		}
+
+		return nextRedirect.Target, true
+	}
+
+	path, query, hasQuery := strings.Cut(string(target), "?")
+
+	definition, nextTarget := storex.ResolvePrefix(path, func(source storex.RedirectSource) *storex.RedirectDefinition {
+		return redirects[source]
+	})
+	if definition == nil || definition.NoFlatten {
+		return "", false
+	}
+
+	if hasQuery && nextTarget != "" {
+		separator := "?"
+		if strings.Contains(string(nextTarget), "?") {
+			separator = "&"
+		}
+
+		nextTarget += storex.RedirectTarget(separator + query)
+	}
+
+	return nextTarget, true
+}
@@ -20,10 +20,10 @@ func Test_FlattenRedirects_SimpleChain(t *testing.T) {
 	}
 	flattened := commandx.FlattenRedirects(redirects)
 
-	// Assertions
-	assert.Len(t, flattened, 2)
+	// Assertions: the manual redirect is followed but not changed
+	assert.Len(t, flattened, 1)
 	assert.Equal(t, "/final", string(flattened[0].Target))
-	assert.Equal(t, "/final", string(flattened[1].Target))
+	assert.Equal(t, "/c", string(redirects["global"]["/b"].Target))
 }
 
 func Test_FlattenRedirects_TwoFlatten(t *testing.T) {
@@ -57,8 +57,153 @@ func Test_FlattenRedirects_MultipleSourcesToSameTarget(t *testing.T) {
 
 	flattened := commandx.FlattenRedirects(redirects)
 
-	// Assertions: Both /a and /b should point to /final
-	assert.Len(t, flattened, 2)
+	// Assertions: /a should point to /final, the manual /b is kept
+	assert.Len(t, flattened, 1)
 	assert.Equal(t, "/final", string(flattened[0].Target), "/a should flatten to /final")
-	assert.Equal(t, "/final", string(flattened[1].Target), "/b should flatten to /final")
+	assert.Equal(t, "/c", string(redirects["global"]["/b"].Target), "/b should not be changed")
+}
+
+func Test_FlattenRedirects_Locked(t *testing.T) {
+	t.Parallel()
+
+	redirects := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+		"global": {
+			"/a": {Source: "/a", Target: "/b", RedirectionType: storex.RedirectionTypeAutomatic, Locked: true},
+			"/b": {Source: "/b", Target: "/c", RedirectionType: storex.RedirectionTypeAutomatic},
+			"/c": {Source: "/c", Target: "/final", RedirectionType: storex.RedirectionTypeAutomatic},
+		},
+	}
+
+	flattened := commandx.FlattenRedirects(redirects)
+
+	assert.Len(t, flattened, 1)
+	assert.Equal(t, "/b", string(redirects["global"]["/a"].Target))
+	assert.Equal(t, "/final", string(redirects["global"]["/b"].Target))
+}
+
+func Test_FlattenRedirects_Prefix(t *testing.T) {
+	t.Parallel()
+
+	redirects := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+		"global": {
+			"/a":         {Source: "/a", Target: "/old/page?x=1", RedirectionType: storex.RedirectionTypeAutomatic},
+			"/old":       {Source: "/old", Target: "/new", MatchType: storex.MatchTypePrefix, RedirectionType: storex.RedirectionTypeAutomatic},
+			"/new/page2": {Source: "/new/page2", Target: "/final", RedirectionType: storex.RedirectionTypeAutomatic},
+			"/b":         {Source: "/b", Target: "/old/page2", RedirectionType: storex.RedirectionTypeAutomatic},
+		},
+	}
+
+	flattened := commandx.FlattenRedirects(redirects)
+
+	assert.Len(t, flattened, 2)
+	assert.Equal(t, "/new/page?x=1", string(redirects["global"]["/a"].Target))
+	assert.Equal(t, "/final", string(redirects["global"]["/b"].Target))
+}
+
+func Test_FlattenRedirectsInScope(t *testing.T) {
+	t.Parallel()
+
+	redirects := map[storex.RedirectSource]*storex.RedirectDefinition{
+		"/a":     {Source: "/a", Target: "/b", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/b":     {Source: "/b", Target: "/c", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/c":     {Source: "/c", Target: "/final", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/x":     {Source: "/x", Target: "/y", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/y":     {Source: "/y", Target: "/z", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/p":     {Source: "/p", Target: "/old/page?x=1", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/old":   {Source: "/old", Target: "/new", MatchType: storex.MatchTypePrefix, RedirectionType: storex.RedirectionTypeAutomatic},
+		"/other": {Source: "/other", Target: "/oldie", RedirectionType: storex.RedirectionTypeAutomatic},
+	}
+
+	// /c and /old changed, the unrelated chain /x → /y → /z is not touched
+	flattened := commandx.FlattenRedirectsInScope(redirects, map[string]struct{}{
+		"/c":     {},
+		"/final": {},
+		"/old":   {},
+		"/new":   {},
+	})
+
+	assert.Len(t, flattened, 3)
+	assert.Equal(t, "/final", string(redirects["/a"].Target))
+	assert.Equal(t, "/final", string(redirects["/b"].Target))
+	assert.Equal(t, "/new/page?x=1", string(redirects["/p"].Target))
+	assert.Equal(t, "/y", string(redirects["/x"].Target))
+	assert.Equal(t, "/oldie", string(redirects["/other"].Target))
+}
+
+func Test_AffectedRedirects(t *testing.T) {
+	t.Parallel()
+
+	redirects := map[storex.RedirectSource]*storex.RedirectDefinition{
+		"/a": {Source: "/a", Target: "/b"},
+		"/b": {Source: "/b", Target: "/c"},
+		"/d": {Source: "/d", Target: "/b?x=1"},
+		"/e": {Source: "/e", Target: "/f"},
+	}
+
+	affected := commandx.AffectedRedirects(redirects, map[string]struct{}{"/c": {}})
+
+	sources := make([]storex.RedirectSource, 0, len(affected))
+	for _, def := range affected {
+		sources = append(sources, def.Source)
+	}
+
+	assert.ElementsMatch(t, []storex.RedirectSource{"/a", "/b", "/d"}, sources)
+}
+
+func Test_NewFlattenScope(t *testing.T) {
+	t.Parallel()
+
+	scope := commandx.NewFlattenScope(
+		&storex.RedirectDefinition{Dimension: "de", Source: "/a", Target: "/b"},
+		&storex.RedirectDefinition{Dimension: "fr", Source: "/gone", Code: storex.RedirectCodeGone},
+		nil,
+	)
+
+	assert.Equal(t, commandx.FlattenScope{
+		"de": {"/a": {}, "/b": {}},
+		"fr": {"/gone": {}},
+	}, scope)
+}
+
+func Test_FlattenRedirects_OriginalTarget(t *testing.T) {
+	t.Parallel()
+
+	redirects := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+		"global": {
+			"/a": {Source: "/a", Target: "/b", RedirectionType: storex.RedirectionTypeAutomatic},
+			"/b": {Source: "/b", Target: "/c", RedirectionType: storex.RedirectionTypeAutomatic},
+		},
+	}
+
+	commandx.FlattenRedirects(redirects)
+
+	assert.Equal(t, "/c", string(redirects["global"]["/a"].Target))
+	assert.Equal(t, "/b", string(redirects["global"]["/a"].OriginalTarget))
+
+	// the intermediate redirect is removed, /a is unflattened to its intended target
+	delete(redirects["global"], "/b")
+
+	flattened := commandx.FlattenRedirectsInScope(redirects["global"], map[string]struct{}{"/b": {}, "/c": {}})
+
+	assert.Len(t, flattened, 1)
+	assert.Equal(t, "/b", string(redirects["global"]["/a"].Target))
+	assert.Empty(t, redirects["global"]["/a"].OriginalTarget)
+}
+
+func Test_FlattenRedirects_NoFlatten(t *testing.T) {
+	t.Parallel()
+
+	redirects := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+		"global": {
+			"/promo":   {Source: "/promo", Target: "/track", RedirectionType: storex.RedirectionTypeAutomatic},
+			"/track":   {Source: "/track", Target: "/landing", RedirectionType: storex.RedirectionTypeAutomatic, NoFlatten: true},
+			"/landing": {Source: "/landing", Target: "/final", RedirectionType: storex.RedirectionTypeAutomatic},
+		},
+	}
+
+	flattened := commandx.FlattenRedirects(redirects)
+
+	assert.Empty(t, flattened)
+	assert.Equal(t, "/track", string(redirects["global"]["/promo"].Target), "chains stop at the hop")
+	assert.Equal(t, "/landing", string(redirects["global"]["/track"].Target), "the hop itself is not flattened")
 }
@@ -0,0 +1,209 @@
+package redirectcommand
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	keellog "github.com/foomo/keel/log"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.uber.org/zap"
+)
+
+// LockName of the lease around the automatic pipeline and full flattening
+const LockName = "redirects.pipeline"
+
+// CreateRedirectsLockMiddleware runs the automatic pipeline while holding the lease,
+// without a lease repository the pipeline runs unlocked
+func CreateRedirectsLockMiddleware(leases repositoryx.LeaseRepository, policy storex.LockPolicy) CreateRedirectsMiddlewareFn {
+	return func(next CreateRedirectsHandlerFn) CreateRedirectsHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd CreateRedirects) error {
+			if leases == nil {
+				return next(ctx, l, cmd)
+			}
+
+			return withLease(ctx, l, leases, policy, func(ctx context.Context) error {
+				return next(ctx, l, cmd)
+			})
+		}
+	}
+}
+
+// FlattenAllRedirectsLockMiddleware runs the full flattening while holding the lease,
+// without a lease repository the flattening runs unlocked
+func FlattenAllRedirectsLockMiddleware(leases repositoryx.LeaseRepository, policy storex.LockPolicy) FlattenAllRedirectsMiddlewareFn {
+	return func(next FlattenAllRedirectsHandlerFn) FlattenAllRedirectsHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd FlattenAllRedirects) error {
+			if leases == nil {
+				return next(ctx, l, cmd)
+			}
+
+			return withLease(ctx, l, leases, policy, func(ctx context.Context) error {
+				return next(ctx, l, cmd)
+			})
+		}
+	}
+}
+
+// RollbackBatchLockMiddleware runs the rollback while holding the lease,
+// without a lease repository the rollback runs unlocked
+func RollbackBatchLockMiddleware(leases repositoryx.LeaseRepository, policy storex.LockPolicy) RollbackBatchMiddlewareFn {
+	return func(next RollbackBatchHandlerFn) RollbackBatchHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd RollbackBatch) error {
+			if leases == nil {
+				return next(ctx, l, cmd)
+			}
+
+			return withLease(ctx, l, leases, policy, func(ctx context.Context) error {
+				return next(ctx, l, cmd)
+			})
+		}
+	}
+}
+
+// RestoreSnapshotLockMiddleware runs the restore while holding the lease,
+// without a lease repository the restore runs unlocked
+func RestoreSnapshotLockMiddleware(leases repositoryx.LeaseRepository, policy storex.LockPolicy) RestoreSnapshotMiddlewareFn {
+	return func(next RestoreSnapshotHandlerFn) RestoreSnapshotHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd RestoreSnapshot) error {
+			if leases == nil || cmd.DryRun {
+				return next(ctx, l, cmd)
+			}
+
+			return withLease(ctx, l, leases, policy, func(ctx context.Context) error {
+				return next(ctx, l, cmd)
+			})
+		}
+	}
+}
+
+// withLease runs fn while holding the lease. The lease is renewed in the background, if it is lost
+// the context of fn is canceled and storex.ErrLeaseLost is returned.
+func withLease(
+	ctx context.Context,
+	l *zap.Logger,
+	leases repositoryx.LeaseRepository,
+	policy storex.LockPolicy,
+	fn func(ctx context.Context) error,
+) error {
+	lease, err := acquireLease(ctx, leases, policy)
+	if err != nil {
+		keellog.WithError(l, err).Warn("failed to acquire lease", zap.String("name", LockName))
+		return err
+	}
+
+	l = l.With(zap.String("lease", lease.Name), zap.String("token", string(lease.Token)))
+	l.Info("acquired lease")
+
+	fnCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		renewLease(fnCtx, l, leases, policy, lease, done, cancel)
+	}()
+
+	err = fn(fnCtx)
+
+	close(done)
+	wg.Wait()
+
+	if cause := context.Cause(fnCtx); errors.Is(cause, storex.ErrLeaseLost) {
+		l.Error("lease was lost while running")
+		return errors.Join(storex.ErrLeaseLost, err)
+	}
+
+	// release even if the caller's context is done
+	if releaseErr := leases.Release(context.WithoutCancel(ctx), lease); errors.Is(releaseErr, storex.ErrLeaseLost) {
+		l.Error("lease expired before it was released")
+		return errors.Join(storex.ErrLeaseLost, err)
+	} else if releaseErr != nil {
+		keellog.WithError(l, releaseErr).Warn("failed to release lease, it expires after its ttl")
+	}
+
+	return err
+}
+
+// acquireLease takes the lease, waits for it according to the policy
+func acquireLease(ctx context.Context, leases repositoryx.LeaseRepository, policy storex.LockPolicy) (*storex.Lease, error) {
+	deadline := time.Now().Add(policy.Timeout())
+
+	for {
+		lease, err := leases.Acquire(ctx, LockName, LeaseOwner(), policy.LeaseTTL())
+		if err == nil {
+			return lease, nil
+		}
+
+		if !errors.Is(err, storex.ErrLeaseHeld) || policy.Mode == storex.LockModeFail {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for lease '%s': %w", LockName, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(policy.Interval()):
+		}
+	}
+}
+
+// renewLease renews the lease until done, it cancels with storex.ErrLeaseLost if the lease
+// was taken over or could not be renewed before it expired
+func renewLease(
+	ctx context.Context,
+	l *zap.Logger,
+	leases repositoryx.LeaseRepository,
+	policy storex.LockPolicy,
+	lease *storex.Lease,
+	done <-chan struct{},
+	cancel context.CancelCauseFunc,
+) {
+	ticker := time.NewTicker(policy.LeaseTTL() / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			expires, _ := lease.Expires.Time()
+
+			err := leases.Renew(ctx, lease, policy.LeaseTTL())
+			if err == nil {
+				continue
+			}
+
+			if errors.Is(err, storex.ErrLeaseLost) || time.Now().After(expires) {
+				cancel(storex.ErrLeaseLost)
+				return
+			}
+
+			keellog.WithError(l, err).Warn("failed to renew lease, retrying")
+		}
+	}
+}
+
+// LeaseOwner identifies the process holding a lease or running a job
+func LeaseOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	return fmt.Sprintf("%s/%d", hostname, os.Getpid())
+}
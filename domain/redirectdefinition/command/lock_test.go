@@ -0,0 +1,145 @@
+package redirectcommand_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type memoryLeases struct {
+	mu    sync.Mutex
+	held  *storex.Lease
+	lost  bool
+	calls int
+}
+
+func (m *memoryLeases) Acquire(_ context.Context, name, owner string, ttl time.Duration) (*storex.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.calls++
+	if m.held != nil {
+		return nil, storex.ErrLeaseHeld
+	}
+
+	m.held = &storex.Lease{Name: name, Owner: owner, Token: storex.NewEntityID(), Expires: storex.NewDateTime(time.Now().Add(ttl))}
+
+	return m.held, nil
+}
+
+func (m *memoryLeases) Renew(_ context.Context, lease *storex.Lease, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lost || m.held == nil || m.held.Token != lease.Token {
+		return storex.ErrLeaseLost
+	}
+
+	lease.Expires = storex.NewDateTime(time.Now().Add(ttl))
+
+	return nil
+}
+
+func (m *memoryLeases) Release(_ context.Context, lease *storex.Lease) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.held == nil || m.held.Token != lease.Token {
+		return storex.ErrLeaseLost
+	}
+
+	m.held = nil
+
+	return nil
+}
+
+func (m *memoryLeases) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.held = nil
+}
+
+func TestCreateRedirectsLockMiddleware(t *testing.T) {
+	t.Parallel()
+
+	leases := &memoryLeases{}
+	called := false
+
+	handler := commandx.CreateRedirectsLockMiddleware(leases, storex.LockPolicy{})(func(_ context.Context, _ *zap.Logger, _ commandx.CreateRedirects) error {
+		called = true
+
+		assert.NotNil(t, leases.held, "the lease is held while running")
+
+		return nil
+	})
+
+	require.NoError(t, handler(context.Background(), zap.NewNop(), commandx.CreateRedirects{}))
+	assert.True(t, called)
+	assert.Nil(t, leases.held, "the lease is released")
+}
+
+func TestCreateRedirectsLockMiddleware_Fail(t *testing.T) {
+	t.Parallel()
+
+	leases := &memoryLeases{held: &storex.Lease{Token: "other"}}
+
+	handler := commandx.CreateRedirectsLockMiddleware(leases, storex.LockPolicy{Mode: storex.LockModeFail})(func(_ context.Context, _ *zap.Logger, _ commandx.CreateRedirects) error {
+		t.Fatal("must not run without the lease")
+		return nil
+	})
+
+	require.ErrorIs(t, handler(context.Background(), zap.NewNop(), commandx.CreateRedirects{}), storex.ErrLeaseHeld)
+	assert.Equal(t, 1, leases.calls)
+}
+
+func TestCreateRedirectsLockMiddleware_Wait(t *testing.T) {
+	t.Parallel()
+
+	leases := &memoryLeases{held: &storex.Lease{Token: "other"}}
+	policy := storex.LockPolicy{WaitTimeout: time.Second, RetryInterval: 5 * time.Millisecond}
+
+	time.AfterFunc(20*time.Millisecond, leases.release)
+
+	handler := commandx.CreateRedirectsLockMiddleware(leases, policy)(func(_ context.Context, _ *zap.Logger, _ commandx.CreateRedirects) error {
+		return nil
+	})
+
+	require.NoError(t, handler(context.Background(), zap.NewNop(), commandx.CreateRedirects{}))
+	assert.Greater(t, leases.calls, 1)
+}
+
+func TestCreateRedirectsLockMiddleware_Lost(t *testing.T) {
+	t.Parallel()
+
+	leases := &memoryLeases{lost: true}
+	policy := storex.LockPolicy{TTL: 30 * time.Millisecond}
+
+	handler := commandx.CreateRedirectsLockMiddleware(leases, policy)(func(ctx context.Context, _ *zap.Logger, _ commandx.CreateRedirects) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	require.ErrorIs(t, handler(context.Background(), zap.NewNop(), commandx.CreateRedirects{}), storex.ErrLeaseLost)
+}
+
+func TestCreateRedirectsLockMiddleware_WithoutLeases(t *testing.T) {
+	t.Parallel()
+
+	called := false
+
+	handler := commandx.CreateRedirectsLockMiddleware(nil, storex.LockPolicy{})(func(_ context.Context, _ *zap.Logger, _ commandx.CreateRedirects) error {
+		called = true
+		return nil
+	})
+
+	require.NoError(t, handler(context.Background(), zap.NewNop(), commandx.CreateRedirects{}))
+	assert.True(t, called)
+}
@@ -0,0 +1,102 @@
+package redirectcommand
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	keellog "github.com/foomo/keel/log"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	telemetryx "github.com/foomo/redirects/v2/pkg/telemetry"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// PurgeObsoleteRedirects command, deletes the redirects soft deleted by the consolidation before the cutoff
+	PurgeObsoleteRedirects struct {
+		Cutoff time.Time `json:"cutoff"`
+		// DryRun only records the redirects
+		DryRun bool `json:"dryRun"`
+		// Result is filled by the handler if set
+		Result *storex.PurgeRecord `json:"-"`
+	}
+	// PurgeObsoleteRedirectsHandlerFn handler
+	PurgeObsoleteRedirectsHandlerFn func(ctx context.Context, l *zap.Logger, cmd PurgeObsoleteRedirects) error
+	// PurgeObsoleteRedirectsMiddlewareFn middleware
+	PurgeObsoleteRedirectsMiddlewareFn func(next PurgeObsoleteRedirectsHandlerFn) PurgeObsoleteRedirectsHandlerFn
+)
+
+// PurgeObsoleteRedirectsHandler ...
+func PurgeObsoleteRedirectsHandler(repo repositoryx.RedirectsDefinitionRepository) PurgeObsoleteRedirectsHandlerFn {
+	return func(ctx context.Context, l *zap.Logger, cmd PurgeObsoleteRedirects) (err error) {
+		ctx, span := telemetryx.Tracer().Start(ctx, "PurgeObsoleteRedirects")
+		defer func() {
+			endSpan(ctx, span, "purge", err)
+		}()
+
+		definitions, err := repo.FindObsolete(ctx, cmd.Cutoff)
+		if err != nil {
+			keellog.WithError(l, err).Error("failed to fetch obsolete definitions")
+			return err
+		}
+
+		record := &storex.PurgeRecord{
+			ID:          storex.NewEntityID(),
+			Time:        storex.NewDateTime(time.Now()),
+			Cutoff:      storex.NewDateTime(cmd.Cutoff),
+			DryRun:      cmd.DryRun,
+			Definitions: definitions,
+		}
+
+		if cmd.Result != nil {
+			*cmd.Result = *record
+		}
+
+		if len(definitions) == 0 {
+			l.Info("no obsolete redirects to purge")
+			return nil
+		}
+
+		if !cmd.DryRun {
+			ids := make([]storex.EntityID, 0, len(definitions))
+			for _, def := range definitions {
+				ids = append(ids, def.ID)
+			}
+
+			if err := repo.DeleteMany(ctx, ids); err != nil {
+				keellog.WithError(l, err).Error("failed to delete obsolete definitions")
+				return err
+			}
+
+			metrics.purgedRedirects.Add(ctx, int64(len(ids)))
+		}
+
+		return repo.InsertPurgeRecord(ctx, record)
+	}
+}
+
+// PurgeObsoleteRedirectsHandlerComposed returns the handler with middleware applied to it
+func PurgeObsoleteRedirectsHandlerComposed(handler PurgeObsoleteRedirectsHandlerFn, middlewares ...PurgeObsoleteRedirectsMiddlewareFn) PurgeObsoleteRedirectsHandlerFn {
+	composed := func(next PurgeObsoleteRedirectsHandlerFn) PurgeObsoleteRedirectsHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, cmd PurgeObsoleteRedirects) error {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, cmd)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, cmd PurgeObsoleteRedirects) error {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, cmd)
+	})
+}
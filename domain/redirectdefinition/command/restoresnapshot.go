@@ -0,0 +1,159 @@
+package redirectcommand
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	keellog "github.com/foomo/keel/log"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	natsx "github.com/foomo/redirects/v2/pkg/nats"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// RestoreSnapshot command, restores the definitions in the scope of the snapshot to its state
+	RestoreSnapshot struct {
+		// Name of the stored snapshot, ignored if Snapshot is set
+		Name string `json:"name,omitempty"`
+		// Snapshot to restore, e.g. read from a file with storex.ReadSnapshot
+		Snapshot *storex.Snapshot `json:"snapshot,omitempty"`
+		// DryRun only returns the diff
+		DryRun bool `json:"dryRun"`
+		// Result is filled by the handler if set
+		Result *storex.DefinitionsDiff `json:"-"`
+	}
+	// RestoreSnapshotHandlerFn handler
+	RestoreSnapshotHandlerFn func(ctx context.Context, l *zap.Logger, cmd RestoreSnapshot) error
+	// RestoreSnapshotMiddlewareFn middleware
+	RestoreSnapshotMiddlewareFn func(next RestoreSnapshotHandlerFn) RestoreSnapshotHandlerFn
+)
+
+// RestoreSnapshotHandler ...
+func RestoreSnapshotHandler(repo repositoryx.RedirectsDefinitionRepository) RestoreSnapshotHandlerFn {
+	return func(ctx context.Context, l *zap.Logger, cmd RestoreSnapshot) error {
+		snapshot := cmd.Snapshot
+		if snapshot == nil {
+			stored, err := repo.FindSnapshot(ctx, cmd.Name)
+			if err != nil {
+				return fmt.Errorf("failed to fetch snapshot '%s': %w", cmd.Name, err)
+			}
+
+			snapshot = stored
+		}
+
+		l = l.With(zap.String("snapshot", snapshot.Name), zap.Bool("dryRun", cmd.DryRun))
+
+		current, err := repo.FindAllByFilter(ctx, snapshot.Filter())
+		if err != nil {
+			keellog.WithError(l, err).Error("failed to fetch current definitions")
+			return err
+		}
+
+		diff := storex.DiffDefinitions(SortedDefinitions(current), snapshot.InScope(), storex.DefinitionsEqual)
+
+		if cmd.Result != nil {
+			*cmd.Result = *diff
+		}
+
+		l.Info("snapshot diff",
+			zap.Int("added", len(diff.Added)),
+			zap.Int("removed", len(diff.Removed)),
+			zap.Int("changed", len(diff.Changed)),
+		)
+
+		if cmd.DryRun || diff.Empty() {
+			return nil
+		}
+
+		return applyDiff(ctx, l, repo, diff)
+	}
+}
+
+// applyDiff deletes the removed definitions and upserts the added and changed ones,
+// changed definitions with another ID replace the current ones
+func applyDiff(ctx context.Context, l *zap.Logger, repo repositoryx.RedirectsDefinitionRepository, diff *storex.DefinitionsDiff) error {
+	deletes := make([]storex.EntityID, 0, len(diff.Removed))
+	for _, def := range diff.Removed {
+		deletes = append(deletes, def.ID)
+	}
+
+	upserts := make([]*storex.RedirectDefinition, 0, len(diff.Added)+len(diff.Changed))
+	upserts = append(upserts, diff.Added...)
+
+	for _, change := range diff.Changed {
+		if change.Current.ID != change.Target.ID {
+			deletes = append(deletes, change.Current.ID)
+		}
+
+		upserts = append(upserts, change.Target)
+	}
+
+	// delete first, the upserts would otherwise violate the unique source per dimension
+	if len(deletes) > 0 {
+		if err := repo.DeleteMany(ctx, deletes); err != nil {
+			keellog.WithError(l, err).Error("failed to delete definitions")
+			return err
+		}
+	}
+
+	if len(upserts) > 0 {
+		if err := repo.UpsertMany(ctx, upserts); err != nil {
+			keellog.WithError(l, err).Error("failed to upsert definitions")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RestoreSnapshotHandlerComposed returns the handler with middleware applied to it
+func RestoreSnapshotHandlerComposed(handler RestoreSnapshotHandlerFn, middlewares ...RestoreSnapshotMiddlewareFn) RestoreSnapshotHandlerFn {
+	composed := func(next RestoreSnapshotHandlerFn) RestoreSnapshotHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, cmd RestoreSnapshot) error {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, cmd)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, cmd RestoreSnapshot) error {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, cmd)
+	})
+}
+
+// RestoreSnapshotPublishMiddleware publishes the update signal once after a restore which changed definitions,
+// the snapshot is restored as is without flattening
+func RestoreSnapshotPublishMiddleware(updateSignal *natsx.UpdateSignal) RestoreSnapshotMiddlewareFn {
+	return func(next RestoreSnapshotHandlerFn) RestoreSnapshotHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd RestoreSnapshot) error {
+			if cmd.Result == nil {
+				cmd.Result = &storex.DefinitionsDiff{}
+			}
+
+			err := next(ctx, l, cmd)
+			if err != nil {
+				return err
+			}
+
+			if cmd.DryRun || cmd.Result.Empty() {
+				return nil
+			}
+
+			l.Info("publishing update signal")
+
+			return updateSignal.Publish()
+		}
+	}
+}
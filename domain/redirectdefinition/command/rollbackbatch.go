@@ -0,0 +1,192 @@
+package redirectcommand
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+
+	keellog "github.com/foomo/keel/log"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	natsx "github.com/foomo/redirects/v2/pkg/nats"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// RollbackBatch command, restores the redirects changed or deleted by the batch and deletes the ones it created
+	RollbackBatch struct {
+		ID storex.EntityID `json:"id"`
+		// User who rolled back the batch
+		User string `json:"user,omitempty"`
+		// Result is filled by the handler if set
+		Result *RollbackBatchResult `json:"-"`
+	}
+	// RollbackBatchResult of the command
+	RollbackBatchResult struct {
+		Restored []*storex.RedirectDefinition `json:"restored"`
+		Deleted  []*storex.RedirectDefinition `json:"deleted"`
+		// Skipped redirects were changed after the batch and are kept
+		Skipped []storex.EntityID `json:"skipped,omitempty"`
+	}
+	// RollbackBatchHandlerFn handler
+	RollbackBatchHandlerFn func(ctx context.Context, l *zap.Logger, cmd RollbackBatch) error
+	// RollbackBatchMiddlewareFn middleware
+	RollbackBatchMiddlewareFn func(next RollbackBatchHandlerFn) RollbackBatchHandlerFn
+)
+
+// RollbackBatchHandler ...
+func RollbackBatchHandler(repo repositoryx.RedirectsDefinitionRepository) RollbackBatchHandlerFn {
+	return func(ctx context.Context, l *zap.Logger, cmd RollbackBatch) error {
+		l = l.With(zap.String("batch", string(cmd.ID)))
+
+		batch, err := repo.FindBatch(ctx, cmd.ID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch batch '%s': %w", cmd.ID, err)
+		}
+
+		if batch.RolledBack != "" {
+			return storex.ErrBatchRolledBack
+		}
+
+		entries, err := repo.FindBatchEntries(ctx, cmd.ID)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]*storex.EntityID, 0, len(entries))
+		for _, entry := range entries {
+			ids = append(ids, &entry.ID)
+		}
+
+		current, err := repo.FindByIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		result := RollbackBatchEntries(cmd.ID, entries, current)
+
+		if len(result.Restored) > 0 {
+			if err := repo.UpsertMany(ctx, result.Restored); err != nil {
+				keellog.WithError(l, err).Error("failed to restore definitions")
+				return err
+			}
+		}
+
+		if len(result.Deleted) > 0 {
+			deleteIDs := make([]storex.EntityID, 0, len(result.Deleted))
+			for _, def := range result.Deleted {
+				deleteIDs = append(deleteIDs, def.ID)
+			}
+
+			if err := repo.DeleteMany(ctx, deleteIDs); err != nil {
+				keellog.WithError(l, err).Error("failed to delete definitions")
+				return err
+			}
+		}
+
+		batch.RolledBack = storex.NewDateTime(time.Now())
+		batch.RolledBackBy = cmd.User
+
+		if err := repo.UpdateBatch(ctx, batch); err != nil {
+			return err
+		}
+
+		if cmd.Result != nil {
+			*cmd.Result = *result
+		}
+
+		l.Info("rolled back batch",
+			zap.Int("restored", len(result.Restored)),
+			zap.Int("deleted", len(result.Deleted)),
+			zap.Int("skipped", len(result.Skipped)),
+		)
+
+		return nil
+	}
+}
+
+// RollbackBatchEntries returns the before-images to restore and the created definitions to delete,
+// definitions changed after the batch are skipped
+func RollbackBatchEntries(batch storex.EntityID, entries []*storex.BatchEntry, current []*storex.RedirectDefinition) *RollbackBatchResult {
+	currentByID := make(map[storex.EntityID]*storex.RedirectDefinition, len(current))
+	for _, def := range current {
+		currentByID[def.ID] = def
+	}
+
+	result := &RollbackBatchResult{
+		Restored: []*storex.RedirectDefinition{},
+		Deleted:  []*storex.RedirectDefinition{},
+	}
+
+	for _, entry := range entries {
+		def, exists := currentByID[entry.ID]
+
+		switch {
+		case entry.Operation == storex.BatchOperationDeleted && !exists:
+			result.Restored = append(result.Restored, entry.Before)
+		case !exists:
+			// created or changed redirect was deleted since, nothing to roll back
+		case def.Batch != batch:
+			result.Skipped = append(result.Skipped, entry.ID)
+		case entry.Operation == storex.BatchOperationCreated:
+			result.Deleted = append(result.Deleted, def)
+		case entry.Operation == storex.BatchOperationChanged:
+			result.Restored = append(result.Restored, entry.Before)
+		default:
+			result.Skipped = append(result.Skipped, entry.ID)
+		}
+	}
+
+	return result
+}
+
+// RollbackBatchHandlerComposed returns the handler with middleware applied to it
+func RollbackBatchHandlerComposed(handler RollbackBatchHandlerFn, middlewares ...RollbackBatchMiddlewareFn) RollbackBatchHandlerFn {
+	composed := func(next RollbackBatchHandlerFn) RollbackBatchHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, cmd RollbackBatch) error {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, cmd)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, cmd RollbackBatch) error {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, cmd)
+	})
+}
+
+// RollbackBatchPublishMiddleware flattens the chains affected by the rollback and publishes the update signal
+func RollbackBatchPublishMiddleware(updateSignal *natsx.UpdateSignal, repo repositoryx.RedirectsDefinitionRepository) RollbackBatchMiddlewareFn {
+	return func(next RollbackBatchHandlerFn) RollbackBatchHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, cmd RollbackBatch) error {
+			if cmd.Result == nil {
+				cmd.Result = &RollbackBatchResult{}
+			}
+
+			err := next(ctx, l, cmd)
+			if err != nil {
+				return err
+			}
+
+			scope := NewFlattenScope(cmd.Result.Restored...)
+			scope.Add(cmd.Result.Deleted...)
+
+			if err := applyFlattening(ctx, l, repo, scope); err != nil {
+				return err
+			}
+
+			return updateSignal.Publish()
+		}
+	}
+}
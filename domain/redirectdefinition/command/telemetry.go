@@ -0,0 +1,39 @@
+package redirectcommand
+
+import (
+	"context"
+
+	telemetryx "github.com/foomo/redirects/v2/pkg/telemetry"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type commandMetrics struct {
+	flatteningDuration metric.Float64Histogram
+	flattenedRedirects metric.Int64Counter
+	autoCreateDuration metric.Float64Histogram
+	autoCreated        metric.Int64Counter
+	purgedRedirects    metric.Int64Counter
+	errors             metric.Int64Counter
+}
+
+var metrics = commandMetrics{
+	flatteningDuration: telemetryx.DurationHistogram("redirects.flattening.duration", "Duration of flattening the redirects"),
+	flattenedRedirects: telemetryx.Int64Counter("redirects.flattening.changed", "Number of redirects changed by flattening"),
+	autoCreateDuration: telemetryx.DurationHistogram("redirects.autocreate.duration", "Duration of creating automatic redirects from a contentserver export"),
+	autoCreated:        telemetryx.Int64Counter("redirects.autocreate.created", "Number of automatic redirects created per dimension"),
+	purgedRedirects:    telemetryx.Int64Counter("redirects.purge.deleted", "Number of obsolete redirects deleted by the purge"),
+	errors:             telemetryx.Int64Counter("redirects.command.errors", "Number of errors by operation"),
+}
+
+// endSpan records the error on the span and the error counter before ending the span
+func endSpan(ctx context.Context, span trace.Span, operation string, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		metrics.errors.Add(ctx, 1, metric.WithAttributes(telemetryx.AttributeOperation.String(operation)))
+	}
+
+	span.End()
+}
@@ -58,12 +58,21 @@ func UpdateRedirectHandlerComposed(handler UpdateRedirectHandlerFn, middlewares
 func UpdateRedirectPublishMiddleware(updateSignal *natsx.UpdateSignal, repo repositoryx.RedirectsDefinitionRepository) UpdateRedirectMiddlewareFn {
 	return func(next UpdateRedirectHandlerFn) UpdateRedirectHandlerFn {
 		return func(ctx context.Context, l *zap.Logger, cmd UpdateRedirect) error {
-			err := next(ctx, l, cmd)
+			// the previous source and target are in scope as well
+			previous, err := repo.FindByIDs(ctx, []*storex.EntityID{&cmd.RedirectDefinition.ID})
 			if err != nil {
 				return err
 			}
 
-			if err := applyFlattening(ctx, l, repo); err != nil {
+			err = next(ctx, l, cmd)
+			if err != nil {
+				return err
+			}
+
+			scope := NewFlattenScope(previous...)
+			scope.Add(cmd.RedirectDefinition)
+
+			if err := applyFlattening(ctx, l, repo, scope); err != nil {
 				return err
 			}
 
@@ -77,10 +86,10 @@ func UpdateRedirectPublishMiddleware(updateSignal *natsx.UpdateSignal, repo repo
 	}
 }
 
-func ValidateUpdateRedirectMiddleware(restrictedSourcesProvider providerx.RestrictedSourcesProviderFunc, repo repositoryx.RedirectsDefinitionRepository) UpdateRedirectMiddlewareFn {
+func ValidateUpdateRedirectMiddleware(restrictedSourcesProvider providerx.RestrictedSourcesProviderFunc, allowedHostsProvider providerx.AllowedHostsProviderFunc, repo repositoryx.RedirectsDefinitionRepository) UpdateRedirectMiddlewareFn {
 	return func(next UpdateRedirectHandlerFn) UpdateRedirectHandlerFn {
 		return func(ctx context.Context, l *zap.Logger, cmd UpdateRedirect) error {
-			return validateRedirect(ctx, l, repo, restrictedSourcesProvider, cmd.RedirectDefinition, next)
+			return validateRedirect(ctx, l, repo, restrictedSourcesProvider, allowedHostsProvider, cmd.RedirectDefinition, next)
 		}
 	}
 }
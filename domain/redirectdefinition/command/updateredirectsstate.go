@@ -27,6 +27,13 @@ type (
 // UpdateRedirectsStateHandler ...
 func UpdateRedirectsStateHandler(repo repositoryx.RedirectsDefinitionRepository) UpdateRedirectsStateHandlerFn {
 	return func(ctx context.Context, _ *zap.Logger, cmd UpdateRedirectsState) error {
+		// reactivated redirects are no longer soft deleted and never purged
+		for _, def := range cmd.RedirectDefinitions {
+			if !def.Stale {
+				def.Obsolete = ""
+			}
+		}
+
 		return repo.UpsertMany(ctx, cmd.RedirectDefinitions)
 	}
 }
@@ -62,7 +69,7 @@ func UpdateRedirectsStatePublishMiddleware(updateSignal *natsx.UpdateSignal, rep
 				return err
 			}
 
-			if err := applyFlattening(ctx, l, repo); err != nil {
+			if err := applyFlattening(ctx, l, repo, NewFlattenScope(cmd.RedirectDefinitions...)); err != nil {
 				return err
 			}
 
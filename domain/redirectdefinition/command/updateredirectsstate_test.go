@@ -0,0 +1,33 @@
+package redirectcommand_test
+
+import (
+	"context"
+	"testing"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	repositorytestx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository/repositorytest"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// reactivated redirects are no longer obsolete, the ones staying stale are purged as before
+func TestUpdateRedirectsStateHandler_ClearsObsolete(t *testing.T) {
+	t.Parallel()
+
+	const obsolete = storex.DateTime("2024-05-01T00:00:00.000Z")
+
+	repo := repositorytestx.NewRedirectsDefinitionRepository()
+	handler := commandx.UpdateRedirectsStateHandler(repo)
+
+	require.NoError(t, handler(context.Background(), zap.NewNop(), commandx.UpdateRedirectsState{
+		RedirectDefinitions: []*storex.RedirectDefinition{
+			{ID: "a", Dimension: "de", Source: "/a", Target: "/x", Obsolete: obsolete},
+			{ID: "b", Dimension: "de", Source: "/b", Target: "/x", Obsolete: obsolete, Stale: true},
+		},
+	}))
+
+	assert.Empty(t, repo.BySource("de", "/a").Obsolete)
+	assert.Equal(t, obsolete, repo.BySource("de", "/b").Obsolete)
+}
@@ -2,7 +2,9 @@ package redirectcommand
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/url"
 	"path"
 	"strings"
 
@@ -18,6 +20,7 @@ func validateRedirect(
 	l *zap.Logger,
 	repo repositoryx.RedirectsDefinitionRepository,
 	restrictedSourcesProvider providerx.RestrictedSourcesProviderFunc,
+	allowedHostsProvider providerx.AllowedHostsProviderFunc,
 	redirect *storex.RedirectDefinition,
 	next any,
 ) error {
@@ -27,6 +30,29 @@ func validateRedirect(
 		restrictedSources = restrictedSourcesProvider()
 	}
 
+	// Store source and target in canonical form, see storex.CanonicalizeURI
+	canonicalSource, err := redirect.Source.Canonical()
+	if err != nil {
+		return fmt.Errorf("invalid redirect source '%s': %w", redirect.Source, err)
+	}
+
+	canonicalTarget, err := redirect.Target.Canonical()
+	if err != nil {
+		return fmt.Errorf("invalid redirect target '%s': %w", redirect.Target, err)
+	}
+
+	redirect.Source, redirect.Target = canonicalSource, canonicalTarget
+
+	// Resolve the intended target first, the checks below apply to the target that is stored
+	switch next.(type) {
+	case CreateRedirectHandlerFn:
+		redirect.SetIntendedTarget(redirect.Target)
+	case UpdateRedirectHandlerFn:
+		if err := updateIntendedTarget(ctx, repo, redirect); err != nil {
+			return err
+		}
+	}
+
 	// Convert source and target to lowercase
 	source := strings.ToLower(string(redirect.Source))
 	target := strings.ToLower(string(redirect.Target))
@@ -39,15 +65,57 @@ func validateRedirect(
 		return fmt.Errorf("redirect source and target cannot be the same")
 	}
 
+	// patterns are written unescaped, so the decoded source is matched as well
+	decodedSource := source
+	if decoded, err := url.PathUnescape(string(redirect.Source)); err == nil {
+		decodedSource = strings.ToLower(decoded)
+	}
+
 	for _, restricted := range restrictedSources {
 		restricted = strings.ToLower(restricted)
 
 		matched, _ := path.Match(restricted, source)
+		if !matched {
+			matched, _ = path.Match(restricted, decodedSource)
+		}
+
 		if matched {
 			return fmt.Errorf("source '%s' is restricted due to pattern '%s'", redirect.Source, restricted)
 		}
 	}
 
+	// Check the target against open redirects
+	if redirect.Target != "" || redirect.Code.IsRedirection() {
+		allowedHosts := storex.AllowedHosts{WarnOnly: true}
+		if allowedHostsProvider != nil {
+			allowedHosts = allowedHostsProvider(redirect.Dimension)
+		}
+
+		if _, err := allowedHosts.ValidateTarget(redirect.Target); errors.Is(err, storex.ErrHostNotAllowed) && allowedHosts.WarnOnly {
+			l.Warn("accepted redirect target to a host which is not allowed",
+				zap.String("dimension", string(redirect.Dimension)),
+				zap.String("source", string(redirect.Source)),
+				zap.String("target", string(redirect.Target)),
+			)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if !redirect.MatchType.Valid() {
+		return fmt.Errorf("invalid match type '%s'", redirect.MatchType)
+	}
+
+	if redirect.IsPrefix() && (redirect.Source == "/" || strings.Contains(string(redirect.Source), "?")) {
+		return fmt.Errorf("prefix source '%s' must be a path below the root without query", redirect.Source)
+	}
+
+	if redirect.ParamPolicy != nil {
+		if err := redirect.ParamPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Fetch all existing redirects for the dimension
 	existingRedirects, err := repo.FindAllByDimension(ctx, redirect.Dimension, true)
 	if err != nil {
@@ -59,6 +127,11 @@ func validateRedirect(
 		return fmt.Errorf("cyclic redirect detected: %s → %s creates a loop", redirect.Source, redirect.Target)
 	}
 
+	// Manual changes are not part of an automatic batch and are kept on rollback,
+	// edited redirects are no longer soft deleted and never purged
+	redirect.Batch = ""
+	redirect.Obsolete = ""
+
 	// Call the next handler dynamically based on function type
 	switch fn := next.(type) {
 	case CreateRedirectHandlerFn:
@@ -69,3 +142,30 @@ func validateRedirect(
 		return fmt.Errorf("invalid handler type")
 	}
 }
+
+// updateIntendedTarget derives the intended target of an updated redirect: a changed target replaces it,
+// otherwise a changed original target does, and an unchanged redirect keeps both
+func updateIntendedTarget(ctx context.Context, repo repositoryx.RedirectsDefinitionRepository, redirect *storex.RedirectDefinition) error {
+	if redirect.OriginalTarget == "" {
+		return nil
+	}
+
+	existing, err := repo.FindByIDs(ctx, []*storex.EntityID{&redirect.ID})
+	if err != nil {
+		return fmt.Errorf("failed to fetch existing redirect: %w", err)
+	}
+
+	switch {
+	case len(existing) == 0 || existing[0].Target != redirect.Target:
+		redirect.SetIntendedTarget(redirect.Target)
+	case existing[0].OriginalTarget != redirect.OriginalTarget:
+		originalTarget, err := redirect.OriginalTarget.Canonical()
+		if err != nil {
+			return fmt.Errorf("invalid redirect original target '%s': %w", redirect.OriginalTarget, err)
+		}
+
+		redirect.SetIntendedTarget(originalTarget)
+	}
+
+	return nil
+}
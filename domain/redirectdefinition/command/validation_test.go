@@ -0,0 +1,121 @@
+package redirectcommand_test
+
+import (
+	"context"
+	"testing"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	repositorytestx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository/repositorytest"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestValidateUpdateRedirectOriginalTarget(t *testing.T) {
+	t.Parallel()
+
+	existing := func() []*storex.RedirectDefinition {
+		return []*storex.RedirectDefinition{
+			{ID: "a", Dimension: "de", Source: "/a", Target: "/c", OriginalTarget: "/b", Code: storex.RedirectCodePermanent},
+			{ID: "x", Dimension: "de", Source: "/x", Target: "/a", Code: storex.RedirectCodePermanent},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		originalTarget storex.RedirectTarget
+		wantErr        string
+		wantTarget     storex.RedirectTarget
+	}{
+		{name: "valid", originalTarget: "/d", wantTarget: "/d"},
+		{name: "external host", originalTarget: "https://evil.com/x", wantErr: "external redirect target host"},
+		{name: "same as source", originalTarget: "/a", wantErr: "cannot be the same"},
+		{name: "cycle", originalTarget: "/x", wantErr: "cyclic redirect"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := repositorytestx.NewRedirectsDefinitionRepository(existing()...)
+			handler := commandx.UpdateRedirectHandlerComposed(
+				commandx.UpdateRedirectHandler(repo),
+				commandx.ValidateUpdateRedirectMiddleware(nil, func(storex.Dimension) storex.AllowedHosts { return storex.AllowedHosts{} }, repo),
+			)
+
+			redirect := repo.BySource("de", "/a")
+			redirect.OriginalTarget = tt.originalTarget
+
+			err := handler(context.Background(), zap.NewNop(), commandx.UpdateRedirect{RedirectDefinition: redirect})
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				assert.Equal(t, storex.RedirectTarget("/c"), repo.BySource("de", "/a").Target)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			stored := repo.BySource("de", "/a")
+			assert.Equal(t, tt.wantTarget, stored.Target)
+			assert.Empty(t, stored.OriginalTarget)
+		})
+	}
+}
+
+// manually edited redirects are no longer obsolete
+func TestValidateUpdateRedirectClearsObsolete(t *testing.T) {
+	t.Parallel()
+
+	repo := repositorytestx.NewRedirectsDefinitionRepository(&storex.RedirectDefinition{
+		ID: "a", Dimension: "de", Source: "/a", Target: "/b", Code: storex.RedirectCodePermanent,
+		RedirectionType: storex.RedirectionTypeAutomatic, Stale: true, Obsolete: "2024-05-01T00:00:00.000Z",
+	})
+	handler := commandx.UpdateRedirectHandlerComposed(
+		commandx.UpdateRedirectHandler(repo),
+		commandx.ValidateUpdateRedirectMiddleware(nil, nil, repo),
+	)
+
+	redirect := repo.BySource("de", "/a")
+	redirect.Target = "/c"
+
+	require.NoError(t, handler(context.Background(), zap.NewNop(), commandx.UpdateRedirect{RedirectDefinition: redirect}))
+	assert.Empty(t, repo.BySource("de", "/a").Obsolete)
+}
+
+func TestValidateRedirectRestrictedSources(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		source     storex.RedirectSource
+		restricted bool
+	}{
+		{source: "/über-uns/team", restricted: true},
+		{source: "/%C3%9Cber-uns/team", restricted: true},
+		{source: "/services/x", restricted: true},
+		{source: "/ueber-uns/team", restricted: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.source), func(t *testing.T) {
+			t.Parallel()
+
+			repo := repositorytestx.NewRedirectsDefinitionRepository()
+			handler := commandx.CreateRedirectHandlerComposed(
+				commandx.CreateRedirectHandler(repo),
+				commandx.ValidateRedirectMiddleware(func() []string { return []string{"/über-uns/*", "/services/*"} }, nil, repo),
+			)
+
+			err := handler(context.Background(), zap.NewNop(), commandx.CreateRedirect{RedirectDefinition: &storex.RedirectDefinition{
+				Dimension: "de", Source: tt.source, Target: "/target", Code: storex.RedirectCodePermanent,
+			}})
+			if tt.restricted {
+				require.ErrorContains(t, err, "is restricted")
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
@@ -5,9 +5,15 @@ import (
 )
 
 type Commands struct {
-	CreateRedirects      commandx.CreateRedirectsHandlerFn
-	CreateRedirect       commandx.CreateRedirectHandlerFn
-	UpdateRedirect       commandx.UpdateRedirectHandlerFn
-	UpdateRedirectsState commandx.UpdateRedirectsStateHandlerFn
-	DeleteRedirect       commandx.DeleteRedirectHandlerFn
+	CreateRedirects        commandx.CreateRedirectsHandlerFn
+	CreateRedirect         commandx.CreateRedirectHandlerFn
+	UpdateRedirect         commandx.UpdateRedirectHandlerFn
+	UpdateRedirectsState   commandx.UpdateRedirectsStateHandlerFn
+	DeleteRedirect         commandx.DeleteRedirectHandlerFn
+	CanonicalizeRedirects  commandx.CanonicalizeRedirectsHandlerFn
+	FlattenAllRedirects    commandx.FlattenAllRedirectsHandlerFn
+	PurgeObsoleteRedirects commandx.PurgeObsoleteRedirectsHandlerFn
+	RollbackBatch          commandx.RollbackBatchHandlerFn
+	CreateSnapshot         commandx.CreateSnapshotHandlerFn
+	RestoreSnapshot        commandx.RestoreSnapshotHandlerFn
 }
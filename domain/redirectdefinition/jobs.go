@@ -0,0 +1,308 @@
+package redirectdefinition
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/foomo/contentserver/content"
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.uber.org/zap"
+)
+
+// ErrJobQueueDisabled is returned by the job methods without a job repository
+var ErrJobQueueDisabled = errors.New("job queue is not configured")
+
+// jobPayload is the contentserver export of a job
+type jobPayload struct {
+	OldState map[string]*content.RepoNode `json:"oldState"`
+	NewState map[string]*content.RepoNode `json:"newState"`
+}
+
+// EnqueueCreateRedirects stores the contentserver export as job and returns its ID,
+// the job is processed by RunJobWorker
+func (a *API) EnqueueCreateRedirects(ctx context.Context, oldState, newState map[string]*content.RepoNode) (storex.EntityID, error) {
+	if a.jobRepository == nil {
+		return "", ErrJobQueueDisabled
+	}
+
+	payload, err := encodeJobPayload(jobPayload{OldState: oldState, NewState: newState})
+	if err != nil {
+		return "", err
+	}
+
+	now := storex.NewDateTime(time.Now())
+	job := &storex.Job{
+		ID:          storex.NewEntityID(),
+		Status:      storex.JobStatusQueued,
+		Created:     now,
+		NextAttempt: now,
+		Dimensions:  map[storex.Dimension]*storex.JobDimension{},
+		Payload:     payload,
+	}
+
+	for _, state := range []map[string]*content.RepoNode{oldState, newState} {
+		for dimension := range state {
+			job.Dimensions[storex.Dimension(dimension)] = &storex.JobDimension{Status: storex.JobStatusQueued}
+		}
+	}
+
+	job.Progress.Total = len(job.Dimensions)
+
+	if err := a.jobRepository.Enqueue(ctx, job); err != nil {
+		return "", err
+	}
+
+	a.l.Info("enqueued contentserver export", zap.String("job", string(job.ID)), zap.Int("dimensions", job.Progress.Total))
+
+	return job.ID, nil
+}
+
+// GetJob returns the status of the job
+func (a *API) GetJob(ctx context.Context, id storex.EntityID) (*storex.Job, error) {
+	if a.jobRepository == nil {
+		return nil, ErrJobQueueDisabled
+	}
+
+	return a.jobRepository.FindJob(ctx, id)
+}
+
+// RunJobWorker processes the queued jobs, it polls for new jobs in the given interval until the context is done.
+// Several workers may run in parallel, each job is claimed by a single worker.
+func (a *API) RunJobWorker(ctx context.Context, pollInterval time.Duration) error {
+	if a.jobRepository == nil {
+		return ErrJobQueueDisabled
+	}
+
+	worker := commandx.LeaseOwner()
+
+	for {
+		job, err := a.jobRepository.Claim(ctx, worker, a.jobPolicy.TTL())
+		if err != nil {
+			a.l.Error("failed to claim job", zap.Error(err))
+		} else if job != nil {
+			a.processJob(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// processJob runs the job while renewing its claim in the background, failed jobs are queued again
+// until the max attempts of the job policy are reached. If the claim is lost the job is left to the
+// worker that claimed it again.
+func (a *API) processJob(ctx context.Context, job *storex.Job) {
+	l := a.l.With(zap.String("job", string(job.ID)), zap.Int("attempt", job.Attempts))
+	l.Info("processing job")
+
+	jobCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	done := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		a.renewJob(jobCtx, l, job, done, cancel)
+	}()
+
+	err := a.runJob(jobCtx, l, job)
+
+	close(done)
+	wg.Wait()
+
+	if errors.Is(err, storex.ErrLeaseLost) || errors.Is(context.Cause(jobCtx), storex.ErrLeaseLost) {
+		l.Error("job was claimed again while running")
+		return
+	}
+
+	a.finishJob(ctx, l, job, err)
+}
+
+// runJob runs the automatic pipeline for each pending dimension of the job
+func (a *API) runJob(ctx context.Context, l *zap.Logger, job *storex.Job) error {
+	payload, err := decodeJobPayload(job.Payload)
+	if err != nil {
+		// a broken payload does not get better with retries
+		job.Attempts = a.jobPolicy.Attempts()
+		return err
+	}
+
+	var failed int
+
+	for _, dimension := range job.Pending() {
+		jobDimension := job.Dimensions[dimension]
+		jobDimension.Status = storex.JobStatusRunning
+
+		result, err := a.CreateRedirects(ctx, commandx.CreateRedirects{
+			OldState:   dimensionState(payload.OldState, dimension),
+			NewState:   dimensionState(payload.NewState, dimension),
+			Dimensions: []storex.Dimension{dimension},
+		})
+		if err != nil {
+			l.Error("failed to process dimension", zap.String("dimension", string(dimension)), zap.Error(err))
+
+			failed++
+			jobDimension.Status = storex.JobStatusFailed
+			jobDimension.Error = err.Error()
+		} else {
+			jobDimension.Status = storex.JobStatusSucceeded
+			jobDimension.Error = ""
+			jobDimension.Batch = result.Batch
+
+			if counts, ok := result.Dimensions[dimension]; ok {
+				jobDimension.Created = counts.Created
+				jobDimension.Updated = counts.Updated
+				jobDimension.Staled = counts.Staled
+				jobDimension.Deleted = counts.Deleted
+				jobDimension.Conflicts = counts.Conflicts
+			}
+
+			logConflicts(l, result.Conflicts)
+		}
+
+		job.Progress.Done = job.Progress.Total - len(job.Pending())
+
+		if err := a.jobRepository.UpdateStatus(ctx, job); errors.Is(err, storex.ErrLeaseLost) {
+			return err
+		} else if err != nil {
+			l.Error("failed to update job progress", zap.Error(err))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d dimensions failed", failed, job.Progress.Total)
+	}
+
+	return nil
+}
+
+// renewJob renews the claim of the job until done, it cancels with storex.ErrLeaseLost if the job
+// was claimed again or the claim could not be renewed before it expired
+func (a *API) renewJob(ctx context.Context, l *zap.Logger, job *storex.Job, done <-chan struct{}, cancel context.CancelCauseFunc) {
+	ttl := a.jobPolicy.TTL()
+	expires := time.Now().Add(ttl)
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed := time.Now().Add(ttl)
+
+			err := a.jobRepository.Renew(ctx, job, ttl)
+			if err == nil {
+				expires = renewed
+				continue
+			}
+
+			if errors.Is(err, storex.ErrLeaseLost) || time.Now().After(expires) {
+				cancel(storex.ErrLeaseLost)
+				return
+			}
+
+			l.Warn("failed to renew job claim, retrying", zap.Error(err))
+		}
+	}
+}
+
+// finishJob sets the final status of the job or queues it again for the next attempt
+func (a *API) finishJob(ctx context.Context, l *zap.Logger, job *storex.Job, err error) {
+	now := time.Now()
+
+	switch {
+	case err == nil:
+		job.Status = storex.JobStatusSucceeded
+		job.Error = ""
+		job.Finished = storex.NewDateTime(now)
+
+		l.Info("job succeeded")
+	case job.Attempts >= a.jobPolicy.Attempts():
+		job.Status = storex.JobStatusFailed
+		job.Error = err.Error()
+		job.Finished = storex.NewDateTime(now)
+
+		l.Error("job failed", zap.Error(err))
+	default:
+		job.Status = storex.JobStatusQueued
+		job.Error = err.Error()
+		job.NextAttempt = storex.NewDateTime(now.Add(a.jobPolicy.Delay(job.Attempts)))
+
+		l.Warn("job failed, retrying", zap.Error(err), zap.String("nextAttempt", string(job.NextAttempt)))
+	}
+
+	// persist the status even if the worker is shutting down
+	if err := a.jobRepository.UpdateStatus(context.WithoutCancel(ctx), job); err != nil {
+		l.Error("failed to update job status", zap.Error(err))
+	}
+}
+
+// dimensionState returns the state restricted to the dimension
+func dimensionState(state map[string]*content.RepoNode, dimension storex.Dimension) map[string]*content.RepoNode {
+	if node, ok := state[string(dimension)]; ok {
+		return map[string]*content.RepoNode{string(dimension): node}
+	}
+
+	return map[string]*content.RepoNode{}
+}
+
+func encodeJobPayload(payload jobPayload) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func decodeJobPayload(data []byte) (*jobPayload, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var payload jobPayload
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	return &payload, nil
+}
+
+func logConflicts(l *zap.Logger, conflicts []*storex.RedirectConflict) {
+	for _, conflict := range conflicts {
+		l.Warn("automatic redirect rejected in favour of existing redirect",
+			zap.String("dimension", string(conflict.Dimension)),
+			zap.String("source", string(conflict.Source)),
+			zap.String("existingTarget", string(conflict.ExistingTarget)),
+			zap.String("rejectedTarget", string(conflict.RejectedTarget)),
+			zap.String("reason", string(conflict.Reason)),
+		)
+	}
+}
@@ -0,0 +1,213 @@
+package redirectdefinition_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redirectdefinitionx "github.com/foomo/redirects/v2/domain/redirectdefinition"
+	repositorytestx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository/repositorytest"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// flakyRepository fails the first upserts and delays the others
+type flakyRepository struct {
+	*repositorytestx.RedirectsDefinitionRepository
+	failures atomic.Int32
+	delay    time.Duration
+}
+
+func (r *flakyRepository) UpsertMany(ctx context.Context, defs []*storex.RedirectDefinition) error {
+	if r.failures.Add(-1) >= 0 {
+		return errors.New("upsert failed")
+	}
+
+	time.Sleep(r.delay)
+
+	return r.RedirectsDefinitionRepository.UpsertMany(ctx, defs)
+}
+
+func newJobAPI(t *testing.T, repo *flakyRepository, jobs *repositorytestx.JobRepository, policy storex.JobPolicy) *redirectdefinitionx.API {
+	t.Helper()
+
+	api, err := redirectdefinitionx.NewAPI(zap.NewNop(), repo, nil,
+		redirectdefinitionx.WithJobRepository(jobs),
+		redirectdefinitionx.WithJobPolicy(policy),
+	)
+	require.NoError(t, err)
+
+	return api
+}
+
+// startJobWorkers runs the workers until the test is done
+func startJobWorkers(t *testing.T, api *redirectdefinitionx.API, workers int) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{}, workers)
+
+	for range workers {
+		go func() {
+			_ = api.RunJobWorker(ctx, time.Millisecond)
+			done <- struct{}{}
+		}()
+	}
+
+	t.Cleanup(func() {
+		cancel()
+
+		for range workers {
+			<-done
+		}
+	})
+}
+
+func waitForJob(t *testing.T, api *redirectdefinitionx.API, id storex.EntityID, status storex.JobStatus) *storex.Job {
+	t.Helper()
+
+	var job *storex.Job
+
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = api.GetJob(context.Background(), id)
+
+		return err == nil && job.Status == status
+	}, 5*time.Second, time.Millisecond)
+
+	return job
+}
+
+func enqueueMove(t *testing.T, api *redirectdefinitionx.API, from, to string) storex.EntityID {
+	t.Helper()
+
+	id, err := api.EnqueueCreateRedirects(context.Background(),
+		contentState(map[string]string{"b": from}),
+		contentState(map[string]string{"b": to}),
+	)
+	require.NoError(t, err)
+
+	return id
+}
+
+func TestJobWorker_Succeeds(t *testing.T) {
+	t.Parallel()
+
+	repo := &flakyRepository{RedirectsDefinitionRepository: repositorytestx.NewRedirectsDefinitionRepository()}
+	api := newJobAPI(t, repo, repositorytestx.NewJobRepository(), storex.JobPolicy{})
+
+	id := enqueueMove(t, api, "/b", "/c")
+	startJobWorkers(t, api, 1)
+
+	job := waitForJob(t, api, id, storex.JobStatusSucceeded)
+	assert.Equal(t, 1, job.Attempts)
+	assert.Equal(t, storex.JobProgress{Total: 1, Done: 1}, job.Progress)
+	assert.Equal(t, storex.JobStatusSucceeded, job.Dimensions["de"].Status)
+	assert.Equal(t, 1, job.Dimensions["de"].Created)
+	assert.NotEmpty(t, job.Finished)
+
+	def := repo.BySource("de", "/b")
+	require.NotNil(t, def)
+	assert.Equal(t, storex.RedirectTarget("/c"), def.Target)
+}
+
+func TestJobWorker_RetriesFailedDimensions(t *testing.T) {
+	t.Parallel()
+
+	repo := &flakyRepository{RedirectsDefinitionRepository: repositorytestx.NewRedirectsDefinitionRepository()}
+	repo.failures.Store(1)
+	api := newJobAPI(t, repo, repositorytestx.NewJobRepository(), storex.JobPolicy{RetryDelay: time.Millisecond})
+
+	id := enqueueMove(t, api, "/b", "/c")
+	startJobWorkers(t, api, 1)
+
+	job := waitForJob(t, api, id, storex.JobStatusSucceeded)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Empty(t, job.Error)
+	assert.Empty(t, job.Dimensions["de"].Error)
+	assert.NotNil(t, repo.BySource("de", "/b"))
+}
+
+func TestJobWorker_FailsAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	repo := &flakyRepository{RedirectsDefinitionRepository: repositorytestx.NewRedirectsDefinitionRepository()}
+	repo.failures.Store(2)
+	api := newJobAPI(t, repo, repositorytestx.NewJobRepository(), storex.JobPolicy{MaxAttempts: 2, RetryDelay: time.Millisecond})
+
+	id := enqueueMove(t, api, "/b", "/c")
+	startJobWorkers(t, api, 1)
+
+	job := waitForJob(t, api, id, storex.JobStatusFailed)
+	assert.Equal(t, 2, job.Attempts)
+	assert.Equal(t, "1 of 1 dimensions failed", job.Error)
+	assert.Equal(t, storex.JobStatusFailed, job.Dimensions["de"].Status)
+	assert.NotEmpty(t, job.Finished)
+}
+
+func TestJobWorker_BrokenPayload(t *testing.T) {
+	t.Parallel()
+
+	jobs := repositorytestx.NewJobRepository()
+	api := newJobAPI(t, &flakyRepository{RedirectsDefinitionRepository: repositorytestx.NewRedirectsDefinitionRepository()}, jobs, storex.JobPolicy{})
+
+	now := storex.NewDateTime(time.Now())
+	require.NoError(t, jobs.Enqueue(context.Background(), &storex.Job{
+		ID:          "broken",
+		Status:      storex.JobStatusQueued,
+		Created:     now,
+		NextAttempt: now,
+		Dimensions:  map[storex.Dimension]*storex.JobDimension{"de": {Status: storex.JobStatusQueued}},
+		Payload:     []byte("not gzip"),
+	}))
+	startJobWorkers(t, api, 1)
+
+	// the job is not retried
+	job := waitForJob(t, api, "broken", storex.JobStatusFailed)
+	assert.Equal(t, 1, job.Attempts)
+	assert.NotEmpty(t, job.Error)
+}
+
+// a job waiting for its retry holds back the later jobs
+func TestJobWorker_KeepsOrder(t *testing.T) {
+	t.Parallel()
+
+	repo := &flakyRepository{RedirectsDefinitionRepository: repositorytestx.NewRedirectsDefinitionRepository()}
+	repo.failures.Store(1)
+	api := newJobAPI(t, repo, repositorytestx.NewJobRepository(), storex.JobPolicy{RetryDelay: time.Hour})
+
+	first := enqueueMove(t, api, "/b", "/c")
+	second := enqueueMove(t, api, "/c", "/d")
+	startJobWorkers(t, api, 2)
+
+	require.Eventually(t, func() bool {
+		job, err := api.GetJob(context.Background(), first)
+		return err == nil && job.Status == storex.JobStatusQueued && job.Attempts == 1
+	}, 5*time.Second, time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+
+	job, err := api.GetJob(context.Background(), second)
+	require.NoError(t, err)
+	assert.Equal(t, storex.JobStatusQueued, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Empty(t, repo.Definitions())
+}
+
+// the claim is renewed while the job runs longer than the lease TTL, so no other worker takes it over
+func TestJobWorker_RenewsClaim(t *testing.T) {
+	t.Parallel()
+
+	repo := &flakyRepository{RedirectsDefinitionRepository: repositorytestx.NewRedirectsDefinitionRepository(), delay: 200 * time.Millisecond}
+	api := newJobAPI(t, repo, repositorytestx.NewJobRepository(), storex.JobPolicy{LeaseTTL: 30 * time.Millisecond})
+
+	id := enqueueMove(t, api, "/b", "/c")
+	startJobWorkers(t, api, 2)
+
+	job := waitForJob(t, api, id, storex.JobStatusSucceeded)
+	assert.Equal(t, 1, job.Attempts)
+}
@@ -3,6 +3,8 @@ package redirectdefinition
 import (
 	"context"
 
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
 	providerx "github.com/foomo/redirects/v2/pkg/provider"
 )
 
@@ -16,11 +18,31 @@ func defaultUserProvider(_ context.Context) string {
 	return "unknown"
 }
 
+// returns false, meaning privileged search modes like regex are disabled.
+func defaultPrivilegedUserProvider(_ context.Context) bool {
+	return false
+}
+
 // returns false, meaning automatic redirects are enabled by default.
 func defaultIsAutomaticRedirectInitiallyStaleProvider() bool {
 	return false
 }
 
+// returns no hosts in warn-only mode, meaning absolute targets are logged but accepted until the hosts are configured.
+func defaultAllowedHostsProvider(_ storex.Dimension) storex.AllowedHosts {
+	return storex.AllowedHosts{WarnOnly: true}
+}
+
+// returns the default policy with the initial stale state of the IsAutomaticRedirectInitiallyStaleProvider.
+func (a *API) defaultAutoRedirectPolicyProvider(_ storex.Dimension, _ string) storex.AutoRedirectPolicy {
+	return storex.DefaultAutoRedirectPolicy(a.isAutomaticRedirectInitiallyStaleProvider())
+}
+
+// returns no strategy, meaning the URIs of deleted content return 404.
+func defaultDeletedContentPolicyProvider(_ storex.Dimension, _ string) storex.DeletedContentPolicy {
+	return storex.DeletedContentPolicy{}
+}
+
 func WithSiteIdentifierProvider(siteIdentifierFunc providerx.SiteIdentifierProviderFunc) Option {
 	return func(api *API) {
 		api.getSiteIdentifierProvider = siteIdentifierFunc
@@ -39,8 +61,72 @@ func WithUserProvider(provider providerx.UserProviderFunc) Option {
 	}
 }
 
+func WithPrivilegedUserProvider(provider providerx.PrivilegedUserProviderFunc) Option {
+	return func(api *API) {
+		api.privilegedUserProvider = provider
+	}
+}
+
 func WithIsAutomaticRedirectInitiallyStaleProvider(provider providerx.IsAutomaticRedirectInitiallyStaleProviderFunc) Option {
 	return func(api *API) {
 		api.isAutomaticRedirectInitiallyStaleProvider = provider
 	}
 }
+
+func WithAllowedHostsProvider(provider providerx.AllowedHostsProviderFunc) Option {
+	return func(api *API) {
+		api.allowedHostsProvider = provider
+	}
+}
+
+func WithDeletedContentPolicyProvider(provider providerx.DeletedContentPolicyProviderFunc) Option {
+	return func(api *API) {
+		api.deletedContentPolicyProvider = provider
+	}
+}
+
+// WithAutoRedirectPolicyProvider configures the automatic redirects per dimension and MimeType,
+// custom policies should start from storex.DefaultAutoRedirectPolicy.
+func WithAutoRedirectPolicyProvider(provider providerx.AutoRedirectPolicyProviderFunc) Option {
+	return func(api *API) {
+		api.autoRedirectPolicyProvider = provider
+	}
+}
+
+// WithDeletePolicy configures whether obsolete automatic redirects are soft deleted (default), deleted,
+// or soft deleted and purged after the retention period by the purge job.
+func WithDeletePolicy(policy storex.DeletePolicy) Option {
+	return func(api *API) {
+		api.deletePolicy = policy
+	}
+}
+
+// WithLeaseRepository enables the lock around the automatic pipeline and full flattening,
+// required if several instances process contentserver exports.
+func WithLeaseRepository(repo repositoryx.LeaseRepository) Option {
+	return func(api *API) {
+		api.leaseRepository = repo
+	}
+}
+
+// WithLockPolicy configures whether to wait for (default) or fail on a held lock and the lease timings.
+func WithLockPolicy(policy storex.LockPolicy) Option {
+	return func(api *API) {
+		api.lockPolicy = policy
+	}
+}
+
+// WithJobRepository enables the job queue, contentserver exports are then stored as jobs
+// and processed by API.RunJobWorker instead of within the request.
+func WithJobRepository(repo repositoryx.JobRepository) Option {
+	return func(api *API) {
+		api.jobRepository = repo
+	}
+}
+
+// WithJobPolicy configures the max attempts and retry delay of jobs and the lease of their workers.
+func WithJobPolicy(policy storex.JobPolicy) Option {
+	return func(api *API) {
+		api.jobPolicy = policy
+	}
+}
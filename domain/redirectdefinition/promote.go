@@ -0,0 +1,158 @@
+package redirectdefinition
+
+import (
+	"context"
+	"fmt"
+
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"go.uber.org/zap"
+)
+
+// NewRepositoryRedirectsProviderFunc returns a RedirectsProviderFunc loading the definitions in the scope of the filter
+// from the repository, e.g. of another environment
+func NewRepositoryRedirectsProviderFunc(repo repositoryx.RedirectsDefinitionRepository, filter storex.RedirectsFilter) providerx.RedirectsProviderFunc {
+	return func(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+		definitions, err := repo.FindAllByFilter(ctx, filter)
+
+		return definitions, err, nil
+	}
+}
+
+// DiffRedirects compares two redirect sets in the scope of the filter, definitions are matched by dimension
+// and source and compared without the fields specific to an environment
+func DiffRedirects(ctx context.Context, current, target providerx.RedirectsProviderFunc, filter storex.RedirectsFilter) (*storex.DefinitionsDiff, error) {
+	currentDefinitions, err := loadRedirectSet(ctx, current, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load current redirects: %w", err)
+	}
+
+	targetDefinitions, err := loadRedirectSet(ctx, target, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load target redirects: %w", err)
+	}
+
+	return storex.DiffDefinitions(currentDefinitions, targetDefinitions, storex.DefinitionsEquivalent), nil
+}
+
+// DiffRedirects compares the redirects of this environment with the source, e.g. the snapshot file or
+// repository of stage. Added and changed entries are the ones to promote.
+func (a *API) DiffRedirects(ctx context.Context, source providerx.RedirectsProviderFunc, filter storex.RedirectsFilter) (*storex.DefinitionsDiff, error) {
+	return DiffRedirects(ctx, NewRepositoryRedirectsProviderFunc(a.repo, filter), source, filter)
+}
+
+// PromoteRedirects applies the selected changes of a diff from DiffRedirects through the validation and publish
+// pipeline of CreateRedirect, UpdateRedirect and DeleteRedirect. Changes to redirects modified since the diff are
+// handled by the strategy, changes rejected by the validation are reported as failed.
+func (a *API) PromoteRedirects(
+	ctx context.Context,
+	selection *storex.DefinitionsDiff,
+	strategy storex.PromoteConflictStrategy,
+	dryRun bool,
+) (*storex.PromotionResult, error) {
+	live, err := a.repo.FindAll(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := storex.PlanPromotion(selection, commandx.SortedDefinitions(live), strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	l := a.l.With(zap.Bool("dryRun", dryRun))
+	for _, conflict := range plan.Conflicts {
+		l.Warn("promotion conflict",
+			zap.String("dimension", string(conflict.Dimension)),
+			zap.String("source", string(conflict.Source)),
+			zap.String("reason", string(conflict.Reason)),
+			zap.Bool("overwritten", conflict.Overwritten),
+		)
+	}
+
+	if dryRun {
+		return plan, nil
+	}
+
+	result := &storex.PromotionResult{
+		Created:   []*storex.RedirectDefinition{},
+		Updated:   []*storex.RedirectDefinition{},
+		Deleted:   []*storex.RedirectDefinition{},
+		Conflicts: plan.Conflicts,
+	}
+	failed := func(def *storex.RedirectDefinition, err error) {
+		l.Warn("failed to promote redirect", zap.String("source", string(def.Source)), zap.Error(err))
+		result.Failed = append(result.Failed, &storex.PromotionFailure{Definition: def, Error: err.Error()})
+	}
+
+	for _, def := range plan.Deleted {
+		if err := a.DeleteRedirect(ctx, commandx.DeleteRedirect{ID: def.ID}); err != nil {
+			failed(def, err)
+			continue
+		}
+
+		result.Deleted = append(result.Deleted, def)
+	}
+
+	for _, def := range plan.Updated {
+		a.setLastUpdatedBy(ctx, def)
+
+		if err := a.UpdateRedirect(ctx, commandx.UpdateRedirect{RedirectDefinition: def}); err != nil {
+			failed(def, err)
+			continue
+		}
+
+		result.Updated = append(result.Updated, def)
+	}
+
+	for _, def := range plan.Created {
+		a.setLastUpdatedBy(ctx, def)
+
+		if err := a.CreateRedirect(ctx, commandx.CreateRedirect{RedirectDefinition: def}); err != nil {
+			failed(def, err)
+			continue
+		}
+
+		result.Created = append(result.Created, def)
+	}
+
+	l.Info("promoted redirects",
+		zap.Int("created", len(result.Created)),
+		zap.Int("updated", len(result.Updated)),
+		zap.Int("deleted", len(result.Deleted)),
+		zap.Int("conflicts", len(result.Conflicts)),
+		zap.Int("failed", len(result.Failed)),
+	)
+
+	return result, nil
+}
+
+// loadRedirectSet returns the definitions of the set in the scope of the filter
+func loadRedirectSet(ctx context.Context, provider providerx.RedirectsProviderFunc, filter storex.RedirectsFilter) ([]*storex.RedirectDefinition, error) {
+	definitions, err, clientErr := provider(ctx)
+	if err != nil {
+		return nil, err
+	} else if clientErr != nil {
+		return nil, clientErr
+	}
+
+	inScope := make(map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, len(definitions))
+
+	for dimension, bySource := range definitions {
+		if !filter.Matches(dimension) {
+			continue
+		}
+
+		inScope[dimension] = make(map[storex.RedirectSource]*storex.RedirectDefinition, len(bySource))
+
+		for source, def := range bySource {
+			if filter.IncludeStale || !def.Stale {
+				inScope[dimension][source] = def
+			}
+		}
+	}
+
+	return commandx.SortedDefinitions(inScope), nil
+}
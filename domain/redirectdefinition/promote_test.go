@@ -0,0 +1,69 @@
+package redirectdefinition_test
+
+import (
+	"context"
+	"testing"
+
+	redirectdefinitionx "github.com/foomo/redirects/v2/domain/redirectdefinition"
+	repositorytestx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository/repositorytest"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromoteRedirects(t *testing.T) {
+	t.Parallel()
+
+	live := repositorytestx.NewRedirectsDefinitionRepository(
+		&storex.RedirectDefinition{ID: "p1", Dimension: "de", Source: "/changed", Target: "/old", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+		&storex.RedirectDefinition{ID: "p2", Dimension: "de", Source: "/edited", Target: "/manual", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+		&storex.RedirectDefinition{ID: "p3", Dimension: "de", Source: "/removed", Target: "/y", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+	)
+	stage := repositorytestx.NewRedirectsDefinitionRepository(
+		// flattened in stage, /b is not a redirect in the live environment
+		&storex.RedirectDefinition{ID: "s1", Dimension: "de", Source: "/new", Target: "/c", OriginalTarget: "/b", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+		&storex.RedirectDefinition{ID: "s2", Dimension: "de", Source: "/changed", Target: "/new-target", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+		&storex.RedirectDefinition{ID: "s3", Dimension: "de", Source: "/edited", Target: "/staged", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+		&storex.RedirectDefinition{ID: "s4", Dimension: "de", Source: "/evil", Target: "https://evil.com/", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeManual},
+	)
+	api := newTestAPI(t, live, redirectdefinitionx.WithAllowedHostsProvider(func(storex.Dimension) storex.AllowedHosts {
+		return storex.AllowedHosts{}
+	}))
+	ctx := context.Background()
+
+	diff, err := api.DiffRedirects(ctx, redirectdefinitionx.NewRepositoryRedirectsProviderFunc(stage, storex.RedirectsFilter{}), storex.RedirectsFilter{})
+	require.NoError(t, err)
+
+	// /edited is changed in the live environment after the diff
+	edited := live.BySource("de", "/edited")
+	edited.Target = "/edited-live"
+	require.NoError(t, live.Update(ctx, edited))
+
+	plan, err := api.PromoteRedirects(ctx, diff, storex.PromoteConflictSkip, true)
+	require.NoError(t, err)
+	assert.Len(t, plan.Created, 2)
+	assert.Len(t, plan.Updated, 1)
+	assert.Len(t, plan.Deleted, 1)
+	assert.Equal(t, storex.RedirectTarget("/old"), live.BySource("de", "/changed").Target, "dry run")
+
+	result, err := api.PromoteRedirects(ctx, diff, storex.PromoteConflictSkip, false)
+	require.NoError(t, err)
+	assert.Len(t, result.Created, 1)
+	assert.Len(t, result.Updated, 1)
+	assert.Len(t, result.Deleted, 1)
+	require.Len(t, result.Conflicts, 1)
+	assert.Equal(t, storex.PromotionConflictModified, result.Conflicts[0].Reason)
+	require.Len(t, result.Failed, 1)
+	assert.Equal(t, storex.RedirectSource("/evil"), result.Failed[0].Definition.Source)
+
+	created := live.BySource("de", "/new")
+	require.NotNil(t, created)
+	assert.NotEqual(t, storex.EntityID("s1"), created.ID)
+	assert.Equal(t, storex.RedirectTarget("/b"), created.Target)
+	assert.Empty(t, created.OriginalTarget)
+
+	assert.Equal(t, storex.RedirectTarget("/new-target"), live.BySource("de", "/changed").Target)
+	assert.Equal(t, storex.RedirectTarget("/edited-live"), live.BySource("de", "/edited").Target)
+	assert.Nil(t, live.BySource("de", "/removed"))
+	assert.Nil(t, live.BySource("de", "/evil"))
+}
@@ -7,4 +7,7 @@ import (
 type Queries struct {
 	GetRedirects queryx.GetRedirectsHandlerFn
 	Search       queryx.SearchHandlerFn
+	GetBatches   queryx.GetBatchesHandlerFn
+	GetSnapshots queryx.GetSnapshotsHandlerFn
+	GetSnapshot  queryx.GetSnapshotHandlerFn
 }
@@ -0,0 +1,53 @@
+package redirectquery
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// GetBatches query, returns the latest batches of the automatic runs first
+	GetBatches struct {
+		Limit int `json:"limit"`
+	}
+	// GetBatchesHandlerFn handler
+	GetBatchesHandlerFn func(ctx context.Context, l *zap.Logger, qry GetBatches) ([]*storex.Batch, error)
+	// GetBatchesMiddlewareFn middleware
+	GetBatchesMiddlewareFn func(next GetBatchesHandlerFn) GetBatchesHandlerFn
+)
+
+// GetBatchesHandler ...
+func GetBatchesHandler(repo repositoryx.RedirectsDefinitionRepository) GetBatchesHandlerFn {
+	return func(ctx context.Context, _ *zap.Logger, qry GetBatches) ([]*storex.Batch, error) {
+		return repo.FindBatches(ctx, qry.Limit)
+	}
+}
+
+// GetBatchesHandlerComposed returns the handler with middleware applied to it
+func GetBatchesHandlerComposed(handler GetBatchesHandlerFn, middlewares ...GetBatchesMiddlewareFn) GetBatchesHandlerFn {
+	composed := func(next GetBatchesHandlerFn) GetBatchesHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, qry GetBatches) ([]*storex.Batch, error) {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, qry)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, qry GetBatches) ([]*storex.Batch, error) {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, qry)
+	})
+}
@@ -15,17 +15,18 @@ import (
 type (
 	// GetRedirects query
 	GetRedirects struct {
+		Filter storex.RedirectsFilter `json:"filter"`
 	}
 	// GetRedirectsHandlerFn handler
-	GetRedirectsHandlerFn func(ctx context.Context, l *zap.Logger) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error)
+	GetRedirectsHandlerFn func(ctx context.Context, l *zap.Logger, qry GetRedirects) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error)
 	// GetRedirectsMiddlewareFn middleware
 	GetRedirectsMiddlewareFn func(next GetRedirectsHandlerFn) GetRedirectsHandlerFn
 )
 
 // GetRedirectsHandler ...
 func GetRedirectsHandler(repo repositoryx.RedirectsDefinitionRepository) GetRedirectsHandlerFn {
-	return func(ctx context.Context, _ *zap.Logger) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
-		return repo.FindAll(ctx, true)
+	return func(ctx context.Context, _ *zap.Logger, qry GetRedirects) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+		return repo.FindAllByFilter(ctx, qry.Filter)
 	}
 }
 
@@ -35,9 +36,9 @@ func GetRedirectsHandlerComposed(handler GetRedirectsHandlerFn, middlewares ...G
 		for _, middleware := range middlewares {
 			localNext := next
 			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
-			next = middleware(func(ctx context.Context, l *zap.Logger) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+			next = middleware(func(ctx context.Context, l *zap.Logger, qry GetRedirects) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
 				trace.SpanFromContext(ctx).AddEvent(middlewareName)
-				return localNext(ctx, l)
+				return localNext(ctx, l, qry)
 			})
 		}
 
@@ -45,8 +46,8 @@ func GetRedirectsHandlerComposed(handler GetRedirectsHandlerFn, middlewares ...G
 	}
 	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
 
-	return composed(func(ctx context.Context, l *zap.Logger) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	return composed(func(ctx context.Context, l *zap.Logger, qry GetRedirects) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
 		trace.SpanFromContext(ctx).AddEvent(handlerName)
-		return handler(ctx, l)
+		return handler(ctx, l, qry)
 	})
 }
@@ -0,0 +1,53 @@
+package redirectquery
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// GetSnapshot query, returns the stored snapshot with its definitions
+	GetSnapshot struct {
+		Name string `json:"name"`
+	}
+	// GetSnapshotHandlerFn handler
+	GetSnapshotHandlerFn func(ctx context.Context, l *zap.Logger, qry GetSnapshot) (*storex.Snapshot, error)
+	// GetSnapshotMiddlewareFn middleware
+	GetSnapshotMiddlewareFn func(next GetSnapshotHandlerFn) GetSnapshotHandlerFn
+)
+
+// GetSnapshotHandler ...
+func GetSnapshotHandler(repo repositoryx.RedirectsDefinitionRepository) GetSnapshotHandlerFn {
+	return func(ctx context.Context, _ *zap.Logger, qry GetSnapshot) (*storex.Snapshot, error) {
+		return repo.FindSnapshot(ctx, qry.Name)
+	}
+}
+
+// GetSnapshotHandlerComposed returns the handler with middleware applied to it
+func GetSnapshotHandlerComposed(handler GetSnapshotHandlerFn, middlewares ...GetSnapshotMiddlewareFn) GetSnapshotHandlerFn {
+	composed := func(next GetSnapshotHandlerFn) GetSnapshotHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, qry GetSnapshot) (*storex.Snapshot, error) {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, qry)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, qry GetSnapshot) (*storex.Snapshot, error) {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, qry)
+	})
+}
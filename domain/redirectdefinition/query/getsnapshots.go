@@ -0,0 +1,51 @@
+package redirectquery
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type (
+	// GetSnapshots query, returns the stored snapshots without their definitions
+	GetSnapshots struct{}
+	// GetSnapshotsHandlerFn handler
+	GetSnapshotsHandlerFn func(ctx context.Context, l *zap.Logger, qry GetSnapshots) ([]*storex.Snapshot, error)
+	// GetSnapshotsMiddlewareFn middleware
+	GetSnapshotsMiddlewareFn func(next GetSnapshotsHandlerFn) GetSnapshotsHandlerFn
+)
+
+// GetSnapshotsHandler ...
+func GetSnapshotsHandler(repo repositoryx.RedirectsDefinitionRepository) GetSnapshotsHandlerFn {
+	return func(ctx context.Context, _ *zap.Logger, _ GetSnapshots) ([]*storex.Snapshot, error) {
+		return repo.FindSnapshots(ctx)
+	}
+}
+
+// GetSnapshotsHandlerComposed returns the handler with middleware applied to it
+func GetSnapshotsHandlerComposed(handler GetSnapshotsHandlerFn, middlewares ...GetSnapshotsMiddlewareFn) GetSnapshotsHandlerFn {
+	composed := func(next GetSnapshotsHandlerFn) GetSnapshotsHandlerFn {
+		for _, middleware := range middlewares {
+			localNext := next
+			middlewareName := strings.Split(runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name(), ".")[2]
+			next = middleware(func(ctx context.Context, l *zap.Logger, qry GetSnapshots) ([]*storex.Snapshot, error) {
+				trace.SpanFromContext(ctx).AddEvent(middlewareName)
+				return localNext(ctx, l, qry)
+			})
+		}
+
+		return next
+	}
+	handlerName := strings.Split(runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name(), ".")[2]
+
+	return composed(func(ctx context.Context, l *zap.Logger, qry GetSnapshots) ([]*storex.Snapshot, error) {
+		trace.SpanFromContext(ctx).AddEvent(handlerName)
+		return handler(ctx, l, qry)
+	})
+}
@@ -9,6 +9,7 @@ import (
 
 	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
 	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
@@ -16,13 +17,23 @@ import (
 type (
 	// Search query
 	Search struct {
-		Source       storex.RedirectSource  `json:"source"`
-		Dimension    storex.Dimension       `json:"dimension"`
-		ActiveState  storex.ActiveStateType `json:"activeState"`
-		Page         int                    `json:"page"`
-		PageSize     int                    `json:"pageSize"`
-		RedirectType storex.RedirectionType `json:"type,omitempty"`
-		Sort         storex.Sort            `json:"sort"`
+		Source        storex.RedirectSource  `json:"source"`
+		Target        storex.RedirectTarget  `json:"target,omitempty"`
+		SearchMode    storex.SearchMode      `json:"searchMode,omitempty"`
+		Dimension     storex.Dimension       `json:"dimension"`
+		ActiveState   storex.ActiveStateType `json:"activeState"`
+		ContentID     string                 `json:"contentId,omitempty"`
+		LastUpdatedBy string                 `json:"lastUpdatedBy,omitempty"`
+		Code          storex.RedirectCode    `json:"code,omitempty"`
+		UpdatedFrom   storex.DateTime        `json:"updatedFrom,omitempty"`
+		UpdatedTo     storex.DateTime        `json:"updatedTo,omitempty"`
+		NeedsReview   bool                   `json:"needsReview,omitempty"`
+		Page          int                    `json:"page"`
+		PageSize      int                    `json:"pageSize"`
+		Cursor        string                 `json:"cursor,omitempty"`
+		RedirectType  storex.RedirectionType `json:"type,omitempty"`
+		Sort          storex.Sort            `json:"sort"`
+		Facets        bool                   `json:"facets,omitempty"` // Adds facet counts of the whole search to the result
 	}
 	// SearchHandlerFn handler
 	SearchHandlerFn func(ctx context.Context, l *zap.Logger, qry Search) (*storex.PaginatedResult, error)
@@ -51,10 +62,70 @@ func SearchHandler(repo repositoryx.RedirectsDefinitionRepository) SearchHandler
 			return nil, fmt.Errorf("invalid active state: '%s'; should be empty, 'enabled' or 'disabled'", qry.RedirectType)
 		}
 
+		// Validate SearchMode
+		if !qry.SearchMode.IsValid() {
+			return nil, fmt.Errorf("invalid search mode: '%s'; should be empty, 'contains', 'prefix', 'exact' or 'regex'", qry.SearchMode)
+		}
+
+		if qry.SearchMode == storex.SearchModeRegex {
+			for _, term := range []string{string(qry.Source), string(qry.Target)} {
+				if err := storex.ValidateSearchRegex(term); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// Validate Code
+		if qry.Code != 0 && !qry.Code.Valid() {
+			return nil, fmt.Errorf("invalid code: '%d'", qry.Code)
+		}
+
+		filter := storex.SearchFilter{
+			Source:        qry.Source,
+			Target:        qry.Target,
+			SearchMode:    qry.SearchMode,
+			Dimension:     qry.Dimension,
+			RedirectType:  qry.RedirectType,
+			ActiveState:   qry.ActiveState,
+			ContentID:     qry.ContentID,
+			LastUpdatedBy: qry.LastUpdatedBy,
+			Code:          qry.Code,
+			UpdatedFrom:   qry.UpdatedFrom,
+			UpdatedTo:     qry.UpdatedTo,
+			NeedsReview:   qry.NeedsReview,
+		}
+
+		// Validate updated range
+		if _, _, err := filter.UpdatedRange(); err != nil {
+			return nil, err
+		}
+
 		// Create pagination struct
-		pagination := storex.Pagination{Page: page, PageSize: pageSize}
+		pagination := storex.Pagination{Page: page, PageSize: pageSize, Cursor: qry.Cursor}
 
-		return repo.FindMany(ctx, string(qry.Source), string(qry.Dimension), qry.RedirectType, qry.ActiveState, pagination, qry.Sort)
+		result, err := repo.FindMany(ctx, filter, pagination, qry.Sort)
+		if err != nil || !qry.Facets {
+			return result, err
+		}
+
+		if result.Facets, err = repo.FindFacets(ctx, filter); err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+}
+
+// SearchPrivilegedModeMiddleware rejects privileged search modes like regex for unprivileged users
+func SearchPrivilegedModeMiddleware(isPrivileged providerx.PrivilegedUserProviderFunc) SearchMiddlewareFn {
+	return func(next SearchHandlerFn) SearchHandlerFn {
+		return func(ctx context.Context, l *zap.Logger, qry Search) (*storex.PaginatedResult, error) {
+			if qry.SearchMode.Privileged() && !isPrivileged(ctx) {
+				return nil, fmt.Errorf("search mode '%s' is not allowed", qry.SearchMode)
+			}
+
+			return next(ctx, l, qry)
+		}
 	}
 }
 
@@ -0,0 +1,103 @@
+package redirectrepository
+
+import (
+	"context"
+	"errors"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ErrBatchesDisabled is returned without a batches collection
+var ErrBatchesDisabled = errors.New("batches are not stored")
+
+// InsertBatch stores the batch and the before-images of its redirects,
+// without a batches collection the batch is only logged
+func (rs *BaseRedirectsDefinitionRepository) InsertBatch(ctx context.Context, batch *storex.Batch, entries []*storex.BatchEntry) error {
+	rs.l.Info("stored batch",
+		zap.String("id", string(batch.ID)),
+		zap.Int("created", batch.Created),
+		zap.Int("changed", batch.Changed),
+		zap.Int("deleted", batch.Deleted),
+	)
+
+	if rs.batches == nil || rs.batchEntries == nil {
+		return nil
+	}
+
+	if len(entries) > 0 {
+		if _, err := rs.batchEntries.Col().InsertMany(ctx, entries); err != nil {
+			rs.l.Error("Failed to insert batch entries", zap.String("batch", string(batch.ID)), zap.Error(err))
+			return err
+		}
+	}
+
+	_, err := rs.batches.Col().InsertOne(ctx, batch)
+
+	return err
+}
+
+func (rs *BaseRedirectsDefinitionRepository) UpdateBatch(ctx context.Context, batch *storex.Batch) error {
+	if rs.batches == nil {
+		return ErrBatchesDisabled
+	}
+
+	_, err := rs.batches.Col().ReplaceOne(ctx, bson.M{"id": batch.ID}, batch)
+
+	return err
+}
+
+// FindBatches returns the latest batches first
+func (rs *BaseRedirectsDefinitionRepository) FindBatches(ctx context.Context, limit int) ([]*storex.Batch, error) {
+	if rs.batches == nil {
+		return nil, ErrBatchesDisabled
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "time", Value: -1}})
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+
+	var results []*storex.Batch
+
+	err := rs.batches.Find(ctx, bson.M{}, &results, opts)
+	if err != nil {
+		rs.l.Error("Failed to fetch batches", zap.Error(err))
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (rs *BaseRedirectsDefinitionRepository) FindBatch(ctx context.Context, id storex.EntityID) (*storex.Batch, error) {
+	if rs.batches == nil {
+		return nil, ErrBatchesDisabled
+	}
+
+	var result storex.Batch
+
+	err := rs.batches.FindOne(ctx, bson.M{"id": id}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+func (rs *BaseRedirectsDefinitionRepository) FindBatchEntries(ctx context.Context, id storex.EntityID) ([]*storex.BatchEntry, error) {
+	if rs.batchEntries == nil {
+		return nil, ErrBatchesDisabled
+	}
+
+	var results []*storex.BatchEntry
+
+	err := rs.batchEntries.Find(ctx, bson.M{"batch": id}, &results)
+	if err != nil {
+		rs.l.Error("Failed to fetch batch entries", zap.String("batch", string(id)), zap.Error(err))
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package redirectrepository
+
+import (
+	"context"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type (
+	facetCount[T any] struct {
+		Value T   `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	facetsResult struct {
+		RedirectType []facetCount[storex.RedirectionType] `bson:"redirectType"`
+		Stale        []facetCount[bool]                   `bson:"stale"`
+		Code         []facetCount[storex.RedirectCode]    `bson:"code"`
+		Dimension    []facetCount[storex.Dimension]       `bson:"dimension"`
+	}
+)
+
+// FacetsPipeline returns the aggregation pipeline counting the matches of the filter by field
+func FacetsPipeline(filter bson.M) mongo.Pipeline {
+	group := func(field string) bson.A {
+		return bson.A{
+			bson.D{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$" + field},
+				{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			}}},
+		}
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: bson.D{
+			{Key: "redirectType", Value: group("redirectType")},
+			{Key: "stale", Value: group("stale")},
+			{Key: "code", Value: group("code")},
+			{Key: "dimension", Value: group("dimension")},
+		}}},
+	}
+}
+
+func (rs *BaseRedirectsDefinitionRepository) FindFacets(ctx context.Context, searchFilter storex.SearchFilter) (*storex.SearchFacets, error) {
+	filter, err := SearchFilterToBSON(searchFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := rs.collection.Col().Aggregate(ctx, FacetsPipeline(filter))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []facetsResult
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	facets := &storex.SearchFacets{
+		RedirectType: map[storex.RedirectionType]int{},
+		ActiveState:  map[storex.ActiveStateType]int{},
+		Code:         map[storex.RedirectCode]int{},
+		Dimension:    map[storex.Dimension]int{},
+	}
+
+	// $facet always returns exactly one document
+	if len(results) == 0 {
+		return facets, nil
+	}
+
+	for _, c := range results[0].RedirectType {
+		facets.RedirectType[c.Value] += c.Count
+	}
+
+	for _, c := range results[0].Stale {
+		if c.Value {
+			facets.ActiveState[storex.ActiveStateTypeDisabled] += c.Count
+		} else {
+			facets.ActiveState[storex.ActiveStateTypeEnabled] += c.Count
+		}
+	}
+
+	for _, c := range results[0].Code {
+		facets.Code[c.Value] += c.Count
+	}
+
+	for _, c := range results[0].Dimension {
+		facets.Dimension[c.Value] += c.Count
+	}
+
+	return facets, nil
+}
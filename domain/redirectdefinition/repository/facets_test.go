@@ -0,0 +1,30 @@
+package redirectrepository_test
+
+import (
+	"testing"
+
+	redirectrepository "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestFacetsPipeline(t *testing.T) {
+	t.Parallel()
+
+	filter := bson.M{"dimension": "shop-de"}
+	pipeline := redirectrepository.FacetsPipeline(filter)
+
+	require.Len(t, pipeline, 2)
+	assert.Equal(t, bson.D{{Key: "$match", Value: filter}}, pipeline[0])
+
+	facets, ok := pipeline[1][0].Value.(bson.D)
+	require.True(t, ok)
+
+	keys := make([]string, 0, len(facets))
+	for _, facet := range facets {
+		keys = append(keys, facet.Key)
+	}
+
+	assert.Equal(t, []string{"redirectType", "stale", "code", "dimension"}, keys)
+}
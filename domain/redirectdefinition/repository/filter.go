@@ -0,0 +1,193 @@
+package redirectrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+)
+
+// SearchFilterToBSON converts the search filter into a mongo filter
+func SearchFilterToBSON(searchFilter storex.SearchFilter) (bson.M, error) {
+	filter := bson.M{}
+
+	if !searchFilter.SearchMode.IsValid() {
+		return nil, fmt.Errorf("invalid search mode: '%s'", searchFilter.SearchMode)
+	}
+
+	if searchFilter.Source != "" {
+		filter["source"] = searchTermFilter(searchFilter.SearchMode, string(searchFilter.Source))
+	}
+
+	if searchFilter.Target != "" {
+		filter["target"] = searchTermFilter(searchFilter.SearchMode, string(searchFilter.Target))
+	}
+
+	if searchFilter.Dimension != "" {
+		filter["dimension"] = searchFilter.Dimension
+	}
+
+	// Apply redirect type filter
+	if redirectValue, apply := searchFilter.RedirectType.ToFilter(); apply {
+		filter["redirectType"] = redirectValue
+	}
+
+	// Apply active state filter
+	if stateValue, apply := searchFilter.ActiveState.ToFilter(); apply {
+		filter["stale"] = stateValue
+	}
+
+	if searchFilter.ContentID != "" {
+		filter["contentId"] = searchFilter.ContentID
+	}
+
+	if searchFilter.LastUpdatedBy != "" {
+		filter["lastUpdatedBy"] = searchFilter.LastUpdatedBy
+	}
+
+	if searchFilter.NeedsReview {
+		filter["needsReview"] = true
+	}
+
+	if searchFilter.Code != 0 {
+		filter["code"] = searchFilter.Code
+	}
+
+	from, to, err := searchFilter.UpdatedRange()
+	if err != nil {
+		return nil, err
+	}
+
+	if !from.IsZero() || !to.IsZero() {
+		updated := bson.M{}
+		if !from.IsZero() {
+			updated["$gte"] = bson.NewDateTimeFromTime(from)
+		}
+
+		if !to.IsZero() {
+			updated["$lte"] = bson.NewDateTimeFromTime(to)
+		}
+
+		filter[string(storex.SortFieldUpdated)] = updated
+	}
+
+	return filter, nil
+}
+
+// RedirectsFilterToBSON converts the redirects filter into a mongo filter
+func RedirectsFilterToBSON(redirectsFilter storex.RedirectsFilter) bson.M {
+	filter := bson.M{}
+
+	if !redirectsFilter.IncludeStale {
+		filter["stale"] = false
+	}
+
+	inDimensions := bson.M{"$in": redirectsFilter.Dimensions}
+	ofSite := bson.Regex{Pattern: "^" + regexp.QuoteMeta(string(redirectsFilter.Site)) + "-"}
+
+	switch {
+	case len(redirectsFilter.Dimensions) > 0 && redirectsFilter.Site != "":
+		filter["$or"] = bson.A{bson.M{"dimension": inDimensions}, bson.M{"dimension": ofSite}}
+	case len(redirectsFilter.Dimensions) > 0:
+		filter["dimension"] = inDimensions
+	case redirectsFilter.Site != "":
+		filter["dimension"] = ofSite
+	}
+
+	return filter
+}
+
+// searchTermFilter returns the value to filter a field by the given term
+func searchTermFilter(mode storex.SearchMode, term string) any {
+	switch mode {
+	case storex.SearchModeExact:
+		return term
+	case storex.SearchModePrefix:
+		// case-sensitive anchored prefix so that the index is used
+		return bson.Regex{Pattern: "^" + regexp.QuoteMeta(term)}
+	case storex.SearchModeRegex:
+		return bson.Regex{Pattern: term, Options: "i"}
+	default: // SearchModeContains
+		return bson.Regex{Pattern: regexp.QuoteMeta(term), Options: "i"}
+	}
+}
+
+// MigrateUpdatedToDate converts updated timestamps stored as strings by previous versions into BSON dates.
+// Documents with unparsable values are left untouched.
+func (rs *BaseRedirectsDefinitionRepository) MigrateUpdatedToDate(ctx context.Context) (int64, error) {
+	result, err := rs.collection.Col().UpdateMany(ctx,
+		bson.M{string(storex.SortFieldUpdated): bson.M{"$type": "string"}},
+		bson.A{
+			bson.M{"$set": bson.M{
+				string(storex.SortFieldUpdated): bson.M{"$dateFromString": bson.M{
+					"dateString": "$updated",
+					"onError":    "$updated",
+					"onNull":     nil,
+				}},
+			}},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rs.l.Info("migrated updated timestamps to dates", zap.Int64("modified", result.ModifiedCount))
+
+	return result.ModifiedCount, nil
+}
+
+// KeysetFilter returns the filter for all results sorted behind the cursor.
+// Missing values sort first in ascending and last in descending order.
+func KeysetFilter(c storex.PageCursor) (bson.M, error) {
+	field := string(c.Field)
+	afterID := bson.M{field: nil, "id": bson.M{"$gt": c.ID}}
+
+	if c.Value == nil {
+		if c.Direction.GetSortValue() < 0 {
+			return afterID, nil
+		}
+
+		return bson.M{"$or": bson.A{afterID, bson.M{field: bson.M{"$ne": nil}}}}, nil
+	}
+
+	var value any = *c.Value
+	if c.Field == storex.SortFieldUpdated {
+		t, err := storex.DateTime(*c.Value).Time()
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+
+		value = bson.NewDateTimeFromTime(t)
+	}
+
+	operator := "$gt"
+	or := bson.A{}
+
+	if c.Direction.GetSortValue() < 0 {
+		operator = "$lt"
+		or = append(or, bson.M{field: nil})
+	}
+
+	or = append(or,
+		bson.M{field: bson.M{operator: value}},
+		bson.M{field: value, "id": bson.M{"$gt": c.ID}},
+	)
+
+	return bson.M{"$or": or}, nil
+}
+
+// ObsoleteFilter returns the filter for stale automatic redirects soft deleted by the consolidation before the cutoff,
+// locked redirects are never purged
+func ObsoleteFilter(cutoff time.Time) bson.M {
+	return bson.M{
+		"redirectType": storex.RedirectionTypeAutomatic,
+		"stale":        true,
+		"locked":       bson.M{"$ne": true},
+		"obsolete":     bson.M{"$lt": bson.NewDateTimeFromTime(cutoff)},
+	}
+}
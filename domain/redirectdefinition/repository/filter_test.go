@@ -0,0 +1,172 @@
+package redirectrepository_test
+
+import (
+	"testing"
+	"time"
+
+	redirectrepository "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestSearchFilterToBSON(t *testing.T) {
+	t.Parallel()
+
+	filter, err := redirectrepository.SearchFilterToBSON(storex.SearchFilter{
+		Target:        "/sale",
+		Dimension:     "shop-de",
+		ContentID:     "content-1",
+		LastUpdatedBy: "editor",
+		Code:          storex.RedirectCodeGone,
+		UpdatedFrom:   "2024-05-01",
+		RedirectType:  storex.RedirectionTypeAll,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.M{
+		"target":        bson.Regex{Pattern: "/sale", Options: "i"},
+		"dimension":     storex.Dimension("shop-de"),
+		"contentId":     "content-1",
+		"lastUpdatedBy": "editor",
+		"code":          storex.RedirectCodeGone,
+		"updated":       bson.M{"$gte": bson.NewDateTimeFromTime(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))},
+	}, filter)
+
+	_, err = redirectrepository.SearchFilterToBSON(storex.SearchFilter{UpdatedTo: "tomorrow"})
+	require.Error(t, err)
+}
+
+func TestSearchFilterToBSON_SearchMode(t *testing.T) {
+	t.Parallel()
+
+	for mode, expected := range map[storex.SearchMode]any{
+		"":                        bson.Regex{Pattern: `/sale\?q=\(a\+b\)`, Options: "i"},
+		storex.SearchModeContains: bson.Regex{Pattern: `/sale\?q=\(a\+b\)`, Options: "i"},
+		storex.SearchModePrefix:   bson.Regex{Pattern: `^/sale\?q=\(a\+b\)`},
+		storex.SearchModeExact:    "/sale?q=(a+b)",
+		storex.SearchModeRegex:    bson.Regex{Pattern: "/sale?q=(a+b)", Options: "i"},
+	} {
+		filter, err := redirectrepository.SearchFilterToBSON(storex.SearchFilter{Source: "/sale?q=(a+b)", SearchMode: mode})
+		require.NoError(t, err)
+		assert.Equal(t, expected, filter["source"], mode)
+	}
+
+	_, err := redirectrepository.SearchFilterToBSON(storex.SearchFilter{Source: "/sale", SearchMode: "fuzzy"})
+	require.Error(t, err)
+}
+
+func TestKeysetFilter(t *testing.T) {
+	t.Parallel()
+
+	filter, err := redirectrepository.KeysetFilter(storex.PageCursor{Field: storex.SortFieldSource, Value: ptr("/a"), ID: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"source": bson.M{"$gt": "/a"}},
+		bson.M{"source": "/a", "id": bson.M{"$gt": storex.EntityID("1")}},
+	}}, filter)
+
+	// missing values sort last in descending order
+	updated := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	filter, err = redirectrepository.KeysetFilter(storex.PageCursor{
+		Field:     storex.SortFieldUpdated,
+		Direction: storex.DirectionDescending,
+		Value:     ptr(string(storex.NewDateTime(updated))),
+		ID:        "1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"updated": nil},
+		bson.M{"updated": bson.M{"$lt": bson.NewDateTimeFromTime(updated)}},
+		bson.M{"updated": bson.NewDateTimeFromTime(updated), "id": bson.M{"$gt": storex.EntityID("1")}},
+	}}, filter)
+
+	// missing values sort first in ascending order
+	filter, err = redirectrepository.KeysetFilter(storex.PageCursor{Field: storex.SortFieldLastUpdatedBy, ID: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"lastUpdatedBy": nil, "id": bson.M{"$gt": storex.EntityID("1")}},
+		bson.M{"lastUpdatedBy": bson.M{"$ne": nil}},
+	}}, filter)
+
+	// empty values sort behind missing ones
+	filter, err = redirectrepository.KeysetFilter(storex.PageCursor{Field: storex.SortFieldLastUpdatedBy, Value: ptr(""), ID: "1"})
+	require.NoError(t, err)
+	assert.Equal(t, bson.M{"$or": bson.A{
+		bson.M{"lastUpdatedBy": bson.M{"$gt": ""}},
+		bson.M{"lastUpdatedBy": "", "id": bson.M{"$gt": storex.EntityID("1")}},
+	}}, filter)
+}
+
+func ptr(s string) *string {
+	return &s
+}
+
+func TestRedirectsFilterToBSON(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, bson.M{"stale": false}, redirectrepository.RedirectsFilterToBSON(storex.RedirectsFilter{}))
+	assert.Equal(t, bson.M{
+		"dimension": bson.M{"$in": []storex.Dimension{"shop-de"}},
+	}, redirectrepository.RedirectsFilterToBSON(storex.RedirectsFilter{Dimensions: []storex.Dimension{"shop-de"}, IncludeStale: true}))
+	assert.Equal(t, bson.M{
+		"stale": false,
+		"$or": bson.A{
+			bson.M{"dimension": bson.M{"$in": []storex.Dimension{"outlet-de"}}},
+			bson.M{"dimension": bson.Regex{Pattern: `^shop\.ch-`}},
+		},
+	}, redirectrepository.RedirectsFilterToBSON(storex.RedirectsFilter{Dimensions: []storex.Dimension{"outlet-de"}, Site: "shop.ch"}))
+}
+
+func TestObsoleteFilter(t *testing.T) {
+	t.Parallel()
+
+	cutoff := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, bson.M{
+		"redirectType": storex.RedirectionTypeAutomatic,
+		"stale":        true,
+		"locked":       bson.M{"$ne": true},
+		"obsolete":     bson.M{"$lt": bson.NewDateTimeFromTime(cutoff)},
+	}, redirectrepository.ObsoleteFilter(cutoff))
+}
+
+func TestLeaseFilters(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, bson.M{
+		"name":    "redirects.pipeline",
+		"expires": bson.M{"$lt": bson.NewDateTimeFromTime(now)},
+	}, redirectrepository.ExpiredLeaseFilter("redirects.pipeline", now))
+	assert.Equal(t, bson.M{
+		"name":    "redirects.pipeline",
+		"token":   storex.EntityID("token"),
+		"expires": bson.M{"$gte": bson.NewDateTimeFromTime(now)},
+	}, redirectrepository.HeldLeaseFilter(&storex.Lease{Name: "redirects.pipeline", Token: "token"}, now))
+}
+
+func TestClaimableJobFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	date := bson.NewDateTimeFromTime(now)
+
+	assert.Equal(t, bson.M{
+		"status": bson.M{"$in": bson.A{storex.JobStatusQueued, storex.JobStatusRunning}},
+	}, redirectrepository.UnfinishedJobFilter())
+	assert.Equal(t, bson.M{
+		"id": storex.EntityID("job"),
+		"$or": bson.A{
+			bson.M{"status": storex.JobStatusQueued, "nextAttempt": bson.M{"$lte": date}},
+			bson.M{"status": storex.JobStatusRunning, "lockedUntil": bson.M{"$lt": date}},
+		},
+	}, redirectrepository.ClaimableJobFilter("job", now))
+	assert.Equal(t, bson.M{
+		"id":     storex.EntityID("job"),
+		"status": storex.JobStatusRunning,
+		"claim":  storex.EntityID("claim"),
+	}, redirectrepository.ClaimedJobFilter(&storex.Job{ID: "job", Claim: "claim"}))
+}
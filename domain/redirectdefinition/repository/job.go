@@ -0,0 +1,199 @@
+package redirectrepository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	keelmongo "github.com/foomo/keel/persistence/mongo"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type (
+	JobRepository interface {
+		Enqueue(ctx context.Context, job *storex.Job) error
+		// Claim returns the oldest unfinished job for the worker if it is due or nil otherwise,
+		// later jobs wait until it succeeded or failed so that the exports are applied in order
+		Claim(ctx context.Context, worker string, ttl time.Duration) (*storex.Job, error)
+		// Renew extends the claim of the worker, storex.ErrLeaseLost is returned if the job was claimed again
+		Renew(ctx context.Context, job *storex.Job, ttl time.Duration) error
+		// UpdateStatus persists the status, progress and results of the job,
+		// storex.ErrLeaseLost is returned if the job was claimed again
+		UpdateStatus(ctx context.Context, job *storex.Job) error
+		// FindJob returns the job without its payload
+		FindJob(ctx context.Context, id storex.EntityID) (*storex.Job, error)
+	}
+	BaseJobRepository struct {
+		l          *zap.Logger
+		collection *keelmongo.Collection
+	}
+)
+
+func NewJobRepository(l *zap.Logger, collection *keelmongo.Collection) *BaseJobRepository {
+	return &BaseJobRepository{
+		l:          l,
+		collection: collection,
+	}
+}
+
+func NewBaseJobRepository(l *zap.Logger, persistor *keelmongo.Persistor) (*BaseJobRepository, error) {
+	collection, cErr := persistor.Collection(
+		"redirects_jobs",
+		keelmongo.CollectionWithIndexes(
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "id", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "status", Value: 1},
+					{Key: "created", Value: 1},
+				},
+			},
+		),
+	)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	return NewJobRepository(l, collection), nil
+}
+
+func (rs *BaseJobRepository) Enqueue(ctx context.Context, job *storex.Job) error {
+	if job.ID == "" {
+		job.ID = storex.NewEntityID()
+	}
+
+	_, err := rs.collection.Col().InsertOne(ctx, job)
+
+	return err
+}
+
+func (rs *BaseJobRepository) Claim(ctx context.Context, worker string, ttl time.Duration) (*storex.Job, error) {
+	now := time.Now()
+
+	var head storex.Job
+
+	err := rs.collection.Col().FindOne(ctx,
+		UnfinishedJobFilter(),
+		options.FindOne().
+			SetSort(bson.D{{Key: "created", Value: 1}, {Key: "_id", Value: 1}}).
+			SetProjection(bson.M{"id": 1}),
+	).Decode(&head)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil //nolint:nilnil
+	} else if err != nil {
+		rs.l.Error("Failed to find next job", zap.Error(err))
+		return nil, err
+	}
+
+	var job storex.Job
+
+	err = rs.collection.Col().FindOneAndUpdate(ctx,
+		ClaimableJobFilter(head.ID, now),
+		bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "status", Value: storex.JobStatusRunning},
+				{Key: "worker", Value: worker},
+				{Key: "claim", Value: storex.NewEntityID()},
+				{Key: "started", Value: storex.NewDateTime(now)},
+				{Key: "lockedUntil", Value: storex.NewDateTime(now.Add(ttl))},
+			}},
+			{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil //nolint:nilnil
+	} else if err != nil {
+		rs.l.Error("Failed to claim job", zap.Error(err))
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (rs *BaseJobRepository) Renew(ctx context.Context, job *storex.Job, ttl time.Duration) error {
+	result, err := rs.collection.Col().UpdateOne(ctx,
+		ClaimedJobFilter(job),
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "lockedUntil", Value: storex.NewDateTime(time.Now().Add(ttl))},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return storex.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (rs *BaseJobRepository) UpdateStatus(ctx context.Context, job *storex.Job) error {
+	result, err := rs.collection.Col().UpdateOne(ctx,
+		ClaimedJobFilter(job),
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "status", Value: job.Status},
+			{Key: "finished", Value: job.Finished},
+			{Key: "nextAttempt", Value: job.NextAttempt},
+			{Key: "progress", Value: job.Progress},
+			{Key: "dimensions", Value: job.Dimensions},
+			{Key: "error", Value: job.Error},
+		}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return storex.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (rs *BaseJobRepository) FindJob(ctx context.Context, id storex.EntityID) (*storex.Job, error) {
+	var job storex.Job
+
+	err := rs.collection.Col().FindOne(ctx,
+		bson.M{"id": id},
+		options.FindOne().SetProjection(bson.M{"payload": 0}),
+	).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// UnfinishedJobFilter returns the filter for queued and running jobs
+func UnfinishedJobFilter() bson.M {
+	return bson.M{"status": bson.M{"$in": bson.A{storex.JobStatusQueued, storex.JobStatusRunning}}}
+}
+
+// ClaimableJobFilter returns the filter for the job if it is queued and due at now
+// or running and the claim of its worker expired
+func ClaimableJobFilter(id storex.EntityID, now time.Time) bson.M {
+	date := bson.NewDateTimeFromTime(now)
+
+	return bson.M{
+		"id": id,
+		"$or": bson.A{
+			bson.M{"status": storex.JobStatusQueued, "nextAttempt": bson.M{"$lte": date}},
+			bson.M{"status": storex.JobStatusRunning, "lockedUntil": bson.M{"$lt": date}},
+		},
+	}
+}
+
+// ClaimedJobFilter returns the filter for the running job as long as it was not claimed again
+func ClaimedJobFilter(job *storex.Job) bson.M {
+	return bson.M{"id": job.ID, "status": storex.JobStatusRunning, "claim": job.Claim}
+}
@@ -0,0 +1,131 @@
+package redirectrepository
+
+import (
+	"context"
+	"time"
+
+	keelmongo "github.com/foomo/keel/persistence/mongo"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+type (
+	LeaseRepository interface {
+		// Acquire takes the lease if it is free or expired, otherwise it returns storex.ErrLeaseHeld
+		Acquire(ctx context.Context, name, owner string, ttl time.Duration) (*storex.Lease, error)
+		// Renew extends the lease, it returns storex.ErrLeaseLost if the lease expired or was taken over
+		Renew(ctx context.Context, lease *storex.Lease, ttl time.Duration) error
+		// Release frees the lease, it returns storex.ErrLeaseLost if the lease expired or was taken over
+		Release(ctx context.Context, lease *storex.Lease) error
+	}
+	BaseLeaseRepository struct {
+		l          *zap.Logger
+		collection *keelmongo.Collection
+	}
+)
+
+func NewLeaseRepository(l *zap.Logger, collection *keelmongo.Collection) *BaseLeaseRepository {
+	return &BaseLeaseRepository{
+		l:          l,
+		collection: collection,
+	}
+}
+
+func NewBaseLeaseRepository(l *zap.Logger, persistor *keelmongo.Persistor) (*BaseLeaseRepository, error) {
+	collection, cErr := persistor.Collection(
+		"redirects_leases",
+		keelmongo.CollectionWithIndexes(
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "name", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+		),
+	)
+	if cErr != nil {
+		return nil, cErr
+	}
+
+	return NewLeaseRepository(l, collection), nil
+}
+
+func (rs *BaseLeaseRepository) Acquire(ctx context.Context, name, owner string, ttl time.Duration) (*storex.Lease, error) {
+	now := time.Now()
+	lease := &storex.Lease{
+		Name:     name,
+		Owner:    owner,
+		Token:    storex.NewEntityID(),
+		Acquired: storex.NewDateTime(now),
+		Expires:  storex.NewDateTime(now.Add(ttl)),
+	}
+
+	// a held lease does not match the filter, the upsert then violates the unique index
+	_, err := rs.collection.Col().UpdateOne(ctx,
+		ExpiredLeaseFilter(name, now),
+		bson.D{{Key: "$set", Value: lease}},
+		options.UpdateOne().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, storex.ErrLeaseHeld
+	} else if err != nil {
+		rs.l.Error("Failed to acquire lease", zap.String("name", name), zap.Error(err))
+		return nil, err
+	}
+
+	return lease, nil
+}
+
+func (rs *BaseLeaseRepository) Renew(ctx context.Context, lease *storex.Lease, ttl time.Duration) error {
+	now := time.Now()
+	expires := storex.NewDateTime(now.Add(ttl))
+
+	result, err := rs.collection.Col().UpdateOne(ctx,
+		HeldLeaseFilter(lease, now),
+		bson.D{{Key: "$set", Value: bson.D{{Key: "expires", Value: expires}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return storex.ErrLeaseLost
+	}
+
+	lease.Expires = expires
+
+	return nil
+}
+
+func (rs *BaseLeaseRepository) Release(ctx context.Context, lease *storex.Lease) error {
+	result, err := rs.collection.Col().DeleteOne(ctx, HeldLeaseFilter(lease, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return storex.ErrLeaseLost
+	}
+
+	return nil
+}
+
+// ExpiredLeaseFilter returns the filter for the lease if it expired before now
+func ExpiredLeaseFilter(name string, now time.Time) bson.M {
+	return bson.M{
+		"name":    name,
+		"expires": bson.M{"$lt": bson.NewDateTimeFromTime(now)},
+	}
+}
+
+// HeldLeaseFilter returns the filter for the lease if it is still held with its token at now
+func HeldLeaseFilter(lease *storex.Lease, now time.Time) bson.M {
+	return bson.M{
+		"name":    lease.Name,
+		"token":   lease.Token,
+		"expires": bson.M{"$gte": bson.NewDateTimeFromTime(now)},
+	}
+}
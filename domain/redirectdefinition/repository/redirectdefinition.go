@@ -2,6 +2,7 @@ package redirectrepository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -16,8 +17,10 @@ import (
 type (
 	RedirectsDefinitionRepository interface {
 		FindOne(ctx context.Context, id, source string) (*storex.RedirectDefinition, error)
-		FindMany(ctx context.Context, source, dimension string, redirectType storex.RedirectionType, activeState storex.ActiveStateType, pagination storex.Pagination, sort storex.Sort) (*storex.PaginatedResult, error)
+		FindMany(ctx context.Context, filter storex.SearchFilter, pagination storex.Pagination, sort storex.Sort) (*storex.PaginatedResult, error)
+		FindFacets(ctx context.Context, filter storex.SearchFilter) (*storex.SearchFacets, error)
 		FindAll(ctx context.Context, onlyActive bool) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error)
+		FindAllByFilter(ctx context.Context, filter storex.RedirectsFilter) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error)
 		FindAllByDimension(ctx context.Context, dimension storex.Dimension, onlyActive bool) (map[storex.RedirectSource]*storex.RedirectDefinition, error)
 		Insert(ctx context.Context, def *storex.RedirectDefinition) error
 		Update(ctx context.Context, def *storex.RedirectDefinition) error
@@ -25,10 +28,27 @@ type (
 		FindByIDs(ctx context.Context, ids []*storex.EntityID) ([]*storex.RedirectDefinition, error)
 		Delete(ctx context.Context, id storex.EntityID) error
 		DeleteMany(ctx context.Context, ids []storex.EntityID) error
+		FindObsolete(ctx context.Context, cutoff time.Time) ([]*storex.RedirectDefinition, error)
+		InsertPurgeRecord(ctx context.Context, record *storex.PurgeRecord) error
+		InsertBatch(ctx context.Context, batch *storex.Batch, entries []*storex.BatchEntry) error
+		UpdateBatch(ctx context.Context, batch *storex.Batch) error
+		FindBatches(ctx context.Context, limit int) ([]*storex.Batch, error)
+		FindBatch(ctx context.Context, id storex.EntityID) (*storex.Batch, error)
+		FindBatchEntries(ctx context.Context, id storex.EntityID) ([]*storex.BatchEntry, error)
+		InsertSnapshot(ctx context.Context, snapshot *storex.Snapshot) error
+		FindSnapshots(ctx context.Context) ([]*storex.Snapshot, error)
+		FindSnapshot(ctx context.Context, name string) (*storex.Snapshot, error)
+		// MigrateUpdatedToDate converts the updated timestamps stored as strings and returns the number of them
+		MigrateUpdatedToDate(ctx context.Context) (int64, error)
 	}
 	BaseRedirectsDefinitionRepository struct {
-		l          *zap.Logger
-		collection *keelmongo.Collection
+		l            *zap.Logger
+		collection   *keelmongo.Collection
+		purges       *keelmongo.Collection
+		batches      *keelmongo.Collection
+		batchEntries *keelmongo.Collection
+		snapshots    *keelmongo.Collection
+		snapshotDefs *keelmongo.Collection
 	}
 )
 
@@ -66,13 +86,146 @@ func NewBaseRedirectsDefinitionRepository(l *zap.Logger, persistor *keelmongo.Pe
 					{Key: string(storex.SortFieldSource), Value: 1},
 				},
 			},
+			// Indexes for the keyset pagination
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: string(storex.SortFieldSource), Value: 1},
+					{Key: "id", Value: 1},
+				},
+			},
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: string(storex.SortFieldUpdated), Value: 1},
+					{Key: "id", Value: 1},
+				},
+			},
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: string(storex.SortFieldLastUpdatedBy), Value: 1},
+					{Key: "id", Value: 1},
+				},
+			},
+			// Indexes for the structured search filters
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "dimension", Value: 1},
+					{Key: "target", Value: 1},
+				},
+			},
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "contentId", Value: 1},
+				},
+			},
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "dimension", Value: 1},
+					{Key: "code", Value: 1},
+				},
+			},
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "dimension", Value: 1},
+					{Key: string(storex.SortFieldUpdated), Value: -1},
+				},
+			},
+			// Index for the purge of obsolete redirects
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "obsolete", Value: 1},
+				},
+			},
 		),
 	)
 	if cErr != nil {
 		return nil, cErr
 	}
 
-	return NewRedirectsDefinitionRepository(l, collection), nil
+	purges, pErr := persistor.Collection(
+		"redirects_purges",
+		keelmongo.CollectionWithIndexes(
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "time", Value: -1},
+				},
+			},
+		),
+	)
+	if pErr != nil {
+		return nil, pErr
+	}
+
+	batches, bErr := persistor.Collection(
+		"redirects_batches",
+		keelmongo.CollectionWithIndexes(
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "id", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "time", Value: -1},
+				},
+			},
+		),
+	)
+	if bErr != nil {
+		return nil, bErr
+	}
+
+	batchEntries, beErr := persistor.Collection(
+		"redirects_batch_entries",
+		keelmongo.CollectionWithIndexes(
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "batch", Value: 1},
+				},
+			},
+		),
+	)
+	if beErr != nil {
+		return nil, beErr
+	}
+
+	snapshots, sErr := persistor.Collection(
+		"redirects_snapshots",
+		keelmongo.CollectionWithIndexes(
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "name", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+		),
+	)
+	if sErr != nil {
+		return nil, sErr
+	}
+
+	snapshotDefs, sdErr := persistor.Collection(
+		"redirects_snapshot_entries",
+		keelmongo.CollectionWithIndexes(
+			mongo.IndexModel{
+				Keys: bson.D{
+					{Key: "snapshot", Value: 1},
+				},
+			},
+		),
+	)
+	if sdErr != nil {
+		return nil, sdErr
+	}
+
+	repo := NewRedirectsDefinitionRepository(l, collection)
+	repo.purges = purges
+	repo.batches = batches
+	repo.batchEntries = batchEntries
+	repo.snapshots = snapshots
+	repo.snapshotDefs = snapshotDefs
+
+	return repo, nil
 }
 
 func (rs *BaseRedirectsDefinitionRepository) FindOne(ctx context.Context, id, source string) (*storex.RedirectDefinition, error) {
@@ -88,9 +241,7 @@ func (rs *BaseRedirectsDefinitionRepository) FindOne(ctx context.Context, id, so
 
 func (rs *BaseRedirectsDefinitionRepository) FindMany(
 	ctx context.Context,
-	source, dimension string,
-	redirectType storex.RedirectionType,
-	activeState storex.ActiveStateType,
+	searchFilter storex.SearchFilter,
 	pagination storex.Pagination,
 	sort storex.Sort,
 ) (*storex.PaginatedResult, error) {
@@ -105,53 +256,58 @@ func (rs *BaseRedirectsDefinitionRepository) FindMany(
 
 	var result []*storex.RedirectDefinition
 
-	filter := bson.M{}
-
-	// Apply filters
-	if source != "" {
-		pattern := bson.Regex{Pattern: source, Options: "i"} // Case-insensitive regex
-		filter["source"] = pattern
-	}
-
-	if dimension != "" {
-		filter["dimension"] = dimension
+	filter, err := SearchFilterToBSON(searchFilter)
+	if err != nil {
+		return nil, err
 	}
 
-	// Apply redirect type filter
-	if redirectValue, apply := redirectType.ToFilter(); apply {
-		filter["redirectType"] = redirectValue
+	// Sorting settings
+	if sort.Field == "" {
+		sort.Field = storex.SortFieldSource // Default sort field
 	}
 
-	// Apply active state filter
-	if stateValue, apply := activeState.ToFilter(); apply {
-		filter["stale"] = stateValue
+	if !sort.Field.IsValid() {
+		return nil, fmt.Errorf("invalid sort field: '%s'", sort.Field)
 	}
 
-	// Pagination settings
-	skip := (pagination.Page - 1) * pagination.PageSize
+	// Pagination settings, one more result is fetched to detect the last page
 	opts := options.Find().
-		SetSkip(int64(skip)).
-		SetLimit(int64(pagination.PageSize))
+		SetLimit(int64(pagination.PageSize + 1)).
+		SetSort(bson.D{
+			{Key: string(sort.Field), Value: sort.Direction.GetSortValue()},
+			{Key: "id", Value: 1}, // Tie-breaker for consistent results
+		})
 
-	// Sorting settings
-	sortField := sort.Field
-	if sortField == "" {
-		sortField = storex.SortFieldSource // Default sort field
-	}
+	query := filter
+	if pagination.Cursor != "" {
+		pageCursor, err := storex.DecodePageCursor(pagination.Cursor)
+		if err != nil {
+			return nil, err
+		}
 
-	opts.SetSort(bson.D{
-		{Key: string(sortField), Value: sort.Direction.GetSortValue()},
-		{Key: "_id", Value: 1}, // Tie-breaker for consistent results
-	})
+		if !pageCursor.Matches(sort) {
+			return nil, errors.New("cursor does not match the sort order")
+		}
+
+		keyset, err := KeysetFilter(pageCursor)
+		if err != nil {
+			return nil, err
+		}
+
+		query = bson.M{"$and": bson.A{filter, keyset}}
+	} else {
+		opts.SetSkip(int64((pagination.Page - 1) * pagination.PageSize))
+	}
 
 	// Query MongoDB
-	cursor, err := rs.collection.Col().Find(ctx, filter, opts)
+	cursor, err := rs.collection.Col().Find(ctx, query, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
 	// Decode results
+	var lastMissing bool
 	for cursor.Next(ctx) {
 		var red storex.RedirectDefinition
 		if err := cursor.Decode(&red); err != nil {
@@ -159,6 +315,18 @@ func (rs *BaseRedirectsDefinitionRepository) FindMany(
 		}
 
 		result = append(result, &red)
+
+		// the decoded definition does not tell a null from an empty value
+		if len(result) == pagination.PageSize {
+			value, err := cursor.Current.LookupErr(string(sort.Field))
+			lastMissing = err != nil || value.Type == bson.TypeNull
+		}
+	}
+
+	var nextCursor string
+	if len(result) > pagination.PageSize {
+		result = result[:pagination.PageSize]
+		nextCursor = storex.NewPageCursor(result[len(result)-1], sort, lastMissing).Encode()
 	}
 
 	total, err := rs.collection.Col().CountDocuments(ctx, filter)
@@ -167,23 +335,22 @@ func (rs *BaseRedirectsDefinitionRepository) FindMany(
 	}
 
 	return &storex.PaginatedResult{
-		Results:  result,
-		Total:    int(total),
-		Page:     pagination.Page,
-		PageSize: pagination.PageSize,
+		Results:    result,
+		Total:      int(total),
+		Page:       pagination.Page,
+		PageSize:   pagination.PageSize,
+		NextCursor: nextCursor,
 	}, nil
 }
 
 func (rs *BaseRedirectsDefinitionRepository) FindAll(ctx context.Context, onlyActive bool) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
-	var results []storex.RedirectDefinition
-
-	filter := bson.M{}
+	return rs.FindAllByFilter(ctx, storex.RedirectsFilter{IncludeStale: !onlyActive})
+}
 
-	if onlyActive {
-		filter["stale"] = false
-	}
+func (rs *BaseRedirectsDefinitionRepository) FindAllByFilter(ctx context.Context, redirectsFilter storex.RedirectsFilter) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	var results []storex.RedirectDefinition
 
-	cursor, err := rs.collection.Col().Find(ctx, filter)
+	cursor, err := rs.collection.Col().Find(ctx, RedirectsFilterToBSON(redirectsFilter))
 	if err != nil {
 		rs.l.Error("Failed to fetch redirects", zap.Error(err))
 		return nil, err
@@ -291,6 +458,41 @@ func (rs *BaseRedirectsDefinitionRepository) DeleteMany(ctx context.Context, ids
 	return err
 }
 
+// FindObsolete returns the stale automatic redirects soft deleted by the consolidation before the cutoff
+func (rs *BaseRedirectsDefinitionRepository) FindObsolete(ctx context.Context, cutoff time.Time) ([]*storex.RedirectDefinition, error) {
+	var results []*storex.RedirectDefinition
+
+	err := rs.collection.Find(ctx, ObsoleteFilter(cutoff), &results)
+	if err != nil {
+		rs.l.Error("Failed to fetch obsolete redirects", zap.Error(err))
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// InsertPurgeRecord stores the record, without a purges collection the record is only logged
+func (rs *BaseRedirectsDefinitionRepository) InsertPurgeRecord(ctx context.Context, record *storex.PurgeRecord) error {
+	if record.ID == "" {
+		record.ID = storex.NewEntityID()
+	}
+
+	rs.l.Info("purged obsolete redirects",
+		zap.String("id", string(record.ID)),
+		zap.String("cutoff", string(record.Cutoff)),
+		zap.Int("count", len(record.Definitions)),
+		zap.Bool("dryRun", record.DryRun),
+	)
+
+	if rs.purges == nil {
+		return nil
+	}
+
+	_, err := rs.purges.Col().InsertOne(ctx, record)
+
+	return err
+}
+
 func (rs *BaseRedirectsDefinitionRepository) FindByIDs(ctx context.Context, ids []*storex.EntityID) ([]*storex.RedirectDefinition, error) {
 	var results []*storex.RedirectDefinition
 
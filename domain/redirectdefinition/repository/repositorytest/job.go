@@ -0,0 +1,157 @@
+package redirectrepositorytest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+)
+
+var _ repositoryx.JobRepository = (*JobRepository)(nil)
+
+// JobRepository stores copies of the jobs in memory
+type JobRepository struct {
+	mu   sync.Mutex
+	jobs []*storex.Job
+}
+
+func NewJobRepository() *JobRepository {
+	return &JobRepository{}
+}
+
+func (r *JobRepository) Enqueue(_ context.Context, job *storex.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if job.ID == "" {
+		job.ID = storex.NewEntityID()
+	}
+
+	r.jobs = append(r.jobs, cloneJob(job))
+
+	return nil
+}
+
+func (r *JobRepository) Claim(_ context.Context, worker string, ttl time.Duration) (*storex.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	var head *storex.Job
+
+	for _, job := range r.jobs {
+		if job.Status != storex.JobStatusQueued && job.Status != storex.JobStatusRunning {
+			continue
+		}
+
+		// jobs are stored in the order they were enqueued
+		if head == nil || job.Created < head.Created {
+			head = job
+		}
+	}
+
+	if head == nil || !claimable(head, now) {
+		return nil, nil //nolint:nilnil
+	}
+
+	head.Status = storex.JobStatusRunning
+	head.Worker = worker
+	head.Claim = storex.NewEntityID()
+	head.Started = storex.NewDateTime(now)
+	head.LockedUntil = storex.NewDateTime(now.Add(ttl))
+	head.Attempts++
+
+	return cloneJob(head), nil
+}
+
+func (r *JobRepository) Renew(_ context.Context, job *storex.Job, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.claimed(job)
+	if stored == nil {
+		return storex.ErrLeaseLost
+	}
+
+	stored.LockedUntil = storex.NewDateTime(time.Now().Add(ttl))
+
+	return nil
+}
+
+func (r *JobRepository) UpdateStatus(_ context.Context, job *storex.Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := r.claimed(job)
+	if stored == nil {
+		return storex.ErrLeaseLost
+	}
+
+	update := cloneJob(job)
+	stored.Status = update.Status
+	stored.Finished = update.Finished
+	stored.NextAttempt = update.NextAttempt
+	stored.Progress = update.Progress
+	stored.Dimensions = update.Dimensions
+	stored.Error = update.Error
+
+	return nil
+}
+
+func (r *JobRepository) FindJob(_ context.Context, id storex.EntityID) (*storex.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, job := range r.jobs {
+		if job.ID == id {
+			c := cloneJob(job)
+			c.Payload = nil
+
+			return c, nil
+		}
+	}
+
+	return nil, errors.New("job not found")
+}
+
+// claimed returns the stored job if it is running with the claim of the job
+func (r *JobRepository) claimed(job *storex.Job) *storex.Job {
+	for _, stored := range r.jobs {
+		if stored.ID == job.ID && stored.Status == storex.JobStatusRunning && stored.Claim == job.Claim {
+			return stored
+		}
+	}
+
+	return nil
+}
+
+func claimable(job *storex.Job, now time.Time) bool {
+	switch job.Status {
+	case storex.JobStatusQueued:
+		nextAttempt, err := job.NextAttempt.Time()
+		return err == nil && !nextAttempt.After(now)
+	case storex.JobStatusRunning:
+		lockedUntil, err := job.LockedUntil.Time()
+		return err == nil && lockedUntil.Before(now)
+	default:
+		return false
+	}
+}
+
+func cloneJob(job *storex.Job) *storex.Job {
+	c := *job
+	c.Payload = slices.Clone(job.Payload)
+	c.Dimensions = make(map[storex.Dimension]*storex.JobDimension, len(job.Dimensions))
+
+	for dimension, result := range job.Dimensions {
+		r := *result
+		c.Dimensions[dimension] = &r
+	}
+
+	return &c
+}
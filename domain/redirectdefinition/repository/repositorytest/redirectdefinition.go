@@ -0,0 +1,335 @@
+// Package redirectrepositorytest provides in-memory repositories for tests
+package redirectrepositorytest
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	repositoryx "github.com/foomo/redirects/v2/domain/redirectdefinition/repository"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+)
+
+var _ repositoryx.RedirectsDefinitionRepository = (*RedirectsDefinitionRepository)(nil)
+
+// ErrNotImplemented is returned by the search methods of the in-memory repository
+var ErrNotImplemented = errors.New("not implemented by the in-memory repository")
+
+// RedirectsDefinitionRepository stores copies of the definitions in memory
+type RedirectsDefinitionRepository struct {
+	mu           sync.Mutex
+	definitions  map[storex.EntityID]*storex.RedirectDefinition
+	batches      []*storex.Batch
+	batchEntries []*storex.BatchEntry
+	snapshots    []*storex.Snapshot
+	purges       []*storex.PurgeRecord
+}
+
+func NewRedirectsDefinitionRepository(defs ...*storex.RedirectDefinition) *RedirectsDefinitionRepository {
+	repo := &RedirectsDefinitionRepository{definitions: map[storex.EntityID]*storex.RedirectDefinition{}}
+	for _, def := range defs {
+		repo.put(def)
+	}
+
+	return repo
+}
+
+// Definitions returns copies of all stored definitions sorted by dimension and source
+func (r *RedirectsDefinitionRepository) Definitions() []*storex.RedirectDefinition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	defs := make([]*storex.RedirectDefinition, 0, len(r.definitions))
+	for _, def := range r.definitions {
+		defs = append(defs, clone(def))
+	}
+
+	slices.SortFunc(defs, func(a, b *storex.RedirectDefinition) int {
+		if a.Dimension != b.Dimension {
+			return cmp.Compare(a.Dimension, b.Dimension)
+		}
+
+		return cmp.Compare(a.Source, b.Source)
+	})
+
+	return defs
+}
+
+// BySource returns a copy of the definition of the dimension and source or nil
+func (r *RedirectsDefinitionRepository) BySource(dimension storex.Dimension, source storex.RedirectSource) *storex.RedirectDefinition {
+	for _, def := range r.Definitions() {
+		if def.Dimension == dimension && def.Source == source {
+			return def
+		}
+	}
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) FindOne(_ context.Context, id, source string) (*storex.RedirectDefinition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if def, ok := r.definitions[storex.EntityID(id)]; ok && string(def.Source) == source {
+		return clone(def), nil
+	}
+
+	return nil, errors.New("definition not found")
+}
+
+func (r *RedirectsDefinitionRepository) FindMany(_ context.Context, _ storex.SearchFilter, _ storex.Pagination, _ storex.Sort) (*storex.PaginatedResult, error) {
+	return nil, ErrNotImplemented
+}
+
+func (r *RedirectsDefinitionRepository) FindFacets(_ context.Context, _ storex.SearchFilter) (*storex.SearchFacets, error) {
+	return nil, ErrNotImplemented
+}
+
+func (r *RedirectsDefinitionRepository) FindAll(ctx context.Context, onlyActive bool) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	return r.FindAllByFilter(ctx, storex.RedirectsFilter{IncludeStale: !onlyActive})
+}
+
+func (r *RedirectsDefinitionRepository) FindAllByFilter(_ context.Context, filter storex.RedirectsFilter) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	ret := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{}
+
+	for _, def := range r.Definitions() {
+		if (def.Stale && !filter.IncludeStale) || !filter.Matches(def.Dimension) {
+			continue
+		}
+
+		if _, ok := ret[def.Dimension]; !ok {
+			ret[def.Dimension] = map[storex.RedirectSource]*storex.RedirectDefinition{}
+		}
+
+		ret[def.Dimension][def.Source] = def
+	}
+
+	return ret, nil
+}
+
+func (r *RedirectsDefinitionRepository) FindAllByDimension(ctx context.Context, dimension storex.Dimension, onlyActive bool) (map[storex.RedirectSource]*storex.RedirectDefinition, error) {
+	all, err := r.FindAllByFilter(ctx, storex.RedirectsFilter{Dimensions: []storex.Dimension{dimension}, IncludeStale: !onlyActive})
+	if err != nil {
+		return nil, err
+	}
+
+	if defs, ok := all[dimension]; ok {
+		return defs, nil
+	}
+
+	return map[storex.RedirectSource]*storex.RedirectDefinition{}, nil
+}
+
+func (r *RedirectsDefinitionRepository) Insert(_ context.Context, def *storex.RedirectDefinition) error {
+	if def.ID == "" {
+		def.ID = storex.NewEntityID()
+	}
+
+	r.put(def)
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) Update(_ context.Context, def *storex.RedirectDefinition) error {
+	r.put(def)
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) UpsertMany(_ context.Context, defs []*storex.RedirectDefinition) error {
+	for _, def := range defs {
+		r.put(def)
+	}
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) FindByIDs(_ context.Context, ids []*storex.EntityID) ([]*storex.RedirectDefinition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	defs := []*storex.RedirectDefinition{}
+
+	for _, id := range ids {
+		if def, ok := r.definitions[*id]; ok {
+			defs = append(defs, clone(def))
+		}
+	}
+
+	return defs, nil
+}
+
+func (r *RedirectsDefinitionRepository) Delete(_ context.Context, id storex.EntityID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.definitions, id)
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) DeleteMany(ctx context.Context, ids []storex.EntityID) error {
+	for _, id := range ids {
+		_ = r.Delete(ctx, id)
+	}
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) FindObsolete(_ context.Context, cutoff time.Time) ([]*storex.RedirectDefinition, error) {
+	defs := []*storex.RedirectDefinition{}
+
+	for _, def := range r.Definitions() {
+		if def.RedirectionType != storex.RedirectionTypeAutomatic || !def.Stale || def.Locked || def.Obsolete == "" {
+			continue
+		}
+
+		if obsolete, err := def.Obsolete.Time(); err == nil && obsolete.Before(cutoff) {
+			defs = append(defs, def)
+		}
+	}
+
+	return defs, nil
+}
+
+func (r *RedirectsDefinitionRepository) InsertPurgeRecord(_ context.Context, record *storex.PurgeRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.purges = append(r.purges, record)
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) InsertBatch(_ context.Context, batch *storex.Batch, entries []*storex.BatchEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = append(r.batches, batch)
+	r.batchEntries = append(r.batchEntries, entries...)
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) UpdateBatch(_ context.Context, batch *storex.Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, b := range r.batches {
+		if b.ID == batch.ID {
+			r.batches[i] = batch
+		}
+	}
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) FindBatches(_ context.Context, limit int) ([]*storex.Batch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	batches := slices.Clone(r.batches)
+	slices.Reverse(batches)
+
+	if limit > 0 && len(batches) > limit {
+		batches = batches[:limit]
+	}
+
+	return batches, nil
+}
+
+func (r *RedirectsDefinitionRepository) FindBatch(_ context.Context, id storex.EntityID) (*storex.Batch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, batch := range r.batches {
+		if batch.ID == id {
+			return batch, nil
+		}
+	}
+
+	return nil, errors.New("batch not found")
+}
+
+func (r *RedirectsDefinitionRepository) FindBatchEntries(_ context.Context, id storex.EntityID) ([]*storex.BatchEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := []*storex.BatchEntry{}
+
+	for _, entry := range r.batchEntries {
+		if entry.Batch == id {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *RedirectsDefinitionRepository) InsertSnapshot(_ context.Context, snapshot *storex.Snapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.snapshots {
+		if s.Name == snapshot.Name {
+			return storex.ErrSnapshotExists
+		}
+	}
+
+	snapshot.Count = len(snapshot.Definitions)
+	r.snapshots = append(r.snapshots, snapshot)
+
+	return nil
+}
+
+func (r *RedirectsDefinitionRepository) FindSnapshots(_ context.Context) ([]*storex.Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshots := make([]*storex.Snapshot, 0, len(r.snapshots))
+	for i := len(r.snapshots) - 1; i >= 0; i-- {
+		s := *r.snapshots[i]
+		s.Definitions = nil
+		snapshots = append(snapshots, &s)
+	}
+
+	return snapshots, nil
+}
+
+func (r *RedirectsDefinitionRepository) FindSnapshot(_ context.Context, name string) (*storex.Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, s := range r.snapshots {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+
+	return nil, errors.New("snapshot not found")
+}
+
+// MigrateUpdatedToDate is a no-op, the in-memory repository has no stored format
+func (r *RedirectsDefinitionRepository) MigrateUpdatedToDate(_ context.Context) (int64, error) {
+	return 0, nil
+}
+
+// put stores a copy of the definition, replacing the one with the same ID
+func (r *RedirectsDefinitionRepository) put(def *storex.RedirectDefinition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.definitions[def.ID] = clone(def)
+}
+
+func clone(def *storex.RedirectDefinition) *storex.RedirectDefinition {
+	c := *def
+	if def.ParamPolicy != nil {
+		policy := *def.ParamPolicy
+		c.ParamPolicy = &policy
+	}
+
+	return &c
+}
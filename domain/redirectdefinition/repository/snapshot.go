@@ -0,0 +1,97 @@
+package redirectrepository
+
+import (
+	"context"
+	"errors"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ErrSnapshotsDisabled is returned without a snapshots collection
+var ErrSnapshotsDisabled = errors.New("snapshots are not stored")
+
+// InsertSnapshot stores the snapshot and its definitions, the name must be unique
+func (rs *BaseRedirectsDefinitionRepository) InsertSnapshot(ctx context.Context, snapshot *storex.Snapshot) error {
+	if rs.snapshots == nil || rs.snapshotDefs == nil {
+		return ErrSnapshotsDisabled
+	}
+
+	if snapshot.ID == "" {
+		snapshot.ID = storex.NewEntityID()
+	}
+
+	snapshot.Count = len(snapshot.Definitions)
+
+	if _, err := rs.snapshots.Col().InsertOne(ctx, snapshot); mongo.IsDuplicateKeyError(err) {
+		return storex.ErrSnapshotExists
+	} else if err != nil {
+		return err
+	}
+
+	if len(snapshot.Definitions) == 0 {
+		return nil
+	}
+
+	entries := make([]*storex.SnapshotEntry, 0, len(snapshot.Definitions))
+	for _, def := range snapshot.Definitions {
+		entries = append(entries, &storex.SnapshotEntry{Snapshot: snapshot.ID, Definition: def})
+	}
+
+	if _, err := rs.snapshotDefs.Col().InsertMany(ctx, entries); err != nil {
+		rs.l.Error("Failed to insert snapshot entries, removing snapshot", zap.String("name", snapshot.Name), zap.Error(err))
+
+		// an incomplete snapshot must not be restored
+		_, _ = rs.snapshotDefs.Col().DeleteMany(context.WithoutCancel(ctx), bson.M{"snapshot": snapshot.ID})
+		_, _ = rs.snapshots.Col().DeleteOne(context.WithoutCancel(ctx), bson.M{"id": snapshot.ID})
+
+		return err
+	}
+
+	return nil
+}
+
+// FindSnapshots returns the snapshots without their definitions, the latest first
+func (rs *BaseRedirectsDefinitionRepository) FindSnapshots(ctx context.Context) ([]*storex.Snapshot, error) {
+	if rs.snapshots == nil {
+		return nil, ErrSnapshotsDisabled
+	}
+
+	var results []*storex.Snapshot
+
+	err := rs.snapshots.Find(ctx, bson.M{}, &results, options.Find().SetSort(bson.D{{Key: "time", Value: -1}}))
+	if err != nil {
+		rs.l.Error("Failed to fetch snapshots", zap.Error(err))
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FindSnapshot returns the snapshot with its definitions
+func (rs *BaseRedirectsDefinitionRepository) FindSnapshot(ctx context.Context, name string) (*storex.Snapshot, error) {
+	if rs.snapshots == nil || rs.snapshotDefs == nil {
+		return nil, ErrSnapshotsDisabled
+	}
+
+	var snapshot storex.Snapshot
+	if err := rs.snapshots.FindOne(ctx, bson.M{"name": name}, &snapshot); err != nil {
+		return nil, err
+	}
+
+	var entries []*storex.SnapshotEntry
+	if err := rs.snapshotDefs.Find(ctx, bson.M{"snapshot": snapshot.ID}, &entries); err != nil {
+		rs.l.Error("Failed to fetch snapshot entries", zap.String("name", name), zap.Error(err))
+		return nil, err
+	}
+
+	snapshot.Definitions = make([]*storex.RedirectDefinition, 0, len(entries))
+	for _, entry := range entries {
+		snapshot.Definitions = append(snapshot.Definitions, entry.Definition)
+	}
+
+	return &snapshot, nil
+}
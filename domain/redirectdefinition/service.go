@@ -9,17 +9,28 @@ import (
 	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
 	queryx "github.com/foomo/redirects/v2/domain/redirectdefinition/query"
 	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	telemetryx "github.com/foomo/redirects/v2/pkg/telemetry"
 	"go.uber.org/zap"
 )
 
 type SearchParams struct {
-	Locale       string                 `json:"locale"`
-	Path         string                 `json:"path"`
-	Page         int                    `json:"page"`
-	PageSize     int                    `json:"pageSize"`
-	RedirectType storex.RedirectionType `json:"type,omitempty"`
-	ActiveState  storex.ActiveStateType `json:"activeState,omitempty"`
-	Sort         storex.Sort            `json:"sort"`
+	Locale        string                 `json:"locale"`
+	Path          string                 `json:"path"`
+	Target        string                 `json:"target,omitempty"`
+	SearchMode    storex.SearchMode      `json:"searchMode,omitempty"` // applies to path and target, defaults to contains
+	ContentID     string                 `json:"contentId,omitempty"`
+	LastUpdatedBy string                 `json:"lastUpdatedBy,omitempty"`
+	Code          storex.RedirectCode    `json:"code,omitempty"`
+	UpdatedFrom   storex.DateTime        `json:"updatedFrom,omitempty"` // inclusive
+	UpdatedTo     storex.DateTime        `json:"updatedTo,omitempty"`   // inclusive
+	NeedsReview   bool                   `json:"needsReview,omitempty"` // only definitions created for deleted content
+	Page          int                    `json:"page"`
+	PageSize      int                    `json:"pageSize"`
+	Cursor        string                 `json:"cursor,omitempty"` // nextCursor of the previous result, replaces page
+	RedirectType  storex.RedirectionType `json:"type,omitempty"`
+	ActiveState   storex.ActiveStateType `json:"activeState,omitempty"`
+	Sort          storex.Sort            `json:"sort"`
+	Facets        bool                   `json:"facets,omitempty"` // Adds counts by type, active state, code and dimension
 }
 
 type Service struct {
@@ -43,26 +54,47 @@ func NewService(l *zap.Logger, api *API, options ...ServiceOption) *Service {
 	return s
 }
 
-// CreateRedirectsFromContentserverexport creates redirects from contentserverexport
+// CreateRedirectsFromContentserverexport creates redirects from contentserverexport,
+// with the job queue enabled the export is enqueued and the ID of the job is returned
 // internal use only
 func (rs *Service) CreateRedirectsFromContentserverexport(
 	_ http.ResponseWriter,
 	r *http.Request,
 	oldState,
 	newState map[string]*content.RepoNode,
-) error {
+) (storex.EntityID, error) {
 	rs.l.Info("CreateRedirectsFromContentserverexport called ")
 
 	if !rs.enableCreationOfAutomaticRedirects() {
 		rs.l.Info("CreateRedirectsFromContentserverexport not enabled")
-		return nil
+		return "", nil
 	}
 
-	return rs.api.CreateRedirects(r.Context(),
+	ctx, span := telemetryx.Tracer().Start(r.Context(), "CreateRedirectsFromContentserverexport")
+	defer span.End()
+
+	if rs.api.jobRepository != nil {
+		return rs.api.EnqueueCreateRedirects(ctx, oldState, newState)
+	}
+
+	result, err := rs.api.CreateRedirects(ctx,
 		commandx.CreateRedirects{
 			OldState: oldState,
 			NewState: newState,
 		})
+	if err != nil {
+		return "", err
+	}
+
+	logConflicts(rs.l, result.Conflicts)
+
+	return "", nil
+}
+
+// GetJob returns the status of a contentserver export job
+// internal use only
+func (rs *Service) GetJob(_ http.ResponseWriter, r *http.Request, id storex.EntityID) (*storex.Job, error) {
+	return rs.api.GetJob(r.Context(), id)
 }
 
 // GetRedirects returns all redirects
@@ -71,6 +103,22 @@ func (rs *Service) GetRedirects(_ http.ResponseWriter, r *http.Request) (map[sto
 	return rs.api.GetRedirects(r.Context())
 }
 
+// GetFilteredRedirects returns the redirects of the given dimensions or site in the compact format
+// internal use only
+func (rs *Service) GetFilteredRedirects(_ http.ResponseWriter, r *http.Request, filter *storex.RedirectsFilter) (storex.CompactRedirects, error) {
+	qry := queryx.GetRedirects{}
+	if filter != nil {
+		qry.Filter = *filter
+	}
+
+	redirects, err := rs.api.GetFilteredRedirects(r.Context(), qry)
+	if err != nil {
+		return nil, err
+	}
+
+	return storex.NewCompactRedirects(redirects), nil
+}
+
 // Search for a redirect
 // used by frontend
 func (rs *Service) Search(
@@ -92,13 +140,23 @@ func (rs *Service) Search(
 	}
 
 	result, err := rs.api.Search(r.Context(), queryx.Search{
-		Source:       storex.RedirectSource(params.Path),
-		Dimension:    storex.Dimension(fmt.Sprintf("%s-%s", site, params.Locale)),
-		Page:         params.Page,
-		PageSize:     params.PageSize,
-		RedirectType: params.RedirectType,
-		ActiveState:  params.ActiveState,
-		Sort:         params.Sort,
+		Source:        storex.RedirectSource(params.Path),
+		Target:        storex.RedirectTarget(params.Target),
+		SearchMode:    params.SearchMode,
+		Dimension:     storex.Dimension(fmt.Sprintf("%s-%s", site, params.Locale)),
+		ContentID:     params.ContentID,
+		LastUpdatedBy: params.LastUpdatedBy,
+		Code:          params.Code,
+		UpdatedFrom:   params.UpdatedFrom,
+		UpdatedTo:     params.UpdatedTo,
+		NeedsReview:   params.NeedsReview,
+		Page:          params.Page,
+		PageSize:      params.PageSize,
+		Cursor:        params.Cursor,
+		RedirectType:  params.RedirectType,
+		ActiveState:   params.ActiveState,
+		Sort:          params.Sort,
+		Facets:        params.Facets,
 	})
 	if err != nil {
 		return nil, storex.NewRedirectDefinitionError(err.Error())
@@ -160,6 +218,69 @@ func (rs *Service) Update(_ http.ResponseWriter, r *http.Request, def *storex.Re
 	return nil
 }
 
+// GetBatches returns the latest batches of the automatic runs
+// used by frontend
+func (rs *Service) GetBatches(_ http.ResponseWriter, r *http.Request, limit int) ([]*storex.Batch, *storex.RedirectDefinitionError) {
+	batches, err := rs.api.GetBatches(r.Context(), queryx.GetBatches{Limit: limit})
+	if err != nil {
+		return nil, storex.NewRedirectDefinitionError(err.Error())
+	}
+
+	return batches, nil
+}
+
+// RollbackBatch rolls back the redirects of an automatic run
+// used by frontend
+func (rs *Service) RollbackBatch(_ http.ResponseWriter, r *http.Request, id storex.EntityID) (*commandx.RollbackBatchResult, *storex.RedirectDefinitionError) {
+	result, err := rs.api.RollbackBatch(r.Context(), commandx.RollbackBatch{ID: id})
+	if err != nil {
+		return nil, storex.NewRedirectDefinitionError(err.Error())
+	}
+
+	return result, nil
+}
+
+// CreateSnapshot stores a snapshot of all definitions or the ones of a dimension
+// used by frontend
+func (rs *Service) CreateSnapshot(_ http.ResponseWriter, r *http.Request, name string, dimension storex.Dimension) (*storex.Snapshot, *storex.RedirectDefinitionError) {
+	snapshot, err := rs.api.CreateSnapshot(r.Context(), commandx.CreateSnapshot{
+		Name:      name,
+		Dimension: dimension,
+	})
+	if err != nil {
+		return nil, storex.NewRedirectDefinitionError(err.Error())
+	}
+
+	snapshot.Definitions = nil
+
+	return snapshot, nil
+}
+
+// GetSnapshots returns the stored snapshots
+// used by frontend
+func (rs *Service) GetSnapshots(_ http.ResponseWriter, r *http.Request) ([]*storex.Snapshot, *storex.RedirectDefinitionError) {
+	snapshots, err := rs.api.GetSnapshots(r.Context())
+	if err != nil {
+		return nil, storex.NewRedirectDefinitionError(err.Error())
+	}
+
+	return snapshots, nil
+}
+
+// RestoreSnapshot restores a stored snapshot, with dryRun only the diff is returned
+// used by frontend
+func (rs *Service) RestoreSnapshot(_ http.ResponseWriter, r *http.Request, name string, dryRun bool) (*storex.DefinitionsDiff, *storex.RedirectDefinitionError) {
+	diff, err := rs.api.RestoreSnapshot(r.Context(), commandx.RestoreSnapshot{
+		Name:   name,
+		DryRun: dryRun,
+	})
+	if err != nil {
+		return nil, storex.NewRedirectDefinitionError(err.Error())
+	}
+
+	return diff, nil
+}
+
 // UpdateStates updates a redirects state
 // used by frontend
 func (rs *Service) UpdateStates(_ http.ResponseWriter, r *http.Request, ids []*storex.EntityID, state bool) *storex.RedirectDefinitionError {
@@ -172,6 +293,7 @@ func (rs *Service) UpdateStates(_ http.ResponseWriter, r *http.Request, ids []*s
 	// Update each redirect in memory
 	for _, def := range redirects {
 		def.Stale = !state // flip the value because we are updating the stale field
+		def.Batch = ""     // manual changes are kept on rollback
 		def.Updated = storex.NewDateTime(time.Now())
 		rs.api.setLastUpdatedBy(r.Context(), def)
 	}
@@ -14,11 +14,16 @@ import (
 )
 
 const (
-	AdminServiceGoTSRPCProxyCreate       = "Create"
-	AdminServiceGoTSRPCProxyDelete       = "Delete"
-	AdminServiceGoTSRPCProxySearch       = "Search"
-	AdminServiceGoTSRPCProxyUpdate       = "Update"
-	AdminServiceGoTSRPCProxyUpdateStates = "UpdateStates"
+	AdminServiceGoTSRPCProxyCreate          = "Create"
+	AdminServiceGoTSRPCProxyCreateSnapshot  = "CreateSnapshot"
+	AdminServiceGoTSRPCProxyDelete          = "Delete"
+	AdminServiceGoTSRPCProxyGetBatches      = "GetBatches"
+	AdminServiceGoTSRPCProxyGetSnapshots    = "GetSnapshots"
+	AdminServiceGoTSRPCProxyRestoreSnapshot = "RestoreSnapshot"
+	AdminServiceGoTSRPCProxyRollbackBatch   = "RollbackBatch"
+	AdminServiceGoTSRPCProxySearch          = "Search"
+	AdminServiceGoTSRPCProxyUpdate          = "Update"
+	AdminServiceGoTSRPCProxyUpdateStates    = "UpdateStates"
 )
 
 type AdminServiceGoTSRPCProxy struct {
@@ -87,6 +92,38 @@ func (p *AdminServiceGoTSRPCProxy) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		}
 		gotsrpc.Monitor(w, r, args, rets, callStats)
 		return
+	case AdminServiceGoTSRPCProxyCreateSnapshot:
+		var (
+			args []any
+			rets []any
+		)
+		var (
+			arg_name      string
+			arg_dimension github_com_foomo_redirects_v2_domain_redirectdefinition_store.Dimension
+		)
+		args = []any{&arg_name, &arg_dimension}
+		if err := gotsrpc.LoadArgs(&args, callStats, r); err != nil {
+			gotsrpc.ErrorCouldNotLoadArgs(w)
+			return
+		}
+		var executionStart time.Time
+		if callStatsOk {
+			executionStart = time.Now()
+		}
+		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
+		createSnapshotRet, createSnapshotRet_1 := p.service.CreateSnapshot(&rw, r, arg_name, arg_dimension)
+		if callStatsOk {
+			callStats.Execution = time.Since(executionStart)
+		}
+		if rw.Status() == http.StatusOK {
+			rets = []any{createSnapshotRet, createSnapshotRet_1}
+			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
+				gotsrpc.ErrorCouldNotReply(w)
+				return
+			}
+		}
+		gotsrpc.Monitor(w, r, args, rets, callStats)
+		return
 	case AdminServiceGoTSRPCProxyDelete:
 		var (
 			args []any
@@ -118,6 +155,123 @@ func (p *AdminServiceGoTSRPCProxy) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		}
 		gotsrpc.Monitor(w, r, args, rets, callStats)
 		return
+	case AdminServiceGoTSRPCProxyGetBatches:
+		var (
+			args []any
+			rets []any
+		)
+		var (
+			arg_limit int
+		)
+		args = []any{&arg_limit}
+		if err := gotsrpc.LoadArgs(&args, callStats, r); err != nil {
+			gotsrpc.ErrorCouldNotLoadArgs(w)
+			return
+		}
+		var executionStart time.Time
+		if callStatsOk {
+			executionStart = time.Now()
+		}
+		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
+		getBatchesRet, getBatchesRet_1 := p.service.GetBatches(&rw, r, arg_limit)
+		if callStatsOk {
+			callStats.Execution = time.Since(executionStart)
+		}
+		if rw.Status() == http.StatusOK {
+			rets = []any{getBatchesRet, getBatchesRet_1}
+			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
+				gotsrpc.ErrorCouldNotReply(w)
+				return
+			}
+		}
+		gotsrpc.Monitor(w, r, args, rets, callStats)
+		return
+	case AdminServiceGoTSRPCProxyGetSnapshots:
+		var (
+			args []any
+			rets []any
+		)
+		var executionStart time.Time
+		if callStatsOk {
+			executionStart = time.Now()
+		}
+		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
+		getSnapshotsRet, getSnapshotsRet_1 := p.service.GetSnapshots(&rw, r)
+		if callStatsOk {
+			callStats.Execution = time.Since(executionStart)
+		}
+		if rw.Status() == http.StatusOK {
+			rets = []any{getSnapshotsRet, getSnapshotsRet_1}
+			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
+				gotsrpc.ErrorCouldNotReply(w)
+				return
+			}
+		}
+		gotsrpc.Monitor(w, r, args, rets, callStats)
+		return
+	case AdminServiceGoTSRPCProxyRestoreSnapshot:
+		var (
+			args []any
+			rets []any
+		)
+		var (
+			arg_name   string
+			arg_dryRun bool
+		)
+		args = []any{&arg_name, &arg_dryRun}
+		if err := gotsrpc.LoadArgs(&args, callStats, r); err != nil {
+			gotsrpc.ErrorCouldNotLoadArgs(w)
+			return
+		}
+		var executionStart time.Time
+		if callStatsOk {
+			executionStart = time.Now()
+		}
+		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
+		restoreSnapshotRet, restoreSnapshotRet_1 := p.service.RestoreSnapshot(&rw, r, arg_name, arg_dryRun)
+		if callStatsOk {
+			callStats.Execution = time.Since(executionStart)
+		}
+		if rw.Status() == http.StatusOK {
+			rets = []any{restoreSnapshotRet, restoreSnapshotRet_1}
+			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
+				gotsrpc.ErrorCouldNotReply(w)
+				return
+			}
+		}
+		gotsrpc.Monitor(w, r, args, rets, callStats)
+		return
+	case AdminServiceGoTSRPCProxyRollbackBatch:
+		var (
+			args []any
+			rets []any
+		)
+		var (
+			arg_id github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID
+		)
+		args = []any{&arg_id}
+		if err := gotsrpc.LoadArgs(&args, callStats, r); err != nil {
+			gotsrpc.ErrorCouldNotLoadArgs(w)
+			return
+		}
+		var executionStart time.Time
+		if callStatsOk {
+			executionStart = time.Now()
+		}
+		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
+		rollbackBatchRet, rollbackBatchRet_1 := p.service.RollbackBatch(&rw, r, arg_id)
+		if callStatsOk {
+			callStats.Execution = time.Since(executionStart)
+		}
+		if rw.Status() == http.StatusOK {
+			rets = []any{rollbackBatchRet, rollbackBatchRet_1}
+			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
+				gotsrpc.ErrorCouldNotReply(w)
+				return
+			}
+		}
+		gotsrpc.Monitor(w, r, args, rets, callStats)
+		return
 	case AdminServiceGoTSRPCProxySearch:
 		var (
 			args []any
@@ -220,6 +374,8 @@ func (p *AdminServiceGoTSRPCProxy) ServeHTTP(w http.ResponseWriter, r *http.Requ
 
 const (
 	InternalServiceGoTSRPCProxyCreateRedirectsFromContentserverexport = "CreateRedirectsFromContentserverexport"
+	InternalServiceGoTSRPCProxyGetFilteredRedirects                   = "GetFilteredRedirects"
+	InternalServiceGoTSRPCProxyGetJob                                 = "GetJob"
 	InternalServiceGoTSRPCProxyGetRedirects                           = "GetRedirects"
 )
 
@@ -276,12 +432,74 @@ func (p *InternalServiceGoTSRPCProxy) ServeHTTP(w http.ResponseWriter, r *http.R
 			executionStart = time.Now()
 		}
 		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
-		createRedirectsFromContentserverexportRet := p.service.CreateRedirectsFromContentserverexport(&rw, r, arg_oldState, arg_newState)
+		createRedirectsFromContentserverexportRet, createRedirectsFromContentserverexportRet_1 := p.service.CreateRedirectsFromContentserverexport(&rw, r, arg_oldState, arg_newState)
+		if callStatsOk {
+			callStats.Execution = time.Since(executionStart)
+		}
+		if rw.Status() == http.StatusOK {
+			rets = []any{createRedirectsFromContentserverexportRet, gotsrpc.ErrorReply(createRedirectsFromContentserverexportRet_1)}
+			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
+				gotsrpc.ErrorCouldNotReply(w)
+				return
+			}
+		}
+		gotsrpc.Monitor(w, r, args, rets, callStats)
+		return
+	case InternalServiceGoTSRPCProxyGetFilteredRedirects:
+		var (
+			args []any
+			rets []any
+		)
+		var (
+			arg_filter *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectsFilter
+		)
+		args = []any{&arg_filter}
+		if err := gotsrpc.LoadArgs(&args, callStats, r); err != nil {
+			gotsrpc.ErrorCouldNotLoadArgs(w)
+			return
+		}
+		var executionStart time.Time
+		if callStatsOk {
+			executionStart = time.Now()
+		}
+		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
+		getFilteredRedirectsRet, getFilteredRedirectsRet_1 := p.service.GetFilteredRedirects(&rw, r, arg_filter)
+		if callStatsOk {
+			callStats.Execution = time.Since(executionStart)
+		}
+		if rw.Status() == http.StatusOK {
+			rets = []any{getFilteredRedirectsRet, gotsrpc.ErrorReply(getFilteredRedirectsRet_1)}
+			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
+				gotsrpc.ErrorCouldNotReply(w)
+				return
+			}
+		}
+		gotsrpc.Monitor(w, r, args, rets, callStats)
+		return
+	case InternalServiceGoTSRPCProxyGetJob:
+		var (
+			args []any
+			rets []any
+		)
+		var (
+			arg_id github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID
+		)
+		args = []any{&arg_id}
+		if err := gotsrpc.LoadArgs(&args, callStats, r); err != nil {
+			gotsrpc.ErrorCouldNotLoadArgs(w)
+			return
+		}
+		var executionStart time.Time
+		if callStatsOk {
+			executionStart = time.Now()
+		}
+		rw := gotsrpc.ResponseWriter{ResponseWriter: w}
+		getJobRet, getJobRet_1 := p.service.GetJob(&rw, r, arg_id)
 		if callStatsOk {
 			callStats.Execution = time.Since(executionStart)
 		}
 		if rw.Status() == http.StatusOK {
-			rets = []any{gotsrpc.ErrorReply(createRedirectsFromContentserverexportRet)}
+			rets = []any{getJobRet, gotsrpc.ErrorReply(getJobRet_1)}
 			if err := gotsrpc.Reply(rets, callStats, r, w); err != nil {
 				gotsrpc.ErrorCouldNotReply(w)
 				return
@@ -9,13 +9,19 @@ import (
 	github_com_foomo_contentserver_content "github.com/foomo/contentserver/content"
 	gotsrpc "github.com/foomo/gotsrpc/v2"
 	github_com_foomo_redirects_v2_domain_redirectdefinition "github.com/foomo/redirects/v2/domain/redirectdefinition"
+	github_com_foomo_redirects_v2_domain_redirectdefinition_command "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
 	github_com_foomo_redirects_v2_domain_redirectdefinition_store "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
 	pkg_errors "github.com/pkg/errors"
 )
 
 type AdminServiceGoTSRPCClient interface {
 	Create(ctx go_context.Context, def *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinition, locale string) (retCreate_0 github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID, retCreate_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
+	CreateSnapshot(ctx go_context.Context, name string, dimension github_com_foomo_redirects_v2_domain_redirectdefinition_store.Dimension) (retCreateSnapshot_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.Snapshot, retCreateSnapshot_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
 	Delete(ctx go_context.Context, id string) (retDelete_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
+	GetBatches(ctx go_context.Context, limit int) (retGetBatches_0 []*github_com_foomo_redirects_v2_domain_redirectdefinition_store.Batch, retGetBatches_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
+	GetSnapshots(ctx go_context.Context) (retGetSnapshots_0 []*github_com_foomo_redirects_v2_domain_redirectdefinition_store.Snapshot, retGetSnapshots_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
+	RestoreSnapshot(ctx go_context.Context, name string, dryRun bool) (retRestoreSnapshot_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.DefinitionsDiff, retRestoreSnapshot_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
+	RollbackBatch(ctx go_context.Context, id github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID) (retRollbackBatch_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_command.RollbackBatchResult, retRollbackBatch_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
 	Search(ctx go_context.Context, params *github_com_foomo_redirects_v2_domain_redirectdefinition.SearchParams) (retSearch_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.PaginatedResult, retSearch_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
 	Update(ctx go_context.Context, def *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinition) (retUpdate_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
 	UpdateStates(ctx go_context.Context, ids []*github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID, state bool) (retUpdateStates_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error)
@@ -53,6 +59,16 @@ func (tsc *HTTPAdminServiceGoTSRPCClient) Create(ctx go_context.Context, def *gi
 	return
 }
 
+func (tsc *HTTPAdminServiceGoTSRPCClient) CreateSnapshot(ctx go_context.Context, name string, dimension github_com_foomo_redirects_v2_domain_redirectdefinition_store.Dimension) (retCreateSnapshot_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.Snapshot, retCreateSnapshot_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error) {
+	rpcArgs := []any{name, dimension}
+	rpcReply := []any{&retCreateSnapshot_0, &retCreateSnapshot_1}
+	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "CreateSnapshot", rpcArgs, rpcReply)
+	if rpcErr != nil {
+		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.AdminServiceGoTSRPCProxy CreateSnapshot")
+	}
+	return
+}
+
 func (tsc *HTTPAdminServiceGoTSRPCClient) Delete(ctx go_context.Context, id string) (retDelete_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error) {
 	rpcArgs := []any{id}
 	rpcReply := []any{&retDelete_0}
@@ -63,6 +79,46 @@ func (tsc *HTTPAdminServiceGoTSRPCClient) Delete(ctx go_context.Context, id stri
 	return
 }
 
+func (tsc *HTTPAdminServiceGoTSRPCClient) GetBatches(ctx go_context.Context, limit int) (retGetBatches_0 []*github_com_foomo_redirects_v2_domain_redirectdefinition_store.Batch, retGetBatches_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error) {
+	rpcArgs := []any{limit}
+	rpcReply := []any{&retGetBatches_0, &retGetBatches_1}
+	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "GetBatches", rpcArgs, rpcReply)
+	if rpcErr != nil {
+		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.AdminServiceGoTSRPCProxy GetBatches")
+	}
+	return
+}
+
+func (tsc *HTTPAdminServiceGoTSRPCClient) GetSnapshots(ctx go_context.Context) (retGetSnapshots_0 []*github_com_foomo_redirects_v2_domain_redirectdefinition_store.Snapshot, retGetSnapshots_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error) {
+	rpcArgs := []any{}
+	rpcReply := []any{&retGetSnapshots_0, &retGetSnapshots_1}
+	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "GetSnapshots", rpcArgs, rpcReply)
+	if rpcErr != nil {
+		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.AdminServiceGoTSRPCProxy GetSnapshots")
+	}
+	return
+}
+
+func (tsc *HTTPAdminServiceGoTSRPCClient) RestoreSnapshot(ctx go_context.Context, name string, dryRun bool) (retRestoreSnapshot_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.DefinitionsDiff, retRestoreSnapshot_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error) {
+	rpcArgs := []any{name, dryRun}
+	rpcReply := []any{&retRestoreSnapshot_0, &retRestoreSnapshot_1}
+	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "RestoreSnapshot", rpcArgs, rpcReply)
+	if rpcErr != nil {
+		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.AdminServiceGoTSRPCProxy RestoreSnapshot")
+	}
+	return
+}
+
+func (tsc *HTTPAdminServiceGoTSRPCClient) RollbackBatch(ctx go_context.Context, id github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID) (retRollbackBatch_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_command.RollbackBatchResult, retRollbackBatch_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error) {
+	rpcArgs := []any{id}
+	rpcReply := []any{&retRollbackBatch_0, &retRollbackBatch_1}
+	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "RollbackBatch", rpcArgs, rpcReply)
+	if rpcErr != nil {
+		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.AdminServiceGoTSRPCProxy RollbackBatch")
+	}
+	return
+}
+
 func (tsc *HTTPAdminServiceGoTSRPCClient) Search(ctx go_context.Context, params *github_com_foomo_redirects_v2_domain_redirectdefinition.SearchParams) (retSearch_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.PaginatedResult, retSearch_1 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinitionError, clientErr error) {
 	rpcArgs := []any{params}
 	rpcReply := []any{&retSearch_0, &retSearch_1}
@@ -94,7 +150,9 @@ func (tsc *HTTPAdminServiceGoTSRPCClient) UpdateStates(ctx go_context.Context, i
 }
 
 type InternalServiceGoTSRPCClient interface {
-	CreateRedirectsFromContentserverexport(ctx go_context.Context, oldState map[string]*github_com_foomo_contentserver_content.RepoNode, newState map[string]*github_com_foomo_contentserver_content.RepoNode) (retCreateRedirectsFromContentserverexport_0 error, clientErr error)
+	CreateRedirectsFromContentserverexport(ctx go_context.Context, oldState map[string]*github_com_foomo_contentserver_content.RepoNode, newState map[string]*github_com_foomo_contentserver_content.RepoNode) (retCreateRedirectsFromContentserverexport_0 github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID, retCreateRedirectsFromContentserverexport_1 error, clientErr error)
+	GetFilteredRedirects(ctx go_context.Context, filter *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectsFilter) (retGetFilteredRedirects_0 github_com_foomo_redirects_v2_domain_redirectdefinition_store.CompactRedirects, retGetFilteredRedirects_1 error, clientErr error)
+	GetJob(ctx go_context.Context, id github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID) (retGetJob_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.Job, retGetJob_1 error, clientErr error)
 	GetRedirects(ctx go_context.Context) (retGetRedirects_0 map[github_com_foomo_redirects_v2_domain_redirectdefinition_store.Dimension]map[github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectSource]*github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinition, retGetRedirects_1 error, clientErr error)
 }
 
@@ -120,9 +178,9 @@ func NewInternalServiceGoTSRPCClientWithClient(url string, endpoint string, clie
 	}
 }
 
-func (tsc *HTTPInternalServiceGoTSRPCClient) CreateRedirectsFromContentserverexport(ctx go_context.Context, oldState map[string]*github_com_foomo_contentserver_content.RepoNode, newState map[string]*github_com_foomo_contentserver_content.RepoNode) (retCreateRedirectsFromContentserverexport_0 error, clientErr error) {
+func (tsc *HTTPInternalServiceGoTSRPCClient) CreateRedirectsFromContentserverexport(ctx go_context.Context, oldState map[string]*github_com_foomo_contentserver_content.RepoNode, newState map[string]*github_com_foomo_contentserver_content.RepoNode) (retCreateRedirectsFromContentserverexport_0 github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID, retCreateRedirectsFromContentserverexport_1 error, clientErr error) {
 	rpcArgs := []any{oldState, newState}
-	rpcReply := []any{&retCreateRedirectsFromContentserverexport_0}
+	rpcReply := []any{&retCreateRedirectsFromContentserverexport_0, &retCreateRedirectsFromContentserverexport_1}
 	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "CreateRedirectsFromContentserverexport", rpcArgs, rpcReply)
 	if rpcErr != nil {
 		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.InternalServiceGoTSRPCProxy CreateRedirectsFromContentserverexport")
@@ -130,6 +188,26 @@ func (tsc *HTTPInternalServiceGoTSRPCClient) CreateRedirectsFromContentserverexp
 	return
 }
 
+func (tsc *HTTPInternalServiceGoTSRPCClient) GetFilteredRedirects(ctx go_context.Context, filter *github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectsFilter) (retGetFilteredRedirects_0 github_com_foomo_redirects_v2_domain_redirectdefinition_store.CompactRedirects, retGetFilteredRedirects_1 error, clientErr error) {
+	rpcArgs := []any{filter}
+	rpcReply := []any{&retGetFilteredRedirects_0, &retGetFilteredRedirects_1}
+	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "GetFilteredRedirects", rpcArgs, rpcReply)
+	if rpcErr != nil {
+		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.InternalServiceGoTSRPCProxy GetFilteredRedirects")
+	}
+	return
+}
+
+func (tsc *HTTPInternalServiceGoTSRPCClient) GetJob(ctx go_context.Context, id github_com_foomo_redirects_v2_domain_redirectdefinition_store.EntityID) (retGetJob_0 *github_com_foomo_redirects_v2_domain_redirectdefinition_store.Job, retGetJob_1 error, clientErr error) {
+	rpcArgs := []any{id}
+	rpcReply := []any{&retGetJob_0, &retGetJob_1}
+	rpcErr := tsc.Client.Call(ctx, tsc.URL, tsc.EndPoint, "GetJob", rpcArgs, rpcReply)
+	if rpcErr != nil {
+		clientErr = pkg_errors.WithMessage(rpcErr, "failed to call service.InternalServiceGoTSRPCProxy GetJob")
+	}
+	return
+}
+
 func (tsc *HTTPInternalServiceGoTSRPCClient) GetRedirects(ctx go_context.Context) (retGetRedirects_0 map[github_com_foomo_redirects_v2_domain_redirectdefinition_store.Dimension]map[github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectSource]*github_com_foomo_redirects_v2_domain_redirectdefinition_store.RedirectDefinition, retGetRedirects_1 error, clientErr error) {
 	rpcArgs := []any{}
 	rpcReply := []any{&retGetRedirects_0, &retGetRedirects_1}
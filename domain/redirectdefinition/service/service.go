@@ -5,6 +5,7 @@ import (
 
 	"github.com/foomo/contentserver/content"
 	redirectdefinitionx "github.com/foomo/redirects/v2/domain/redirectdefinition"
+	commandx "github.com/foomo/redirects/v2/domain/redirectdefinition/command"
 	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
 )
 
@@ -17,12 +18,19 @@ type AdminService interface {
 	Delete(w http.ResponseWriter, r *http.Request, id string) *storex.RedirectDefinitionError
 	Update(w http.ResponseWriter, r *http.Request, def *storex.RedirectDefinition) *storex.RedirectDefinitionError
 	UpdateStates(w http.ResponseWriter, r *http.Request, ids []*storex.EntityID, state bool) *storex.RedirectDefinitionError
+	GetBatches(w http.ResponseWriter, r *http.Request, limit int) ([]*storex.Batch, *storex.RedirectDefinitionError)
+	RollbackBatch(w http.ResponseWriter, r *http.Request, id storex.EntityID) (*commandx.RollbackBatchResult, *storex.RedirectDefinitionError)
+	CreateSnapshot(w http.ResponseWriter, r *http.Request, name string, dimension storex.Dimension) (*storex.Snapshot, *storex.RedirectDefinitionError)
+	GetSnapshots(w http.ResponseWriter, r *http.Request) ([]*storex.Snapshot, *storex.RedirectDefinitionError)
+	RestoreSnapshot(w http.ResponseWriter, r *http.Request, name string, dryRun bool) (*storex.DefinitionsDiff, *storex.RedirectDefinitionError)
 }
 
 // InternalService is the interface for the internal service
 // the service is responsible for the internal endpoints
 // will not be exposed only to other backend services
 type InternalService interface {
-	CreateRedirectsFromContentserverexport(w http.ResponseWriter, r *http.Request, oldState, newState map[string]*content.RepoNode) error
+	CreateRedirectsFromContentserverexport(w http.ResponseWriter, r *http.Request, oldState, newState map[string]*content.RepoNode) (storex.EntityID, error)
+	GetJob(w http.ResponseWriter, r *http.Request, id storex.EntityID) (*storex.Job, error)
 	GetRedirects(w http.ResponseWriter, r *http.Request) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error)
+	GetFilteredRedirects(w http.ResponseWriter, r *http.Request, filter *storex.RedirectsFilter) (storex.CompactRedirects, error)
 }
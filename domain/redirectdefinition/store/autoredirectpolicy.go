@@ -0,0 +1,28 @@
+package redirectstore
+
+// AutoRedirectPolicy configures the automatic redirects created for moved content
+type AutoRedirectPolicy struct {
+	// Skip creates no automatic redirects, e.g. for preview or internal node types
+	Skip bool
+	// Code of the redirects
+	Code RedirectCode
+	// RespectParams of the redirects
+	RespectParams bool
+	// TransferParams of the redirects
+	TransferParams bool
+	// ParamPolicy of the redirects, nil uses the policy of the dimension
+	ParamPolicy *ParamPolicy
+	// Stale is the initial stale state of the redirects
+	Stale bool
+}
+
+// DefaultAutoRedirectPolicy returns the policy of previous versions: 301, respecting and transferring params.
+// Custom policies should start from it.
+func DefaultAutoRedirectPolicy(initialStaleValue bool) AutoRedirectPolicy {
+	return AutoRedirectPolicy{
+		Code:           RedirectCodePermanent,
+		RespectParams:  true,
+		TransferParams: true,
+		Stale:          initialStaleValue,
+	}
+}
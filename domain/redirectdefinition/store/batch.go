@@ -0,0 +1,49 @@
+package redirectstore
+
+import (
+	"errors"
+)
+
+// ErrBatchRolledBack is returned if a batch was already rolled back
+var ErrBatchRolledBack = errors.New("batch was already rolled back")
+
+// BatchOperation of a batch entry
+type BatchOperation string
+
+const (
+	BatchOperationCreated BatchOperation = "created"
+	BatchOperationChanged BatchOperation = "changed"
+	BatchOperationDeleted BatchOperation = "deleted"
+)
+
+// Batch documents the redirects written by one automatic run
+type Batch struct {
+	ID           EntityID    `json:"id" bson:"id"`
+	Time         DateTime    `json:"time" bson:"time"`
+	Dimensions   []Dimension `json:"dimensions" bson:"dimensions"`
+	Created      int         `json:"created" bson:"created"`
+	Changed      int         `json:"changed" bson:"changed"`
+	Deleted      int         `json:"deleted" bson:"deleted"`
+	RolledBack   DateTime    `json:"rolledBack,omitempty" bson:"rolledBack"`
+	RolledBackBy string      `json:"rolledBackBy,omitempty" bson:"rolledBackBy"`
+}
+
+// BatchEntry holds the before-image of a redirect written by a batch
+type BatchEntry struct {
+	Batch     EntityID            `json:"batch" bson:"batch"`
+	ID        EntityID            `json:"id" bson:"id"`
+	Operation BatchOperation      `json:"operation" bson:"operation"`
+	Before    *RedirectDefinition `json:"before,omitempty" bson:"before,omitempty"` // Not set for created redirects
+}
+
+// Add counts the entry
+func (b *Batch) Add(entry *BatchEntry) {
+	switch entry.Operation {
+	case BatchOperationCreated:
+		b.Created++
+	case BatchOperationChanged:
+		b.Changed++
+	case BatchOperationDeleted:
+		b.Deleted++
+	}
+}
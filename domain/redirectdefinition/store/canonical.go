@@ -0,0 +1,132 @@
+package redirectstore
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// CanonicalizePath returns the canonical escaped form of an unescaped path:
+// Unicode NFC with percent-encoding as produced by net/url (upper case hex).
+// A '+' is kept as is, it only encodes a space in the query.
+func CanonicalizePath(p string) string {
+	u := url.URL{Path: norm.NFC.String(p)}
+
+	return u.EscapedPath()
+}
+
+// CanonicalizeEscapedPath returns the canonical form of an escaped path, see CanonicalizePath.
+// Encoded slashes ('%2F') are kept, they are not the same path as '/'.
+func CanonicalizeEscapedPath(p string) (string, error) {
+	segments := strings.Split(p, "/")
+	for i, segment := range segments {
+		unescaped, err := url.PathUnescape(segment)
+		if err != nil {
+			return "", err
+		}
+
+		segments[i] = strings.ReplaceAll(CanonicalizePath(unescaped), "/", "%2F")
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// CanonicalizeQuery returns the canonical form of a raw query: keys sorted,
+// values in Unicode NFC, spaces ('+' or '%20') encoded as '+'.
+func CanonicalizeQuery(rawQuery string) (string, error) {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", err
+	}
+
+	canonical := make(url.Values, len(values))
+
+	for key, vs := range values {
+		key = norm.NFC.String(key)
+		for _, v := range vs {
+			canonical[key] = append(canonical[key], norm.NFC.String(v))
+		}
+	}
+
+	return canonical.Encode(), nil
+}
+
+// CanonicalizeHost returns the lower case ASCII (punycode) form of a host with optional port
+func CanonicalizeHost(host string) (string, error) {
+	hostname, port := host, ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		hostname, port = h, p
+	}
+
+	ascii, err := idna.Lookup.ToASCII(norm.NFC.String(hostname))
+	if err != nil {
+		return "", fmt.Errorf("invalid host '%s': %w", hostname, err)
+	}
+
+	ascii = strings.ToLower(ascii)
+	if port != "" {
+		return net.JoinHostPort(ascii, port), nil
+	}
+
+	return ascii, nil
+}
+
+// CanonicalizeURI returns the canonical form of a relative or absolute uri,
+// see CanonicalizePath, CanonicalizeQuery and CanonicalizeHost
+func CanonicalizeURI(uri string) (string, error) {
+	if uri == "" {
+		return uri, nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	// opaque uris like mailto: are left alone
+	if u.Opaque != "" {
+		return uri, nil
+	}
+
+	if u.Host != "" {
+		if u.Host, err = CanonicalizeHost(u.Host); err != nil {
+			return "", err
+		}
+	}
+
+	if u.RawPath, err = CanonicalizeEscapedPath(u.EscapedPath()); err != nil {
+		return "", err
+	}
+
+	if u.Path, err = url.PathUnescape(u.RawPath); err != nil {
+		return "", err
+	}
+
+	if u.RawQuery, err = CanonicalizeQuery(u.RawQuery); err != nil {
+		return "", err
+	}
+
+	u.ForceQuery = false
+	u.Fragment = norm.NFC.String(u.Fragment)
+	u.RawFragment = ""
+
+	return u.String(), nil
+}
+
+// Canonical returns the canonical form of the source, see CanonicalizeURI
+func (s RedirectSource) Canonical() (RedirectSource, error) {
+	canonical, err := CanonicalizeURI(string(s))
+
+	return RedirectSource(canonical), err
+}
+
+// Canonical returns the canonical form of the target, see CanonicalizeURI
+func (t RedirectTarget) Canonical() (RedirectTarget, error) {
+	canonical, err := CanonicalizeURI(string(t))
+
+	return RedirectTarget(canonical), err
+}
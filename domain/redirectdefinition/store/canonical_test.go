@@ -0,0 +1,49 @@
+package redirectstore_test
+
+import (
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CanonicalizeURI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{uri: "/über", want: "/%C3%BCber"},
+		{uri: "/%C3%BCber", want: "/%C3%BCber"},
+		{uri: "/%c3%bcber", want: "/%C3%BCber"},
+		{uri: "/u\u0308ber", want: "/%C3%BCber"}, // NFD
+		{uri: "/foo?b=2&a=1", want: "/foo?a=1&b=2"},
+		{uri: "/foo?q=a+b", want: "/foo?q=a+b"},
+		{uri: "/foo?q=a%20b", want: "/foo?q=a+b"},
+		{uri: "/foo?", want: "/foo"},
+		{uri: "/c++", want: "/c++"},
+		{uri: "/a%2fb/c", want: "/a%2Fb/c"},
+		{uri: "/%C3%BCber%2F%c3%a4", want: "/%C3%BCber%2F%C3%A4"},
+		{uri: "https://Bücher.example/Straße", want: "https://xn--bcher-kva.example/Stra%C3%9Fe"},
+		{uri: "https://www.example.com:8443/a", want: "https://www.example.com:8443/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := storex.CanonicalizeURI(tt.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_CanonicalizeURI_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := storex.CanonicalizeURI("/foo%zz")
+	require.Error(t, err)
+}
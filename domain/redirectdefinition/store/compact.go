@@ -0,0 +1,70 @@
+package redirectstore
+
+// CompactRedirectDefinition is the reduced wire format of a definition for gateways
+type CompactRedirectDefinition struct {
+	ID             EntityID       `json:"id,omitempty"`
+	Source         RedirectSource `json:"s"`
+	Target         RedirectTarget `json:"t"`
+	Code           RedirectCode   `json:"c"`
+	MatchType      MatchType      `json:"m,omitempty"`
+	RespectParams  bool           `json:"rp,omitempty"`
+	TransferParams bool           `json:"tp,omitempty"`
+	ParamPolicy    *ParamPolicy   `json:"pp,omitempty"`
+	Stale          bool           `json:"st,omitempty"`
+}
+
+// CompactRedirects are the compact definitions by dimension
+type CompactRedirects map[Dimension][]*CompactRedirectDefinition
+
+// NewCompactRedirects converts the definitions into the compact format
+func NewCompactRedirects(definitions map[Dimension]map[RedirectSource]*RedirectDefinition) CompactRedirects {
+	ret := make(CompactRedirects, len(definitions))
+
+	for dimension, defs := range definitions {
+		compact := make([]*CompactRedirectDefinition, 0, len(defs))
+		for _, def := range defs {
+			compact = append(compact, &CompactRedirectDefinition{
+				ID:             def.ID,
+				Source:         def.Source,
+				Target:         def.Target,
+				Code:           def.Code,
+				MatchType:      def.MatchType,
+				RespectParams:  def.RespectParams,
+				TransferParams: def.TransferParams,
+				ParamPolicy:    def.ParamPolicy,
+				Stale:          def.Stale,
+			})
+		}
+
+		ret[dimension] = compact
+	}
+
+	return ret
+}
+
+// Definitions expands the compact format into definitions
+func (c CompactRedirects) Definitions() map[Dimension]map[RedirectSource]*RedirectDefinition {
+	ret := make(map[Dimension]map[RedirectSource]*RedirectDefinition, len(c))
+
+	for dimension, defs := range c {
+		expanded := make(map[RedirectSource]*RedirectDefinition, len(defs))
+		for _, def := range defs {
+			expanded[def.Source] = &RedirectDefinition{
+				ID:             def.ID,
+				Source:         def.Source,
+				Target:         def.Target,
+				Code:           def.Code,
+				MatchType:      def.MatchType,
+				RespectParams:  def.RespectParams,
+				TransferParams: def.TransferParams,
+				ParamPolicy:    def.ParamPolicy,
+				Dimension:      dimension,
+				Stale:          def.Stale,
+			}
+		}
+
+		ret[dimension] = expanded
+	}
+
+	return ret
+}
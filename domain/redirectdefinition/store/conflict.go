@@ -0,0 +1,45 @@
+package redirectstore
+
+// ConflictReason explains why an automatic definition was rejected
+type ConflictReason string
+
+const (
+	// ConflictReasonManual the source is taken by a manual definition
+	ConflictReasonManual ConflictReason = "manual"
+	// ConflictReasonLocked the source is taken by a locked definition
+	ConflictReasonLocked ConflictReason = "locked"
+)
+
+// RedirectConflict reports an automatic definition which was not written because an existing
+// definition with the same source takes precedence
+type RedirectConflict struct {
+	Dimension      Dimension      `json:"dimension"`
+	Source         RedirectSource `json:"source"`
+	ExistingID     EntityID       `json:"existingId"`
+	ExistingTarget RedirectTarget `json:"existingTarget"`
+	RejectedTarget RedirectTarget `json:"rejectedTarget"`
+	Reason         ConflictReason `json:"reason"`
+}
+
+// IsProtected returns true if automatic consolidation and flattening must not change the definition,
+// which applies to manual and locked definitions
+func (r *RedirectDefinition) IsProtected() bool {
+	return r.Locked || r.RedirectionType == RedirectionTypeManual
+}
+
+// NewRedirectConflict returns the conflict of an automatic definition with the protected existing one
+func NewRedirectConflict(existing, rejected *RedirectDefinition) *RedirectConflict {
+	reason := ConflictReasonManual
+	if existing.Locked {
+		reason = ConflictReasonLocked
+	}
+
+	return &RedirectConflict{
+		Dimension:      existing.Dimension,
+		Source:         existing.Source,
+		ExistingID:     existing.ID,
+		ExistingTarget: existing.Target,
+		RejectedTarget: rejected.Target,
+		Reason:         reason,
+	}
+}
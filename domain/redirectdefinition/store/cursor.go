@@ -0,0 +1,61 @@
+package redirectstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// PageCursor points behind the last result of a page for keyset pagination.
+// It is keyed on the sort field plus the id as tie-breaker.
+type PageCursor struct {
+	Field     SortField `json:"f"`
+	Direction Direction `json:"d"`
+	Value     *string   `json:"v,omitempty"` // Value of the sort field, nil for missing values
+	ID        EntityID  `json:"id"`
+}
+
+// NewPageCursor returns the cursor behind the given definition,
+// missing is true if the sort field of the stored document is null or not set
+func NewPageCursor(def *RedirectDefinition, sort Sort, missing bool) PageCursor {
+	c := PageCursor{
+		Field:     sort.Field,
+		Direction: sort.Direction,
+		ID:        def.ID,
+	}
+
+	if !missing {
+		value := def.SortValue(sort.Field)
+		c.Value = &value
+	}
+
+	return c
+}
+
+// DecodePageCursor parses an opaque cursor string
+func DecodePageCursor(s string) (PageCursor, error) {
+	var c PageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == "" {
+		return c, errors.New("invalid cursor")
+	}
+
+	return c, nil
+}
+
+// Encode returns the opaque cursor string
+func (c PageCursor) Encode() string {
+	data, _ := json.Marshal(c) //nolint:errchkjson // only strings
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Matches returns true if the cursor was created for the given sort
+func (c PageCursor) Matches(sort Sort) bool {
+	return c.Field == sort.Field && c.Direction.GetSortValue() == sort.Direction.GetSortValue()
+}
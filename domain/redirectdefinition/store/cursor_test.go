@@ -0,0 +1,34 @@
+package redirectstore_test
+
+import (
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageCursor(t *testing.T) {
+	t.Parallel()
+
+	sort := storex.Sort{Field: storex.SortFieldLastUpdatedBy, Direction: storex.DirectionDescending}
+	c := storex.NewPageCursor(&storex.RedirectDefinition{ID: "1", LastUpdatedBy: "editor"}, sort, false)
+
+	decoded, err := storex.DecodePageCursor(c.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, c, decoded)
+	require.NotNil(t, decoded.Value)
+	assert.Equal(t, "editor", *decoded.Value)
+	assert.True(t, decoded.Matches(sort))
+	assert.False(t, decoded.Matches(storex.Sort{Field: storex.SortFieldLastUpdatedBy}))
+
+	// empty and missing values are kept apart
+	for _, missing := range []bool{false, true} {
+		decoded, err = storex.DecodePageCursor(storex.NewPageCursor(&storex.RedirectDefinition{ID: "1"}, sort, missing).Encode())
+		require.NoError(t, err)
+		assert.Equal(t, missing, decoded.Value == nil)
+	}
+
+	_, err = storex.DecodePageCursor("not-a-cursor")
+	require.Error(t, err)
+}
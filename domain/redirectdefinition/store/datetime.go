@@ -1,7 +1,10 @@
 package redirectstore
 
 import (
+	"fmt"
 	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
 // DateTimeLayout in the ISO8601 format with millisecond precision
@@ -11,6 +14,13 @@ const (
 	DateTimeLayoutEN = "02/01/2006 03:04 PM"
 )
 
+// dateTimeParseLayouts are accepted when parsing a date time, e.g. from search filters
+var dateTimeParseLayouts = []string{
+	DateTimeLayout,
+	time.RFC3339Nano,
+	time.DateOnly,
+}
+
 // DateTime type
 type DateTime string
 
@@ -21,7 +31,21 @@ func NewDateTime(t time.Time) DateTime {
 
 // Time returns the date time as Time
 func (d DateTime) Time() (time.Time, error) {
-	return time.Parse(DateTimeLayout, string(d))
+	var (
+		t   time.Time
+		err error
+	)
+
+	for _, layout := range dateTimeParseLayouts {
+		if t, err = time.Parse(layout, string(d)); err == nil {
+			return t, nil
+		}
+	}
+
+	// report the error of the canonical layout
+	_, err = time.Parse(DateTimeLayout, string(d))
+
+	return t, err
 }
 
 // MustTime returns the date time as Time and panics on failure
@@ -38,3 +62,37 @@ func (d DateTime) MustTime() time.Time {
 func (d DateTime) String() string {
 	return string(d)
 }
+
+// MarshalBSONValue stores the date time as a BSON date so that range queries work
+func (d DateTime) MarshalBSONValue() (byte, []byte, error) {
+	if d == "" {
+		return byte(bson.TypeNull), nil, nil
+	}
+
+	t, err := d.Time()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	typ, data, err := bson.MarshalValue(bson.NewDateTimeFromTime(t))
+
+	return byte(typ), data, err
+}
+
+// UnmarshalBSONValue reads BSON dates and strings stored by previous versions
+func (d *DateTime) UnmarshalBSONValue(typ byte, data []byte) error {
+	value := bson.RawValue{Type: bson.Type(typ), Value: data}
+
+	switch value.Type {
+	case bson.TypeDateTime:
+		*d = NewDateTime(value.Time().UTC())
+	case bson.TypeString:
+		*d = DateTime(value.StringValue())
+	case bson.TypeNull, bson.TypeUndefined:
+		*d = ""
+	default:
+		return fmt.Errorf("cannot decode BSON %s into DateTime", value.Type)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package redirectstore_test
+
+import (
+	"testing"
+	"time"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestDateTime_BSON(t *testing.T) {
+	t.Parallel()
+
+	updated := storex.NewDateTime(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC))
+
+	data, err := bson.Marshal(storex.RedirectDefinition{Source: "/a", Updated: updated})
+	require.NoError(t, err)
+	assert.Equal(t, bson.TypeDateTime, bson.Raw(data).Lookup("updated").Type)
+
+	var def storex.RedirectDefinition
+	require.NoError(t, bson.Unmarshal(data, &def))
+	assert.Equal(t, updated, def.Updated)
+
+	// legacy string values
+	data, err = bson.Marshal(bson.M{"updated": "2024-05-06T07:08:09.000Z"})
+	require.NoError(t, err)
+	require.NoError(t, bson.Unmarshal(data, &def))
+	assert.Equal(t, updated, def.Updated)
+
+	// empty values
+	data, err = bson.Marshal(storex.RedirectDefinition{Source: "/a"})
+	require.NoError(t, err)
+	assert.Equal(t, bson.TypeNull, bson.Raw(data).Lookup("updated").Type)
+	require.NoError(t, bson.Unmarshal(data, &def))
+	assert.Empty(t, def.Updated)
+}
+
+func TestSearchFilter_UpdatedRange(t *testing.T) {
+	t.Parallel()
+
+	from, to, err := storex.SearchFilter{UpdatedFrom: "2024-05-01", UpdatedTo: "2024-05-31T23:59:59Z"}.UpdatedRange()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 5, 31, 23, 59, 59, 0, time.UTC), to)
+
+	// a date includes the whole day
+	from, to, err = storex.SearchFilter{UpdatedFrom: "2024-05-31", UpdatedTo: "2024-05-31"}.UpdatedRange()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 5, 31, 23, 59, 59, int(999*time.Millisecond), time.UTC), to)
+
+	_, _, err = storex.SearchFilter{UpdatedFrom: "yesterday"}.UpdatedRange()
+	require.Error(t, err)
+
+	_, _, err = storex.SearchFilter{UpdatedFrom: "2024-05-31", UpdatedTo: "2024-05-01"}.UpdatedRange()
+	require.Error(t, err)
+}
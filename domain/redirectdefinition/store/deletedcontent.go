@@ -0,0 +1,34 @@
+package redirectstore
+
+// DeletedContentStrategy defines what happens to the URI of a node missing from the new content tree
+type DeletedContentStrategy string
+
+const (
+	// DeletedContentStrategyNone creates no definition, the URI returns 404 (default)
+	DeletedContentStrategyNone DeletedContentStrategy = ""
+	// DeletedContentStrategyAncestor redirects to the nearest ancestor which still exists
+	DeletedContentStrategyAncestor DeletedContentStrategy = "ancestor"
+	// DeletedContentStrategyFallback redirects to the configured fallback target
+	DeletedContentStrategyFallback DeletedContentStrategy = "fallback"
+	// DeletedContentStrategyGone answers the URI with 410
+	DeletedContentStrategyGone DeletedContentStrategy = "gone"
+)
+
+// DeletedContentPolicy configures the handling of deleted content
+type DeletedContentPolicy struct {
+	Strategy DeletedContentStrategy
+	// FallbackTarget for DeletedContentStrategyFallback, also used by DeletedContentStrategyAncestor
+	// if no ancestor exists anymore. Without a fallback target these cases answer with 410.
+	FallbackTarget RedirectTarget
+	// Code of the redirects, defaults to 301
+	Code RedirectCode
+}
+
+// RedirectCode returns the configured code or the default
+func (p DeletedContentPolicy) RedirectCode() RedirectCode {
+	if p.Code == 0 {
+		return RedirectCodePermanent
+	}
+
+	return p.Code
+}
@@ -0,0 +1,56 @@
+package redirectstore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DeleteStrategy defines what happens to automatic redirects which became obsolete during consolidation
+type DeleteStrategy string
+
+const (
+	// DeleteStrategySoft marks obsolete redirects as stale (default)
+	DeleteStrategySoft DeleteStrategy = ""
+	// DeleteStrategyHard deletes obsolete redirects
+	DeleteStrategyHard DeleteStrategy = "hard"
+	// DeleteStrategyDelayed marks obsolete redirects as stale and purges them after the retention period
+	DeleteStrategyDelayed DeleteStrategy = "delayed"
+)
+
+// DeletePolicy configures the handling of obsolete automatic redirects
+type DeletePolicy struct {
+	Strategy DeleteStrategy
+	// RetentionDays before stale obsolete redirects are purged, only used by DeleteStrategyDelayed
+	RetentionDays int
+}
+
+// Validate returns an error for unknown strategies or a missing retention period
+func (p DeletePolicy) Validate() error {
+	switch p.Strategy {
+	case DeleteStrategySoft, DeleteStrategyHard:
+		return nil
+	case DeleteStrategyDelayed:
+		if p.RetentionDays <= 0 {
+			return errors.New("delayed delete strategy requires a positive retention period")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("invalid delete strategy '%s'", p.Strategy)
+	}
+}
+
+// Cutoff returns the time before which obsolete redirects are purged
+func (p DeletePolicy) Cutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.RetentionDays)
+}
+
+// PurgeRecord documents the redirects removed by a purge
+type PurgeRecord struct {
+	ID          EntityID              `json:"id" bson:"id"`
+	Time        DateTime              `json:"time" bson:"time"`
+	Cutoff      DateTime              `json:"cutoff" bson:"cutoff"`
+	DryRun      bool                  `json:"dryRun" bson:"dryRun"`
+	Definitions []*RedirectDefinition `json:"definitions" bson:"definitions"`
+}
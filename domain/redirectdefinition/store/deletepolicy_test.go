@@ -0,0 +1,30 @@
+package redirectstore_test
+
+import (
+	"testing"
+	"time"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeletePolicy_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, storex.DeletePolicy{}.Validate())
+	assert.NoError(t, storex.DeletePolicy{Strategy: storex.DeleteStrategyHard}.Validate())
+	assert.NoError(t, storex.DeletePolicy{Strategy: storex.DeleteStrategyDelayed, RetentionDays: 30}.Validate())
+	assert.Error(t, storex.DeletePolicy{Strategy: storex.DeleteStrategyDelayed}.Validate())
+	assert.Error(t, storex.DeletePolicy{Strategy: "never"}.Validate())
+}
+
+func TestDeletePolicy_Cutoff(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t,
+		time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		storex.DeletePolicy{Strategy: storex.DeleteStrategyDelayed, RetentionDays: 30}.Cutoff(now),
+	)
+}
@@ -0,0 +1,97 @@
+package redirectstore
+
+import (
+	"reflect"
+	"sort"
+)
+
+// DefinitionsDiff lists the changes from the current to the target definitions,
+// definitions are matched by dimension and source
+type DefinitionsDiff struct {
+	Added   []*RedirectDefinition `json:"added"`   // Only in the target definitions
+	Removed []*RedirectDefinition `json:"removed"` // Only in the current definitions
+	Changed []*DefinitionChange   `json:"changed"`
+}
+
+// DefinitionChange of a definition matched by dimension and source
+type DefinitionChange struct {
+	Current *RedirectDefinition `json:"current"`
+	Target  *RedirectDefinition `json:"target"`
+}
+
+// DefinitionsEqualFn decides whether a matched definition changed
+type DefinitionsEqualFn func(current, target *RedirectDefinition) bool
+
+// DefinitionsEqual compares all fields of the definitions
+func DefinitionsEqual(current, target *RedirectDefinition) bool {
+	return reflect.DeepEqual(current, target)
+}
+
+// DefinitionsEquivalent compares the definitions without the fields specific to an environment:
+// ID, batch, obsolete and last update
+func DefinitionsEquivalent(current, target *RedirectDefinition) bool {
+	a, b := *current, *target
+	for _, def := range []*RedirectDefinition{&a, &b} {
+		def.ID, def.Batch, def.Obsolete, def.Updated, def.LastUpdatedBy = "", "", "", "", ""
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// Empty returns true if there are no changes
+func (d *DefinitionsDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffDefinitions compares the current with the target definitions, the results are sorted by dimension and source
+func DiffDefinitions(current, target []*RedirectDefinition, equal DefinitionsEqualFn) *DefinitionsDiff {
+	type key struct {
+		dimension Dimension
+		source    RedirectSource
+	}
+
+	currentByKey := make(map[key]*RedirectDefinition, len(current))
+	for _, def := range current {
+		currentByKey[key{def.Dimension, def.Source}] = def
+	}
+
+	diff := &DefinitionsDiff{
+		Added:   []*RedirectDefinition{},
+		Removed: []*RedirectDefinition{},
+		Changed: []*DefinitionChange{},
+	}
+	matched := make(map[key]struct{}, len(target))
+
+	for _, def := range target {
+		k := key{def.Dimension, def.Source}
+		matched[k] = struct{}{}
+
+		existing, ok := currentByKey[k]
+
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, def)
+		case !equal(existing, def):
+			diff.Changed = append(diff.Changed, &DefinitionChange{Current: existing, Target: def})
+		}
+	}
+
+	for _, def := range current {
+		if _, ok := matched[key{def.Dimension, def.Source}]; !ok {
+			diff.Removed = append(diff.Removed, def)
+		}
+	}
+
+	less := func(a, b *RedirectDefinition) bool {
+		if a.Dimension != b.Dimension {
+			return a.Dimension < b.Dimension
+		}
+
+		return a.Source < b.Source
+	}
+	sort.Slice(diff.Added, func(i, j int) bool { return less(diff.Added[i], diff.Added[j]) })
+	sort.Slice(diff.Removed, func(i, j int) bool { return less(diff.Removed[i], diff.Removed[j]) })
+	sort.Slice(diff.Changed, func(i, j int) bool { return less(diff.Changed[i].Target, diff.Changed[j].Target) })
+
+	return diff
+}
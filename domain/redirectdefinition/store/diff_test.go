@@ -0,0 +1,37 @@
+package redirectstore_test
+
+import (
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDefinitions(t *testing.T) {
+	t.Parallel()
+
+	current := []*storex.RedirectDefinition{
+		{ID: "1", Dimension: "de", Source: "/a", Target: "/b"},
+		{ID: "2", Dimension: "de", Source: "/c", Target: "/d"},
+		{ID: "3", Dimension: "fr", Source: "/a", Target: "/b"},
+	}
+	target := []*storex.RedirectDefinition{
+		{ID: "1", Dimension: "de", Source: "/a", Target: "/b"},
+		{ID: "2", Dimension: "de", Source: "/c", Target: "/x"},
+		{ID: "4", Dimension: "de", Source: "/e", Target: "/f"},
+	}
+
+	diff := storex.DiffDefinitions(current, target, storex.DefinitionsEqual)
+
+	require.Len(t, diff.Added, 1)
+	assert.Equal(t, storex.EntityID("4"), diff.Added[0].ID)
+	require.Len(t, diff.Removed, 1)
+	assert.Equal(t, storex.EntityID("3"), diff.Removed[0].ID)
+	require.Len(t, diff.Changed, 1)
+	assert.Equal(t, storex.RedirectTarget("/d"), diff.Changed[0].Current.Target)
+	assert.Equal(t, storex.RedirectTarget("/x"), diff.Changed[0].Target.Target)
+	assert.False(t, diff.Empty())
+
+	assert.True(t, storex.DiffDefinitions(current, current, storex.DefinitionsEqual).Empty())
+}
@@ -1,5 +1,12 @@
 package redirectstore
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
 type RedirectionType string
 type ActiveStateType string
 
@@ -44,3 +51,63 @@ func (r RedirectionType) ToFilter() (any, bool) {
 		return nil, false
 	}
 }
+
+// SearchFilter combines the filters of a search, empty values are not applied
+type SearchFilter struct {
+	Source        RedirectSource
+	Target        RedirectTarget
+	SearchMode    SearchMode // applies to source and target
+	Dimension     Dimension
+	RedirectType  RedirectionType
+	ActiveState   ActiveStateType
+	ContentID     string
+	LastUpdatedBy string
+	Code          RedirectCode
+	UpdatedFrom   DateTime // inclusive
+	UpdatedTo     DateTime // inclusive
+	NeedsReview   bool     // only definitions marked for review
+}
+
+// UpdatedRange returns the parsed bounds of the updated range, zero times are not applied.
+// An updated to date without time is the end of that day.
+func (f SearchFilter) UpdatedRange() (from, to time.Time, err error) {
+	if f.UpdatedFrom != "" {
+		if from, err = f.UpdatedFrom.Time(); err != nil {
+			return from, to, fmt.Errorf("invalid updated from '%s': %w", f.UpdatedFrom, err)
+		}
+	}
+
+	if f.UpdatedTo != "" {
+		if to, err = f.UpdatedTo.Time(); err != nil {
+			return from, to, fmt.Errorf("invalid updated to '%s': %w", f.UpdatedTo, err)
+		}
+
+		// a date includes the whole day
+		if _, dateErr := time.Parse(time.DateOnly, string(f.UpdatedTo)); dateErr == nil {
+			to = to.AddDate(0, 0, 1).Add(-time.Millisecond)
+		}
+	}
+
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return from, to, fmt.Errorf("invalid updated range: '%s' is before '%s'", f.UpdatedTo, f.UpdatedFrom)
+	}
+
+	return from, to, nil
+}
+
+// RedirectsFilter scopes the redirects loaded by a gateway, an empty filter loads all active redirects
+type RedirectsFilter struct {
+	Dimensions   []Dimension `json:"dimensions,omitempty"`
+	Site         Site        `json:"site,omitempty"`         // All dimensions of the site, i.e. "<site>-<locale>"
+	IncludeStale bool        `json:"includeStale,omitempty"` // Stale redirects are loaded but never answered by the provider
+}
+
+// Matches returns true if the dimension is in the scope of the filter
+func (f RedirectsFilter) Matches(dimension Dimension) bool {
+	if len(f.Dimensions) == 0 && f.Site == "" {
+		return true
+	}
+
+	return slices.Contains(f.Dimensions, dimension) ||
+		(f.Site != "" && strings.HasPrefix(string(dimension), string(f.Site)+"-"))
+}
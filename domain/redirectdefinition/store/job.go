@@ -0,0 +1,105 @@
+package redirectstore
+
+import (
+	"slices"
+	"time"
+)
+
+// JobStatus of a queued contentserver export
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job processes a contentserver export asynchronously, dimension by dimension
+type Job struct {
+	ID          EntityID                    `json:"id" bson:"id"`
+	Status      JobStatus                   `json:"status" bson:"status"`
+	Attempts    int                         `json:"attempts" bson:"attempts"`
+	Worker      string                      `json:"worker,omitempty" bson:"worker"`
+	Claim       EntityID                    `json:"-" bson:"claim"` // Token of the current claim, a job claimed again gets a new one
+	Created     DateTime                    `json:"created" bson:"created"`
+	Started     DateTime                    `json:"started,omitempty" bson:"started"`
+	Finished    DateTime                    `json:"finished,omitempty" bson:"finished"`
+	NextAttempt DateTime                    `json:"nextAttempt,omitempty" bson:"nextAttempt"` // Queued jobs are claimed from then on
+	LockedUntil DateTime                    `json:"-" bson:"lockedUntil"`                     // Running jobs of lost workers are claimed again after
+	Progress    JobProgress                 `json:"progress" bson:"progress"`
+	Dimensions  map[Dimension]*JobDimension `json:"dimensions" bson:"dimensions"`
+	Error       string                      `json:"error,omitempty" bson:"error"`
+	Payload     []byte                      `json:"-" bson:"payload"` // Compressed old and new state of the export
+}
+
+// JobProgress counts the processed dimensions
+type JobProgress struct {
+	Total int `json:"total" bson:"total"`
+	Done  int `json:"done" bson:"done"`
+}
+
+// JobDimension holds the result of one dimension
+type JobDimension struct {
+	Status    JobStatus `json:"status" bson:"status"`
+	Created   int       `json:"created" bson:"created"`
+	Updated   int       `json:"updated" bson:"updated"`
+	Staled    int       `json:"staled" bson:"staled"`
+	Deleted   int       `json:"deleted" bson:"deleted"`
+	Conflicts int       `json:"conflicts" bson:"conflicts"`
+	Batch     EntityID  `json:"batch,omitempty" bson:"batch"` // Batch to roll back the dimension with
+	Error     string    `json:"error,omitempty" bson:"error"`
+}
+
+// JobPolicy configures the processing of jobs
+type JobPolicy struct {
+	// MaxAttempts before a job fails. Defaults to 3.
+	MaxAttempts int
+	// RetryDelay is multiplied by the attempts before a failed job is retried. Defaults to 1 minute.
+	RetryDelay time.Duration
+	// LeaseTTL after which a running job of a lost worker is claimed again. Defaults to 10 minutes.
+	LeaseTTL time.Duration
+}
+
+// Attempts returns the configured max attempts or the default
+func (p JobPolicy) Attempts() int {
+	if p.MaxAttempts <= 0 {
+		return 3
+	}
+
+	return p.MaxAttempts
+}
+
+// Delay returns the delay before the next attempt
+func (p JobPolicy) Delay(attempts int) time.Duration {
+	delay := p.RetryDelay
+	if delay <= 0 {
+		delay = time.Minute
+	}
+
+	return time.Duration(attempts) * delay
+}
+
+// TTL returns the configured lease TTL or the default
+func (p JobPolicy) TTL() time.Duration {
+	if p.LeaseTTL <= 0 {
+		return 10 * time.Minute
+	}
+
+	return p.LeaseTTL
+}
+
+// Pending returns the dimensions which did not succeed yet
+func (j *Job) Pending() []Dimension {
+	var pending []Dimension
+
+	for dimension, result := range j.Dimensions {
+		if result.Status != JobStatusSucceeded {
+			pending = append(pending, dimension)
+		}
+	}
+
+	slices.Sort(pending)
+
+	return pending
+}
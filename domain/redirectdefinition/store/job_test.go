@@ -0,0 +1,43 @@
+package redirectstore_test
+
+import (
+	"testing"
+	"time"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobPolicy_Defaults(t *testing.T) {
+	t.Parallel()
+
+	policy := storex.JobPolicy{}
+
+	assert.Equal(t, 3, policy.Attempts())
+	assert.Equal(t, 2*time.Minute, policy.Delay(2))
+	assert.Equal(t, 10*time.Minute, policy.TTL())
+}
+
+func TestJobPolicy_Configured(t *testing.T) {
+	t.Parallel()
+
+	policy := storex.JobPolicy{MaxAttempts: 5, RetryDelay: 10 * time.Second, LeaseTTL: time.Minute}
+
+	assert.Equal(t, 5, policy.Attempts())
+	assert.Equal(t, 30*time.Second, policy.Delay(3))
+	assert.Equal(t, time.Minute, policy.TTL())
+}
+
+func TestJob_Pending(t *testing.T) {
+	t.Parallel()
+
+	job := &storex.Job{
+		Dimensions: map[storex.Dimension]*storex.JobDimension{
+			"de-ch": {Status: storex.JobStatusFailed},
+			"de-de": {Status: storex.JobStatusSucceeded},
+			"at-de": {Status: storex.JobStatusQueued},
+		},
+	}
+
+	assert.Equal(t, []storex.Dimension{"at-de", "de-ch"}, job.Pending())
+}
@@ -0,0 +1,70 @@
+package redirectstore
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrLeaseHeld is returned if the lease is held by another owner
+	ErrLeaseHeld = errors.New("lease is held by another owner")
+	// ErrLeaseLost is returned if the lease expired or was taken over while it was held
+	ErrLeaseLost = errors.New("lease was lost")
+)
+
+// Lease is a lock held by one owner until it is released or expires
+type Lease struct {
+	Name     string   `json:"name" bson:"name"`
+	Owner    string   `json:"owner" bson:"owner"`
+	Token    EntityID `json:"token" bson:"token"`
+	Acquired DateTime `json:"acquired" bson:"acquired"`
+	Expires  DateTime `json:"expires" bson:"expires"`
+}
+
+// LockMode defines what happens if the lock is held by another owner
+type LockMode string
+
+const (
+	// LockModeWait waits for the lock until the wait timeout (default)
+	LockModeWait LockMode = ""
+	// LockModeFail fails immediately with ErrLeaseHeld
+	LockModeFail LockMode = "fail"
+)
+
+// LockPolicy configures the lock around the automatic pipeline and full flattening
+type LockPolicy struct {
+	Mode LockMode
+	// TTL of the lease, it is renewed while held. Defaults to 30 seconds.
+	TTL time.Duration
+	// WaitTimeout for LockModeWait. Defaults to 5 minutes.
+	WaitTimeout time.Duration
+	// RetryInterval for LockModeWait. Defaults to 1 second.
+	RetryInterval time.Duration
+}
+
+// LeaseTTL returns the configured TTL or the default
+func (p LockPolicy) LeaseTTL() time.Duration {
+	if p.TTL <= 0 {
+		return 30 * time.Second
+	}
+
+	return p.TTL
+}
+
+// Timeout returns the configured wait timeout or the default
+func (p LockPolicy) Timeout() time.Duration {
+	if p.WaitTimeout <= 0 {
+		return 5 * time.Minute
+	}
+
+	return p.WaitTimeout
+}
+
+// Interval returns the configured retry interval or the default
+func (p LockPolicy) Interval() time.Duration {
+	if p.RetryInterval <= 0 {
+		return time.Second
+	}
+
+	return p.RetryInterval
+}
@@ -0,0 +1,29 @@
+package redirectstore
+
+// IntendedTarget returns the target the redirect was created with, before flattening changed it
+func (r *RedirectDefinition) IntendedTarget() RedirectTarget {
+	if r.OriginalTarget != "" {
+		return r.OriginalTarget
+	}
+
+	return r.Target
+}
+
+// SetEffectiveTarget sets the target resolved by flattening and keeps the intended target,
+// the original target is only stored while it differs from the effective one
+func (r *RedirectDefinition) SetEffectiveTarget(target RedirectTarget) {
+	intended := r.IntendedTarget()
+
+	r.Target = target
+	r.OriginalTarget = ""
+
+	if target != intended {
+		r.OriginalTarget = intended
+	}
+}
+
+// SetIntendedTarget replaces the intended target, e.g. by an editor or a content move
+func (r *RedirectDefinition) SetIntendedTarget(target RedirectTarget) {
+	r.Target = target
+	r.OriginalTarget = ""
+}
@@ -0,0 +1,31 @@
+package redirectstore_test
+
+import (
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectDefinition_SetEffectiveTarget(t *testing.T) {
+	t.Parallel()
+
+	def := &storex.RedirectDefinition{Source: "/a", Target: "/b"}
+
+	def.SetEffectiveTarget("/c")
+	assert.Equal(t, storex.RedirectTarget("/c"), def.Target)
+	assert.Equal(t, storex.RedirectTarget("/b"), def.OriginalTarget)
+	assert.Equal(t, storex.RedirectTarget("/b"), def.IntendedTarget())
+
+	def.SetEffectiveTarget("/d")
+	assert.Equal(t, storex.RedirectTarget("/b"), def.OriginalTarget, "the intended target is kept")
+
+	def.SetEffectiveTarget("/b")
+	assert.Equal(t, storex.RedirectTarget("/b"), def.Target)
+	assert.Empty(t, def.OriginalTarget, "unflattened redirects have no original target")
+
+	def.SetEffectiveTarget("/c")
+	def.SetIntendedTarget("/e")
+	assert.Equal(t, storex.RedirectTarget("/e"), def.Target)
+	assert.Empty(t, def.OriginalTarget)
+}
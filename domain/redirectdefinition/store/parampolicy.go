@@ -0,0 +1,113 @@
+package redirectstore
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"path"
+	"slices"
+)
+
+// ParamMergeStrategy defines which value wins if a parameter is set in the request and in the target
+type ParamMergeStrategy string
+
+const (
+	ParamMergeStrategyTargetWins  ParamMergeStrategy = "targetWins"
+	ParamMergeStrategyRequestWins ParamMergeStrategy = "requestWins"
+)
+
+func (s ParamMergeStrategy) Valid() bool {
+	switch s {
+	case "", ParamMergeStrategyTargetWins, ParamMergeStrategyRequestWins:
+		return true
+	default:
+		return false
+	}
+}
+
+// ParamPolicy defines how query parameters are matched and transferred
+type ParamPolicy struct {
+	// Allow globs of request parameter keys to transfer, empty allows all keys
+	Allow []string `json:"allow,omitempty" bson:"allow,omitempty"`
+	// Deny globs of request parameter keys to drop, applied after Allow
+	Deny []string `json:"deny,omitempty" bson:"deny,omitempty"`
+	// MergeStrategy for parameters set in the request and in the target, defaults to targetWins
+	MergeStrategy ParamMergeStrategy `json:"mergeStrategy,omitempty" bson:"mergeStrategy,omitempty"`
+	// MatchKeys limits the request parameters used for matching a source with RespectParams
+	MatchKeys []string `json:"matchKeys,omitempty" bson:"matchKeys,omitempty"`
+}
+
+// Validate checks the globs and the merge strategy
+func (p ParamPolicy) Validate() error {
+	for _, glob := range slices.Concat(p.Allow, p.Deny) {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid parameter glob '%s': %w", glob, err)
+		}
+	}
+
+	if !p.MergeStrategy.Valid() {
+		return fmt.Errorf("invalid parameter merge strategy '%s'", p.MergeStrategy)
+	}
+
+	return nil
+}
+
+// Transfers returns true if the request parameter with the given key is transferred
+func (p ParamPolicy) Transfers(key string) bool {
+	if len(p.Allow) > 0 && !matchGlobs(key, p.Allow) {
+		return false
+	}
+
+	return !matchGlobs(key, p.Deny)
+}
+
+// Filter returns the values which are transferred
+func (p ParamPolicy) Filter(values url.Values) url.Values {
+	filtered := url.Values{}
+
+	for key, value := range values {
+		if p.Transfers(key) {
+			filtered[key] = value
+		}
+	}
+
+	return filtered
+}
+
+// Merge merges the request parameters into the target parameters
+func (p ParamPolicy) Merge(request, target url.Values) url.Values {
+	merged := url.Values{}
+	base, override := p.Filter(request), target
+
+	if p.MergeStrategy == ParamMergeStrategyRequestWins {
+		base, override = override, base
+	}
+
+	maps.Copy(merged, base)
+	maps.Copy(merged, override)
+
+	return merged
+}
+
+// MatchQuery returns the normalized query of the values limited to MatchKeys
+func (p ParamPolicy) MatchQuery(values url.Values) string {
+	matched := url.Values{}
+
+	for _, key := range p.MatchKeys {
+		if value, ok := values[key]; ok {
+			matched[key] = value
+		}
+	}
+
+	return matched.Encode()
+}
+
+func matchGlobs(key string, globs []string) bool {
+	for _, glob := range globs {
+		if matched, _ := path.Match(glob, key); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package redirectstore
+
+import (
+	"strings"
+)
+
+// MatchType defines how the source of a definition is matched
+type MatchType string
+
+const (
+	// MatchTypeExact matches the source only (default)
+	MatchTypeExact MatchType = ""
+	// MatchTypePrefix matches the source and all paths below it, the remainder is appended to the target
+	MatchTypePrefix MatchType = "prefix"
+)
+
+func (m MatchType) Valid() bool {
+	return m == MatchTypeExact || m == MatchTypePrefix
+}
+
+// IsPrefix returns true if the definition matches all paths below its source
+func (d *RedirectDefinition) IsPrefix() bool {
+	return d.MatchType == MatchTypePrefix
+}
+
+// PrefixTarget returns the target for a path below the source of a prefix definition,
+// e.g. /old/a/b for the definition /old → /new results in /new/a/b
+func (d *RedirectDefinition) PrefixTarget(path string) (RedirectTarget, bool) {
+	if !d.IsPrefix() || !IsBelow(path, string(d.Source)) {
+		return "", false
+	}
+
+	rest := strings.TrimPrefix(path, string(d.Source))
+	if rest == "" {
+		return d.Target, true
+	}
+
+	targetPath, query, hasQuery := strings.Cut(string(d.Target), "?")
+	target := strings.TrimSuffix(targetPath, "/") + rest
+
+	if hasQuery {
+		target += "?" + query
+	}
+
+	return RedirectTarget(target), true
+}
+
+// ParentPaths returns the paths above the given path, longest first and without the root,
+// e.g. /a/b for /a/b/c. These are the candidates for prefix definitions.
+func ParentPaths(path string) []string {
+	var parents []string
+
+	for i := strings.LastIndex(path, "/"); i > 0; i = strings.LastIndex(path, "/") {
+		path = path[:i]
+		parents = append(parents, path)
+	}
+
+	return parents
+}
+
+// IsBelow returns true if the path is the prefix itself or below it
+func IsBelow(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// ResolvePrefix finds the longest prefix definition for the path below its source and returns it with the
+// resulting target. The lookup returns the definition for a source.
+func ResolvePrefix(path string, lookup func(source RedirectSource) *RedirectDefinition) (*RedirectDefinition, RedirectTarget) {
+	for _, parent := range ParentPaths(path) {
+		definition := lookup(RedirectSource(parent))
+		if definition == nil || !definition.IsPrefix() {
+			continue
+		}
+
+		if target, ok := definition.PrefixTarget(path); ok {
+			return definition, target
+		}
+	}
+
+	return nil, ""
+}
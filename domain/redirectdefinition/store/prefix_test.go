@@ -0,0 +1,55 @@
+package redirectstore_test
+
+import (
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectDefinition_PrefixTarget(t *testing.T) {
+	t.Parallel()
+
+	def := &storex.RedirectDefinition{Source: "/old", Target: "/new?ref=move", MatchType: storex.MatchTypePrefix}
+
+	for path, expected := range map[string]storex.RedirectTarget{
+		"/old":     "/new?ref=move",
+		"/old/a/b": "/new/a/b?ref=move",
+		"/older":   "",
+		"/o":       "",
+	} {
+		target, ok := def.PrefixTarget(path)
+		assert.Equal(t, expected != "", ok, path)
+		assert.Equal(t, expected, target, path)
+	}
+
+	// no protocol-relative targets below the root
+	def = &storex.RedirectDefinition{Source: "/old", Target: "/", MatchType: storex.MatchTypePrefix}
+	target, _ := def.PrefixTarget("/old/a")
+	assert.Equal(t, storex.RedirectTarget("/a"), target)
+}
+
+func TestResolvePrefix(t *testing.T) {
+	t.Parallel()
+
+	definitions := map[storex.RedirectSource]*storex.RedirectDefinition{
+		"/a":   {Source: "/a", Target: "/x", MatchType: storex.MatchTypePrefix},
+		"/a/b": {Source: "/a/b", Target: "/y", MatchType: storex.MatchTypePrefix},
+		"/c":   {Source: "/c", Target: "/z"},
+	}
+	lookup := func(source storex.RedirectSource) *storex.RedirectDefinition {
+		return definitions[source]
+	}
+
+	assert.Equal(t, []string{"/a/b/c", "/a/b", "/a"}, storex.ParentPaths("/a/b/c/d"))
+
+	definition, target := storex.ResolvePrefix("/a/b/c", lookup)
+	assert.Equal(t, definitions["/a/b"], definition)
+	assert.Equal(t, storex.RedirectTarget("/y/c"), target)
+
+	_, target = storex.ResolvePrefix("/a/c", lookup)
+	assert.Equal(t, storex.RedirectTarget("/x/c"), target)
+
+	definition, _ = storex.ResolvePrefix("/c/d", lookup)
+	assert.Nil(t, definition, "exact definitions do not match below their source")
+}
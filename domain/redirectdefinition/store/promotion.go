@@ -0,0 +1,160 @@
+package redirectstore
+
+import (
+	"fmt"
+)
+
+// PromoteConflictStrategy decides what happens if the live definition changed since the diff
+type PromoteConflictStrategy string
+
+const (
+	// PromoteConflictSkip keeps the live definition and reports the conflict
+	PromoteConflictSkip PromoteConflictStrategy = ""
+	// PromoteConflictOverwrite promotes the change anyway and reports the conflict
+	PromoteConflictOverwrite PromoteConflictStrategy = "overwrite"
+)
+
+func (s PromoteConflictStrategy) Valid() bool {
+	switch s {
+	case PromoteConflictSkip, PromoteConflictOverwrite:
+		return true
+	default:
+		return false
+	}
+}
+
+// PromotionConflictReason describes how the live definition differs from the diff
+type PromotionConflictReason string
+
+const (
+	// PromotionConflictExists if the source of an added definition is taken
+	PromotionConflictExists PromotionConflictReason = "exists"
+	// PromotionConflictModified if the live definition was changed since the diff
+	PromotionConflictModified PromotionConflictReason = "modified"
+	// PromotionConflictDeleted if the live definition was deleted since the diff
+	PromotionConflictDeleted PromotionConflictReason = "deleted"
+)
+
+// PromotionConflict of a selected change with the live definitions
+type PromotionConflict struct {
+	Dimension   Dimension               `json:"dimension"`
+	Source      RedirectSource          `json:"source"`
+	Reason      PromotionConflictReason `json:"reason"`
+	Live        *RedirectDefinition     `json:"live,omitempty"`
+	Promoted    *RedirectDefinition     `json:"promoted,omitempty"`
+	Overwritten bool                    `json:"overwritten"`
+}
+
+// PromotionFailure of a change rejected by the validation or the repository
+type PromotionFailure struct {
+	Definition *RedirectDefinition `json:"definition"`
+	Error      string              `json:"error"`
+}
+
+// PromotionResult lists the promoted changes, with a dry run the planned ones
+type PromotionResult struct {
+	Created   []*RedirectDefinition `json:"created"`
+	Updated   []*RedirectDefinition `json:"updated"`
+	Deleted   []*RedirectDefinition `json:"deleted"`
+	Conflicts []*PromotionConflict  `json:"conflicts,omitempty"`
+	Failed    []*PromotionFailure   `json:"failed,omitempty"`
+}
+
+// PlanPromotion plans the selected changes of a diff against the live definitions. The selection is checked
+// against the live state, changes to definitions modified since the diff are conflicts handled by the strategy.
+func PlanPromotion(selection *DefinitionsDiff, live []*RedirectDefinition, strategy PromoteConflictStrategy) (*PromotionResult, error) {
+	if !strategy.Valid() {
+		return nil, fmt.Errorf("invalid conflict strategy '%s'", strategy)
+	}
+
+	type key struct {
+		dimension Dimension
+		source    RedirectSource
+	}
+
+	liveByKey := make(map[key]*RedirectDefinition, len(live))
+	for _, def := range live {
+		liveByKey[key{def.Dimension, def.Source}] = def
+	}
+
+	result := &PromotionResult{
+		Created: []*RedirectDefinition{},
+		Updated: []*RedirectDefinition{},
+		Deleted: []*RedirectDefinition{},
+	}
+	overwrite := strategy == PromoteConflictOverwrite
+	conflict := func(reason PromotionConflictReason, liveDef, promotedDef *RedirectDefinition) bool {
+		def := promotedDef
+		if def == nil {
+			def = liveDef
+		}
+
+		result.Conflicts = append(result.Conflicts, &PromotionConflict{
+			Dimension:   def.Dimension,
+			Source:      def.Source,
+			Reason:      reason,
+			Live:        liveDef,
+			Promoted:    promotedDef,
+			Overwritten: overwrite,
+		})
+
+		return overwrite
+	}
+
+	for _, def := range selection.Added {
+		current, ok := liveByKey[key{def.Dimension, def.Source}]
+
+		switch {
+		case !ok:
+			result.Created = append(result.Created, promoted(def, ""))
+		case DefinitionsEquivalent(current, def):
+			// already promoted
+		case conflict(PromotionConflictExists, current, def):
+			result.Updated = append(result.Updated, promoted(def, current.ID))
+		}
+	}
+
+	for _, change := range selection.Changed {
+		current, ok := liveByKey[key{change.Target.Dimension, change.Target.Source}]
+
+		switch {
+		case !ok:
+			if conflict(PromotionConflictDeleted, nil, change.Target) {
+				result.Created = append(result.Created, promoted(change.Target, ""))
+			}
+		case DefinitionsEquivalent(current, change.Target):
+			// already promoted
+		case !DefinitionsEquivalent(current, change.Current) && !conflict(PromotionConflictModified, current, change.Target):
+			// keep the live definition
+		default:
+			result.Updated = append(result.Updated, promoted(change.Target, current.ID))
+		}
+	}
+
+	for _, def := range selection.Removed {
+		current, ok := liveByKey[key{def.Dimension, def.Source}]
+
+		switch {
+		case !ok:
+			// already deleted
+		case !DefinitionsEquivalent(current, def) && !conflict(PromotionConflictModified, current, nil):
+			// keep the live definition
+		default:
+			result.Deleted = append(result.Deleted, current)
+		}
+	}
+
+	return result, nil
+}
+
+// promoted returns a copy of the definition with the ID of the live definition, a new one if empty.
+// The intended target is promoted, the chains are flattened again in the live environment.
+func promoted(def *RedirectDefinition, id EntityID) *RedirectDefinition {
+	copied := *def
+	copied.ID = id
+	copied.SetIntendedTarget(def.IntendedTarget())
+	copied.Batch = ""
+	copied.Obsolete = ""
+
+	return &copied
+}
@@ -0,0 +1,90 @@
+package redirectstore_test
+
+import (
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinitionsEquivalent(t *testing.T) {
+	t.Parallel()
+
+	stage := &storex.RedirectDefinition{ID: "stage", Source: "/a", Target: "/b", LastUpdatedBy: "editor", Batch: "batch"}
+	prod := &storex.RedirectDefinition{ID: "prod", Source: "/a", Target: "/b"}
+
+	assert.True(t, storex.DefinitionsEquivalent(prod, stage))
+	assert.False(t, storex.DefinitionsEquivalent(prod, &storex.RedirectDefinition{ID: "stage", Source: "/a", Target: "/c"}))
+	assert.Equal(t, storex.EntityID("stage"), stage.ID)
+}
+
+func TestPlanPromotion(t *testing.T) {
+	t.Parallel()
+
+	diffCurrent := &storex.RedirectDefinition{ID: "p2", Dimension: "de", Source: "/changed", Target: "/old"}
+	selection := &storex.DefinitionsDiff{
+		Added: []*storex.RedirectDefinition{
+			{ID: "s1", Dimension: "de", Source: "/new", Target: "/x"},
+			{ID: "s2", Dimension: "de", Source: "/taken", Target: "/x"},
+		},
+		Changed: []*storex.DefinitionChange{
+			{Current: diffCurrent, Target: &storex.RedirectDefinition{ID: "s3", Dimension: "de", Source: "/changed", Target: "/new"}},
+			{
+				Current: &storex.RedirectDefinition{ID: "p4", Dimension: "de", Source: "/edited", Target: "/old"},
+				Target:  &storex.RedirectDefinition{ID: "s4", Dimension: "de", Source: "/edited", Target: "/new"},
+			},
+		},
+		Removed: []*storex.RedirectDefinition{
+			{ID: "p5", Dimension: "de", Source: "/removed", Target: "/y"},
+		},
+	}
+	live := []*storex.RedirectDefinition{
+		{ID: "p1", Dimension: "de", Source: "/taken", Target: "/other"},
+		{ID: "p2", Dimension: "de", Source: "/changed", Target: "/old"},
+		{ID: "p4", Dimension: "de", Source: "/edited", Target: "/manual"},
+		{ID: "p5", Dimension: "de", Source: "/removed", Target: "/y"},
+	}
+
+	t.Run("skip", func(t *testing.T) {
+		t.Parallel()
+
+		plan, err := storex.PlanPromotion(selection, live, storex.PromoteConflictSkip)
+		require.NoError(t, err)
+
+		require.Len(t, plan.Created, 1)
+		assert.Equal(t, storex.RedirectSource("/new"), plan.Created[0].Source)
+		assert.Empty(t, plan.Created[0].ID)
+		require.Len(t, plan.Updated, 1)
+		assert.Equal(t, storex.EntityID("p2"), plan.Updated[0].ID)
+		assert.Equal(t, storex.RedirectTarget("/new"), plan.Updated[0].Target)
+		require.Len(t, plan.Deleted, 1)
+		assert.Equal(t, storex.EntityID("p5"), plan.Deleted[0].ID)
+
+		require.Len(t, plan.Conflicts, 2)
+		assert.Equal(t, storex.PromotionConflictExists, plan.Conflicts[0].Reason)
+		assert.Equal(t, storex.PromotionConflictModified, plan.Conflicts[1].Reason)
+		assert.False(t, plan.Conflicts[0].Overwritten)
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		t.Parallel()
+
+		plan, err := storex.PlanPromotion(selection, live, storex.PromoteConflictOverwrite)
+		require.NoError(t, err)
+
+		assert.Len(t, plan.Created, 1)
+		require.Len(t, plan.Updated, 3)
+		assert.Equal(t, storex.EntityID("p1"), plan.Updated[0].ID)
+		assert.Equal(t, storex.EntityID("p4"), plan.Updated[2].ID)
+		assert.Len(t, plan.Conflicts, 2)
+		assert.True(t, plan.Conflicts[0].Overwritten)
+	})
+
+	t.Run("invalid strategy", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := storex.PlanPromotion(selection, live, "merge")
+		assert.Error(t, err)
+	})
+}
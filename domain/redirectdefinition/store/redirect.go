@@ -18,6 +18,13 @@ type RedirectCode int
 type Redirect struct {
 	Response RedirectResponse
 	Code     RedirectCode
+	// DefinitionID of the matched definition, empty for standard redirects
+	DefinitionID EntityID
+}
+
+// IsRedirection returns true for 3xx codes, other codes are answered without a location
+func (r RedirectCode) IsRedirection() bool {
+	return r >= 300 && r < 400
 }
 
 func (r RedirectCode) Valid() bool {
@@ -33,20 +40,6 @@ func (r RedirectCode) Valid() bool {
 	}
 }
 
-// GenericTransform checks whether the url need to be transformed to confirm to
-// the url requirement - lowercased, no trailing slash
-func (r RedirectRequest) GenericTransform() (RedirectRequest, bool, error) {
-	base, err := url.Parse(string(r))
-	if err != nil {
-		return r, false, err
-	}
-
-	base.Path = strings.TrimSuffix(strings.ToLower(base.Path), "/")
-	newRequest := RedirectRequest(base.String())
-
-	return newRequest, newRequest != r, nil
-}
-
 // IsHomepage returns true if we are on homepage
 func (r RedirectRequest) IsHomepage() (bool, error) {
 	base, err := url.Parse(string(r))
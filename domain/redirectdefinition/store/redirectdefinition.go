@@ -10,22 +10,40 @@ type RedirectDefinition struct {
 	ID              EntityID        `json:"id" bson:"id"`
 	ContentID       string          `json:"contentId" bson:"contentId"`
 	Source          RedirectSource  `json:"source" bson:"source"`
-	Target          RedirectTarget  `json:"target" bson:"target"`
+	Target          RedirectTarget  `json:"target" bson:"target"`                           // Effective target, resolved by flattening
+	OriginalTarget  RedirectTarget  `json:"originalTarget,omitempty" bson:"originalTarget"` // Intended target if flattening changed the target
 	Code            RedirectCode    `json:"code" bson:"code"`
+	MatchType       MatchType       `json:"matchType,omitempty" bson:"matchType"` // Prefix definitions also match all paths below the source
 	RespectParams   bool            `json:"respectparams" bson:"respectparams"`
 	TransferParams  bool            `json:"transferparams" bson:"transferparams"`
+	ParamPolicy     *ParamPolicy    `json:"paramPolicy,omitempty" bson:"paramPolicy,omitempty"` // Overrides the parameter policy of the dimension
 	RedirectionType RedirectionType `json:"redirectType" bson:"redirectType"`
 	Dimension       Dimension       `json:"dimension" bson:"dimension"`
 	Stale           bool            `json:"stale" bson:"stale"`
 	Updated         DateTime        `json:"updated,omitempty" bson:"updated"`             // Timestamp of the last update
 	LastUpdatedBy   string          `json:"lastUpdatedBy,omitempty" bson:"lastUpdatedBy"` // User who made the last update
+	NeedsReview     bool            `json:"needsReview,omitempty" bson:"needsReview"`     // Created for deleted content and should be reviewed
+	Locked          bool            `json:"locked,omitempty" bson:"locked"`               // Never changed by automatic consolidation and flattening
+	Obsolete        DateTime        `json:"obsolete,omitempty" bson:"obsolete"`           // Time the consolidation soft deleted the redirect
+	NoFlatten       bool            `json:"noFlatten,omitempty" bson:"noFlatten"`         // Keeps the redirect as hop, chains through it are not flattened
+	Batch           EntityID        `json:"batch,omitempty" bson:"batch"`                 // Automatic run which created or last changed the redirect
 }
 
 type RedirectDefinitions map[RedirectSource]*RedirectDefinition
 
 type PaginatedResult struct {
-	Results  []*RedirectDefinition `json:"results"`
-	Total    int                   `json:"total"`
-	Page     int                   `json:"page"`
-	PageSize int                   `json:"pageSize"`
+	Results    []*RedirectDefinition `json:"results"`
+	Total      int                   `json:"total"`
+	Page       int                   `json:"page"`
+	PageSize   int                   `json:"pageSize"`
+	Facets     *SearchFacets         `json:"facets,omitempty"`     // Only set if requested
+	NextCursor string                `json:"nextCursor,omitempty"` // Keyset cursor of the next page, empty on the last page
+}
+
+// SearchFacets counts the results of a search by field
+type SearchFacets struct {
+	RedirectType map[RedirectionType]int `json:"redirectType"`
+	ActiveState  map[ActiveStateType]int `json:"activeState"`
+	Code         map[RedirectCode]int    `json:"code"`
+	Dimension    map[Dimension]int       `json:"dimension"`
 }
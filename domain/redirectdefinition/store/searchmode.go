@@ -0,0 +1,80 @@
+package redirectstore
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+)
+
+// SearchMode defines how a search term is matched
+type SearchMode string
+
+const (
+	// SearchModeContains matches the escaped term anywhere, case-insensitive (default)
+	SearchModeContains SearchMode = "contains"
+	// SearchModePrefix matches the escaped term at the start, case-sensitive so that the index is used
+	SearchModePrefix SearchMode = "prefix"
+	// SearchModeExact matches the term exactly
+	SearchModeExact SearchMode = "exact"
+	// SearchModeRegex matches the term as case-insensitive regular expression, privileged users only
+	SearchModeRegex SearchMode = "regex"
+)
+
+const (
+	// SearchRegexMaxLength limits the length of regex search terms
+	SearchRegexMaxLength = 256
+	// SearchRegexMaxNodes limits the number of nodes of the parsed regex search terms
+	SearchRegexMaxNodes = 64
+)
+
+func (m SearchMode) IsValid() bool {
+	switch m {
+	case "", SearchModeContains, SearchModePrefix, SearchModeExact, SearchModeRegex:
+		return true
+	default:
+		return false
+	}
+}
+
+// Privileged returns true if the mode may only be used by privileged users
+func (m SearchMode) Privileged() bool {
+	return m == SearchModeRegex
+}
+
+// ValidateSearchRegex checks a regex search term against syntax and complexity limits
+func ValidateSearchRegex(pattern string) error {
+	if len(pattern) > SearchRegexMaxLength {
+		return fmt.Errorf("regex exceeds %d characters", SearchRegexMaxLength)
+	}
+
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+
+	nodes := 0
+
+	var walk func(re *syntax.Regexp, repeated bool) error
+
+	walk = func(re *syntax.Regexp, repeated bool) error {
+		if nodes++; nodes > SearchRegexMaxNodes {
+			return fmt.Errorf("regex exceeds %d nodes", SearchRegexMaxNodes)
+		}
+
+		isRepeat := re.Op == syntax.OpStar || re.Op == syntax.OpPlus || re.Op == syntax.OpRepeat
+		if isRepeat && repeated {
+			// nested quantifiers like (a+)+ backtrack catastrophically
+			return errors.New("regex contains nested quantifiers")
+		}
+
+		for _, sub := range re.Sub {
+			if err := walk(sub, repeated || isRepeat); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return walk(re, false)
+}
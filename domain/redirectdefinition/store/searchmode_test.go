@@ -0,0 +1,26 @@
+package redirectstore_test
+
+import (
+	"strings"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSearchRegex(t *testing.T) {
+	t.Parallel()
+
+	for pattern, valid := range map[string]bool{
+		`^/sale/.*\.html$`:          true,
+		`/(de|en)/produkte?`:        true,
+		`(a+)+$`:                    false,
+		`(.*a){3}`:                  false,
+		`/sale(`:                    false,
+		strings.Repeat("a", 300):    false,
+		strings.Repeat("(a|b)", 40): false,
+	} {
+		err := storex.ValidateSearchRegex(pattern)
+		assert.Equal(t, valid, err == nil, pattern)
+	}
+}
@@ -0,0 +1,77 @@
+package redirectstore
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrSnapshotExists is returned if a snapshot with the name is already stored
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
+// Snapshot of all redirect definitions or the ones of a single dimension
+type Snapshot struct {
+	ID        EntityID  `json:"id" bson:"id"`
+	Name      string    `json:"name" bson:"name"`
+	Time      DateTime  `json:"time" bson:"time"`
+	Dimension Dimension `json:"dimension,omitempty" bson:"dimension"` // All dimensions if empty
+	Count     int       `json:"count" bson:"count"`
+	CreatedBy string    `json:"createdBy,omitempty" bson:"createdBy"`
+	// Definitions are stored as snapshot entries and only set if the snapshot was loaded with them
+	Definitions []*RedirectDefinition `json:"definitions,omitempty" bson:"-"`
+}
+
+// SnapshotEntry holds a definition of a stored snapshot
+type SnapshotEntry struct {
+	Snapshot   EntityID            `json:"snapshot" bson:"snapshot"`
+	Definition *RedirectDefinition `json:"definition" bson:"definition"`
+}
+
+// Filter returns the filter for the definitions in the scope of the snapshot
+func (s *Snapshot) Filter() RedirectsFilter {
+	filter := RedirectsFilter{IncludeStale: true}
+	if s.Dimension != "" {
+		filter.Dimensions = []Dimension{s.Dimension}
+	}
+
+	return filter
+}
+
+// InScope returns the definitions of the snapshot's dimension
+func (s *Snapshot) InScope() []*RedirectDefinition {
+	if s.Dimension == "" {
+		return s.Definitions
+	}
+
+	defs := make([]*RedirectDefinition, 0, len(s.Definitions))
+
+	for _, def := range s.Definitions {
+		if def.Dimension == s.Dimension {
+			defs = append(defs, def)
+		}
+	}
+
+	return defs
+}
+
+// WriteSnapshot writes the snapshot with its definitions as JSON file
+func WriteSnapshot(w io.Writer, snapshot *Snapshot) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(snapshot)
+}
+
+// ReadSnapshot reads a snapshot file written by WriteSnapshot
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, err
+	}
+
+	if snapshot.Name == "" {
+		return nil, errors.New("snapshot file without name")
+	}
+
+	return &snapshot, nil
+}
@@ -0,0 +1,40 @@
+package redirectstore_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot_File(t *testing.T) {
+	t.Parallel()
+
+	snapshot := &storex.Snapshot{
+		ID:        "id",
+		Name:      "before-release",
+		Dimension: "de",
+		Count:     2,
+		Definitions: []*storex.RedirectDefinition{
+			{ID: "1", Dimension: "de", Source: "/a", Target: "/b"},
+			{ID: "2", Dimension: "fr", Source: "/a", Target: "/b"},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, storex.WriteSnapshot(&buf, snapshot))
+
+	read, err := storex.ReadSnapshot(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, read)
+
+	// only the definitions of the snapshot's dimension are restored
+	assert.Len(t, read.InScope(), 1)
+	assert.Equal(t, storex.RedirectsFilter{Dimensions: []storex.Dimension{"de"}, IncludeStale: true}, read.Filter())
+
+	_, err = storex.ReadSnapshot(strings.NewReader(`{"definitions":[]}`))
+	assert.Error(t, err)
+}
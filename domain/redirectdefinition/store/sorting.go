@@ -1,8 +1,9 @@
 package redirectstore
 
 type Pagination struct {
-	Page     int `json:"page"`
-	PageSize int `json:"pageSize"`
+	Page     int    `json:"page"`
+	PageSize int    `json:"pageSize"`
+	Cursor   string `json:"cursor,omitempty"` // Keyset cursor of the previous result, replaces Page if set
 }
 
 type SortField string
@@ -13,6 +14,22 @@ const (
 	SortFieldLastUpdatedBy SortField = "lastUpdatedBy"
 )
 
+func (f SortField) IsValid() bool {
+	return f == SortFieldSource || f == SortFieldUpdated || f == SortFieldLastUpdatedBy
+}
+
+// SortValue returns the value of the sort field as string
+func (d *RedirectDefinition) SortValue(field SortField) string {
+	switch field {
+	case SortFieldUpdated:
+		return string(d.Updated)
+	case SortFieldLastUpdatedBy:
+		return d.LastUpdatedBy
+	default:
+		return string(d.Source)
+	}
+}
+
 type Direction string
 
 const (
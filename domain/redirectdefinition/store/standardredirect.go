@@ -0,0 +1,104 @@
+package redirectstore
+
+import (
+	"path"
+	"strings"
+)
+
+// StandardRedirectRule describes a generic url rule the provider applies
+// when no redirect definition matches a request
+type StandardRedirectRule string
+
+const (
+	StandardRedirectRuleLowercase            StandardRedirectRule = "lowercase"
+	StandardRedirectRuleStripTrailingSlash   StandardRedirectRule = "stripTrailingSlash"
+	StandardRedirectRuleEnforceTrailingSlash StandardRedirectRule = "enforceTrailingSlash"
+	StandardRedirectRuleCollapseSlashes      StandardRedirectRule = "collapseSlashes"
+	StandardRedirectRuleStripIndexFile       StandardRedirectRule = "stripIndexFile"
+)
+
+// standardRedirectRuleOrder is the order in which rules are applied,
+// independent of the order they were configured in
+var standardRedirectRuleOrder = []StandardRedirectRule{
+	StandardRedirectRuleCollapseSlashes,
+	StandardRedirectRuleStripIndexFile,
+	StandardRedirectRuleLowercase,
+	StandardRedirectRuleStripTrailingSlash,
+	StandardRedirectRuleEnforceTrailingSlash,
+}
+
+// indexFiles are the file names removed by StandardRedirectRuleStripIndexFile
+var indexFiles = []string{"index.html", "index.htm", "index.php"}
+
+func (r StandardRedirectRule) Valid() bool {
+	switch r {
+	case StandardRedirectRuleLowercase,
+		StandardRedirectRuleStripTrailingSlash,
+		StandardRedirectRuleEnforceTrailingSlash,
+		StandardRedirectRuleCollapseSlashes,
+		StandardRedirectRuleStripIndexFile:
+		return true
+	default:
+		return false
+	}
+}
+
+// Apply returns the path transformed by the rule
+func (r StandardRedirectRule) Apply(p string) string {
+	switch r {
+	case StandardRedirectRuleLowercase:
+		return strings.ToLower(p)
+	case StandardRedirectRuleStripTrailingSlash:
+		if p == "/" {
+			return p
+		}
+
+		return strings.TrimRight(p, "/")
+	case StandardRedirectRuleEnforceTrailingSlash:
+		// files like /robots.txt keep their form
+		if strings.HasSuffix(p, "/") || path.Ext(p) != "" {
+			return p
+		}
+
+		return p + "/"
+	case StandardRedirectRuleCollapseSlashes:
+		for strings.Contains(p, "//") {
+			p = strings.ReplaceAll(p, "//", "/")
+		}
+
+		return p
+	case StandardRedirectRuleStripIndexFile:
+		for _, indexFile := range indexFiles {
+			if strings.HasSuffix(strings.ToLower(p), "/"+indexFile) {
+				return p[:len(p)-len(indexFile)]
+			}
+		}
+
+		return p
+	default:
+		return p
+	}
+}
+
+// ApplyStandardRedirectRules applies the given rules to the path and reports
+// whether the path was changed
+func ApplyStandardRedirectRules(p string, rules []StandardRedirectRule) (string, bool) {
+	enabled := make(map[StandardRedirectRule]struct{}, len(rules))
+	for _, rule := range rules {
+		enabled[rule] = struct{}{}
+	}
+
+	newPath := p
+
+	for _, rule := range standardRedirectRuleOrder {
+		if _, ok := enabled[rule]; ok {
+			newPath = rule.Apply(newPath)
+		}
+	}
+
+	if newPath == "" {
+		newPath = "/"
+	}
+
+	return newPath, newPath != p
+}
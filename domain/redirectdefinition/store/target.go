@@ -0,0 +1,120 @@
+package redirectstore
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// TargetKind classifies a redirect target
+type TargetKind string
+
+const (
+	TargetKindRelative TargetKind = "relative"
+	TargetKindSameSite TargetKind = "sameSite"
+	TargetKindExternal TargetKind = "external"
+)
+
+// ErrHostNotAllowed is returned for absolute targets to hosts which are not on the allowlist
+var ErrHostNotAllowed = errors.New("external redirect target host is not allowed")
+
+// AllowedHosts configures the hosts absolute redirect targets may point to
+type AllowedHosts struct {
+	// Site hosts of the site itself, absolute targets to these hosts are same-site
+	Site []string `json:"site"`
+	// External hosts allowed as redirect targets, "*.example.com" matches all subdomains but not example.com
+	External []string `json:"external"`
+	// WarnOnly accepts targets to hosts which are not on the allowlist and only logs them, e.g. until the
+	// hosts of existing absolute redirects are configured. Malformed targets are always rejected.
+	WarnOnly bool `json:"warnOnly"`
+}
+
+// ClassifyTarget returns the kind of the target. Protocol-relative targets,
+// targets with a scheme other than http(s) and malformed targets are rejected.
+func ClassifyTarget(target RedirectTarget, siteHosts []string) (TargetKind, error) {
+	value := string(target)
+	if value == "" {
+		return "", fmt.Errorf("redirect target is empty")
+	}
+
+	for _, r := range value {
+		if unicode.IsControl(r) || unicode.IsSpace(r) || r == '\\' {
+			return "", fmt.Errorf("redirect target '%s' contains invalid characters", value)
+		}
+	}
+
+	if strings.HasPrefix(value, "//") {
+		return "", fmt.Errorf("protocol-relative redirect target '%s' is not allowed", value)
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect target '%s': %w", value, err)
+	}
+
+	if u.Scheme == "" {
+		if !strings.HasPrefix(value, "/") {
+			return "", fmt.Errorf("relative redirect target '%s' must start with '/'", value)
+		}
+
+		return TargetKindRelative, nil
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("redirect target scheme '%s' is not allowed", u.Scheme)
+	}
+
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("redirect target '%s' has no host", value)
+	}
+
+	if matchHost(u.Hostname(), siteHosts) {
+		return TargetKindSameSite, nil
+	}
+
+	return TargetKindExternal, nil
+}
+
+// ValidateTarget classifies the target and rejects external targets to hosts not on the allowlist
+// with ErrHostNotAllowed, the kind is also returned with it
+func (a AllowedHosts) ValidateTarget(target RedirectTarget) (TargetKind, error) {
+	kind, err := ClassifyTarget(target, a.Site)
+	if err != nil {
+		return "", err
+	}
+
+	if kind == TargetKindExternal {
+		u, err := url.Parse(string(target))
+		if err != nil {
+			return "", err
+		}
+
+		if !matchHost(u.Hostname(), a.External) {
+			return kind, fmt.Errorf("%w: '%s'", ErrHostNotAllowed, u.Hostname())
+		}
+	}
+
+	return kind, nil
+}
+
+func matchHost(host string, hosts []string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range hosts {
+		allowed = strings.ToLower(allowed)
+		if domain, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+domain) {
+				return true
+			}
+
+			continue
+		}
+
+		if host == allowed {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package redirectstore_test
+
+import (
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AllowedHosts_ValidateTarget(t *testing.T) {
+	t.Parallel()
+
+	allowedHosts := storex.AllowedHosts{
+		Site:     []string{"www.example.com"},
+		External: []string{"partner.com", "*.example.org"},
+	}
+
+	tests := []struct {
+		target storex.RedirectTarget
+		kind   storex.TargetKind
+		err    bool
+	}{
+		{target: "/foo?a=b", kind: storex.TargetKindRelative},
+		{target: "https://www.example.com/foo", kind: storex.TargetKindSameSite},
+		{target: "https://partner.com/foo", kind: storex.TargetKindExternal},
+		{target: "https://shop.example.org/foo", kind: storex.TargetKindExternal},
+		{target: "https://a.shop.example.org/foo", kind: storex.TargetKindExternal},
+		{target: "https://example.org/foo", err: true},
+		{target: "https://evilexample.org/foo", err: true},
+		{target: "https://evil.com/foo", err: true},
+		{target: "//evil.com/foo", err: true},
+		{target: "/\\evil.com", err: true},
+		{target: "javascript:alert(1)", err: true},
+		{target: "JavaScript:alert(1)", err: true},
+		{target: "data:text/html,foo", err: true},
+		{target: "foo/bar", err: true},
+		{target: "", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.target), func(t *testing.T) {
+			t.Parallel()
+
+			kind, err := allowedHosts.ValidateTarget(tt.target)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, kind)
+		})
+	}
+}
+
+func Test_AllowedHosts_ValidateTarget_Wildcard(t *testing.T) {
+	t.Parallel()
+
+	// only the "*." form is a wildcard
+	allowedHosts := storex.AllowedHosts{External: []string{"*example.com"}}
+
+	_, err := allowedHosts.ValidateTarget("https://evilexample.com/foo")
+	require.ErrorIs(t, err, storex.ErrHostNotAllowed)
+
+	_, err = allowedHosts.ValidateTarget("https://www.example.com/foo")
+	require.ErrorIs(t, err, storex.ErrHostNotAllowed)
+}
+
+func Test_AllowedHosts_ValidateTarget_WarnOnly(t *testing.T) {
+	t.Parallel()
+
+	allowedHosts := storex.AllowedHosts{WarnOnly: true}
+
+	kind, err := allowedHosts.ValidateTarget("https://partner.com/foo")
+	require.ErrorIs(t, err, storex.ErrHostNotAllowed)
+	assert.Equal(t, storex.TargetKindExternal, kind)
+
+	_, err = allowedHosts.ValidateTarget("//partner.com/foo")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, storex.ErrHostNotAllowed)
+}
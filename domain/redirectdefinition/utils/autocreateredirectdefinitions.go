@@ -2,51 +2,187 @@ package redirectdefinitionutils
 
 import (
 	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/foomo/contentserver/content"
 	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
 	"go.uber.org/zap"
 )
 
 // AutoCreateRedirectDefinitions generates automatic redirects based on the difference between the old and new content tree.
-// find new.ID to old.ID and check if the URI is different, if it is different, create a redirect
+// find new.ID to old.ID and check if the URI is different, if it is different, create a redirect.
+// If a whole subtree moved, a single prefix definition is created instead of one per node,
+// descendants whose URI changed on their own get their own definition.
 func AutoCreateRedirectDefinitions(
-	_ *zap.Logger,
+	l *zap.Logger,
 	oldMap, newMap map[string]*content.RepoNode,
 	dimension storex.Dimension,
 	initialStaleValue bool,
+) ([]*storex.RedirectDefinition, error) {
+	return AutoCreateRedirectDefinitionsWithPolicy(l, oldMap, newMap, dimension,
+		func(_ storex.Dimension, _ string) storex.AutoRedirectPolicy {
+			return storex.DefaultAutoRedirectPolicy(initialStaleValue)
+		},
+	)
+}
+
+// AutoCreateRedirectDefinitionsWithPolicy generates automatic redirects like AutoCreateRedirectDefinitions
+// with the properties of the policy for the dimension and the MimeType of the moved node.
+// Nodes skipped by their policy are neither redirected on their own nor by the prefix definition of an ancestor.
+func AutoCreateRedirectDefinitionsWithPolicy(
+	_ *zap.Logger,
+	oldMap, newMap map[string]*content.RepoNode,
+	dimension storex.Dimension,
+	policyFunc providerx.AutoRedirectPolicyProviderFunc,
 ) ([]*storex.RedirectDefinition, error) {
 	if len(oldMap) == 0 || len(newMap) == 0 {
 		return nil, errors.New("calling auto create difference with nil arguments")
 	}
 
+	moves := findMoves(oldMap, newMap, func(mimeType string) storex.AutoRedirectPolicy {
+		return policyFunc(dimension, mimeType)
+	})
+	prefixes := findPrefixMoves(moves, newMap)
+
 	redirects := []*storex.RedirectDefinition{}
 
+	for _, m := range moves {
+		if m.policy.Skip {
+			continue
+		}
+
+		matchType := storex.MatchTypeExact
+		if _, ok := prefixes[m.source]; ok {
+			matchType = storex.MatchTypePrefix
+		} else if prefix := longestPrefixMove(m.source, prefixes); prefix != nil && prefix.targetFor(m.source) == m.target {
+			// covered by the prefix definition of an ancestor
+			continue
+		}
+
+		redirects = append(redirects, &storex.RedirectDefinition{
+			ID:              storex.NewEntityID(),
+			ContentID:       m.contentID,
+			Source:          storex.RedirectSource(m.source),
+			Target:          storex.RedirectTarget(m.target),
+			Code:            m.policy.Code,
+			MatchType:       matchType,
+			RespectParams:   m.policy.RespectParams,
+			TransferParams:  m.policy.TransferParams,
+			ParamPolicy:     m.policy.ParamPolicy,
+			RedirectionType: storex.RedirectionTypeAutomatic,
+			Dimension:       dimension,
+			Updated:         storex.NewDateTime(time.Now()),
+			LastUpdatedBy:   "System",
+			Stale:           m.policy.Stale,
+		})
+	}
+
+	return redirects, nil
+}
+
+// move of a node from the source to the target uri
+type move struct {
+	contentID string
+	source    string
+	target    string
+	policy    storex.AutoRedirectPolicy
+}
+
+func (m move) targetFor(source string) string {
+	return m.target + strings.TrimPrefix(source, m.source)
+}
+
+// findMoves returns the nodes with a changed uri and their policy, ordered by source so that ancestors come first
+func findMoves(oldMap, newMap map[string]*content.RepoNode, policyFunc func(mimeType string) storex.AutoRedirectPolicy) []move {
+	var moves []move
+
 	for newNodeID, newNode := range newMap {
 		oldNode, ok := oldMap[newNodeID]
-		if ok {
-			if oldNode.URI != newNode.URI {
-				rd := &storex.RedirectDefinition{
-					ID:              storex.NewEntityID(),
-					ContentID:       newNodeID,
-					Source:          storex.RedirectSource(oldNode.URI),
-					Target:          storex.RedirectTarget(newNode.URI),
-					Code:            301,
-					RespectParams:   true,
-					TransferParams:  true,
-					RedirectionType: storex.RedirectionTypeAutomatic,
-					Dimension:       dimension,
-					Updated:         storex.NewDateTime(time.Now()),
-					LastUpdatedBy:   "System",
-					Stale:           initialStaleValue,
-				}
-				redirects = append(redirects, rd)
+		if !ok {
+			continue
+		}
+
+		source, target := canonicalURI(oldNode.URI), canonicalURI(newNode.URI)
+		if source != target {
+			moves = append(moves, move{contentID: newNodeID, source: source, target: target, policy: policyFunc(newNode.MimeType)})
+		}
+	}
+
+	slices.SortFunc(moves, func(a, b move) int {
+		return strings.Compare(a.source, b.source)
+	})
+
+	return moves
+}
+
+// findPrefixMoves returns the moves which are replaced by a prefix definition. A move qualifies if
+// at least one descendant moved along with it, no descendant is skipped by its policy and no content
+// is served below the old uri anymore. Moves already covered by the prefix of an ancestor are skipped.
+func findPrefixMoves(moves []move, newMap map[string]*content.RepoNode) map[string]*move {
+	prefixes := map[string]*move{}
+
+	served := make([]string, 0, len(newMap))
+	for _, node := range newMap {
+		served = append(served, canonicalURI(node.URI))
+	}
+
+	for i, m := range moves {
+		if m.policy.Skip || m.source == "/" || strings.Contains(m.source, "?") {
+			continue
+		}
+
+		if prefix := longestPrefixMove(m.source, prefixes); prefix != nil && prefix.targetFor(m.source) == m.target {
+			continue
+		}
+
+		if slices.ContainsFunc(served, func(uri string) bool { return storex.IsBelow(uri, m.source) }) {
+			continue
+		}
+
+		// descendants moved elsewhere get an exact definition as exception, skipped ones block the prefix.
+		// Siblings like /a-b sort between /a and /a/b, so all moves are scanned.
+		followed, skipped := false, false
+
+		for _, descendant := range moves {
+			if descendant.source == m.source || !storex.IsBelow(descendant.source, m.source) {
+				continue
 			}
+
+			followed = followed || m.targetFor(descendant.source) == descendant.target
+			skipped = skipped || descendant.policy.Skip
+		}
+
+		if followed && !skipped {
+			prefixes[m.source] = &moves[i]
 		}
 	}
 
-	return redirects, nil
+	return prefixes
+}
+
+// longestPrefixMove returns the prefix move with the longest source above the given source
+func longestPrefixMove(source string, prefixes map[string]*move) *move {
+	for _, parent := range storex.ParentPaths(source) {
+		if prefix, ok := prefixes[parent]; ok {
+			return prefix
+		}
+	}
+
+	return nil
+}
+
+// canonicalURI returns the canonical form of the uri, see storex.CanonicalizeURI,
+// or the uri itself if it cannot be parsed
+func canonicalURI(uri string) string {
+	canonical, err := storex.CanonicalizeURI(uri)
+	if err != nil {
+		return uri
+	}
+
+	return canonical
 }
 
 // CreateFlatRepoNodeMap recursively retrieves all nodes from the tree and returns them in a flat map.
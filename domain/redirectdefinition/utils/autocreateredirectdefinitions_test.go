@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/foomo/contentserver/content"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
 	utilsx "github.com/foomo/redirects/v2/domain/redirectdefinition/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,7 +46,9 @@ func Test_AutoCreateRedirectDefinitionsParse(t *testing.T) {
 		false,
 	)
 	require.NoError(t, err)
-	assert.Len(t, redirects, 12)
+	// the moved subtree results in a single prefix definition
+	require.Len(t, redirects, 1)
+	assert.Equal(t, storex.MatchTypePrefix, redirects[0].MatchType)
 }
 
 func Test_AutoCreateRedirectDefinitionsCoverAll(t *testing.T) {
@@ -330,7 +333,10 @@ func Test_AutoCreateRedirectDefinitionsExg1(t *testing.T) {
 		false,
 	)
 	require.NoError(t, err)
-	assert.Len(t, redirects, 3)
+	require.Len(t, redirects, 1)
+	assert.Equal(t, storex.RedirectSource("/main/damen/kleidung"), redirects[0].Source)
+	assert.Equal(t, storex.RedirectTarget("/main/damen/bekleidung"), redirects[0].Target)
+	assert.Equal(t, storex.MatchTypePrefix, redirects[0].MatchType)
 }
 
 func Test_AutoCreateRedirectDefinitionsExg2(t *testing.T) {
@@ -423,7 +429,10 @@ func Test_AutoCreateRedirectDefinitionsExg2(t *testing.T) {
 		false,
 	)
 	require.NoError(t, err)
-	assert.Len(t, redirects, 3)
+	require.Len(t, redirects, 1)
+	assert.Equal(t, storex.RedirectSource("/main/damen/kleidung"), redirects[0].Source)
+	assert.Equal(t, storex.RedirectTarget("/main/herren/kleidung"), redirects[0].Target)
+	assert.Equal(t, storex.MatchTypePrefix, redirects[0].MatchType)
 }
 
 func Test_AutoCreateRedirectDefinitionsExg3(t *testing.T) {
@@ -516,7 +525,55 @@ func Test_AutoCreateRedirectDefinitionsExg3(t *testing.T) {
 		false,
 	)
 	require.NoError(t, err)
-	assert.Len(t, redirects, 3)
+	// the prefix definition with an exception for the child renamed on its own
+	require.Len(t, redirects, 2)
+
+	bySource := map[storex.RedirectSource]*storex.RedirectDefinition{}
+	for _, redirect := range redirects {
+		bySource[redirect.Source] = redirect
+	}
+
+	require.Contains(t, bySource, storex.RedirectSource("/main/damen/kleidung"))
+	assert.Equal(t, storex.MatchTypePrefix, bySource["/main/damen/kleidung"].MatchType)
+	require.Contains(t, bySource, storex.RedirectSource("/main/damen/kleidung/roecke"))
+	assert.Equal(t, storex.RedirectTarget("/main/damen/bekleidung/damenroecke"), bySource["/main/damen/kleidung/roecke"].Target)
+	assert.Equal(t, storex.MatchTypeExact, bySource["/main/damen/kleidung/roecke"].MatchType)
+}
+
+// siblings like /a-b sort between /a and its descendants
+func Test_AutoCreateRedirectDefinitionsPrefixSiblings(t *testing.T) {
+	t.Parallel()
+
+	nodes := func(uris map[string]string) map[string]*content.RepoNode {
+		root := &content.RepoNode{ID: "root", URI: "/", Nodes: map[string]*content.RepoNode{}}
+		for id, uri := range uris {
+			root.Nodes[id] = &content.RepoNode{ID: id, URI: uri}
+		}
+
+		return utilsx.CreateFlatRepoNodeMap(root, make(map[string]*content.RepoNode))
+	}
+
+	redirects, err := utilsx.AutoCreateRedirectDefinitions(
+		zap.L(),
+		nodes(map[string]string{"a": "/a", "x": "/a/x", "ab": "/a-b", "dot": "/a.b"}),
+		nodes(map[string]string{"a": "/b", "x": "/b/x", "ab": "/c", "dot": "/a.b"}),
+		"HMD-de",
+		false,
+	)
+	require.NoError(t, err)
+	require.Len(t, redirects, 2)
+
+	bySource := map[storex.RedirectSource]*storex.RedirectDefinition{}
+	for _, redirect := range redirects {
+		bySource[redirect.Source] = redirect
+	}
+
+	require.Contains(t, bySource, storex.RedirectSource("/a"))
+	assert.Equal(t, storex.MatchTypePrefix, bySource["/a"].MatchType)
+	assert.Equal(t, storex.RedirectTarget("/b"), bySource["/a"].Target)
+	require.Contains(t, bySource, storex.RedirectSource("/a-b"))
+	assert.Equal(t, storex.MatchTypeExact, bySource["/a-b"].MatchType)
+	assert.Equal(t, storex.RedirectTarget("/c"), bySource["/a-b"].Target)
 }
 
 func Test_AutoCreateRedirectDefinitionsEmptyAndNilArgs(t *testing.T) {
@@ -605,3 +662,69 @@ func FindNodeByURI(root *content.RepoNode, uri string) *content.RepoNode {
 
 	return nil
 }
+
+func Test_AutoCreateRedirectDefinitionsWithPolicy(t *testing.T) {
+	t.Parallel()
+
+	oldNodes := &content.RepoNode{
+		ID:  "1",
+		URI: "/main",
+		Nodes: map[string]*content.RepoNode{
+			"2": {ID: "2", URI: "/main/sale", MimeType: "campaign", Nodes: map[string]*content.RepoNode{
+				"3": {ID: "3", URI: "/main/sale/shoes", MimeType: "campaign"},
+				"4": {ID: "4", URI: "/main/sale/preview", MimeType: "preview"},
+			}},
+			"5": {ID: "5", URI: "/main/about", MimeType: "page"},
+		},
+	}
+	newNodes := &content.RepoNode{
+		ID:  "1",
+		URI: "/main",
+		Nodes: map[string]*content.RepoNode{
+			"2": {ID: "2", URI: "/main/angebote", MimeType: "campaign", Nodes: map[string]*content.RepoNode{
+				"3": {ID: "3", URI: "/main/angebote/shoes", MimeType: "campaign"},
+				"4": {ID: "4", URI: "/main/angebote/preview", MimeType: "preview"},
+			}},
+			"5": {ID: "5", URI: "/main/ueber-uns", MimeType: "page"},
+		},
+	}
+
+	redirects, err := utilsx.AutoCreateRedirectDefinitionsWithPolicy(
+		zap.NewNop(),
+		utilsx.CreateFlatRepoNodeMap(oldNodes, make(map[string]*content.RepoNode)),
+		utilsx.CreateFlatRepoNodeMap(newNodes, make(map[string]*content.RepoNode)),
+		"HMD-de",
+		func(dimension storex.Dimension, mimeType string) storex.AutoRedirectPolicy {
+			assert.Equal(t, storex.Dimension("HMD-de"), dimension)
+
+			policy := storex.DefaultAutoRedirectPolicy(false)
+			switch mimeType {
+			case "preview":
+				policy.Skip = true
+			case "campaign":
+				policy.Code = storex.RedirectCodeFound
+				policy.TransferParams = false
+				policy.Stale = true
+			}
+
+			return policy
+		},
+	)
+	require.NoError(t, err)
+
+	bySource := map[storex.RedirectSource]*storex.RedirectDefinition{}
+	for _, redirect := range redirects {
+		bySource[redirect.Source] = redirect
+	}
+
+	// the skipped preview node prevents the prefix definition
+	require.Len(t, bySource, 3)
+	assert.NotContains(t, bySource, storex.RedirectSource("/main/sale/preview"))
+	assert.Equal(t, storex.MatchTypeExact, bySource["/main/sale"].MatchType)
+	assert.Equal(t, storex.RedirectCodeFound, bySource["/main/sale/shoes"].Code)
+	assert.False(t, bySource["/main/sale/shoes"].TransferParams)
+	assert.True(t, bySource["/main/sale/shoes"].Stale)
+	assert.Equal(t, storex.RedirectCodePermanent, bySource["/main/about"].Code)
+	assert.True(t, bySource["/main/about"].TransferParams)
+	assert.False(t, bySource["/main/about"].Stale)
+}
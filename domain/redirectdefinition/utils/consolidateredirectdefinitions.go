@@ -10,6 +10,8 @@ import (
 //
 // This function ensures redirect consistency by performing the following:
 //   - Adds or updates automatic redirects from `newDefinitions`.
+//   - Leaves manual and locked redirects untouched, new redirects with their source are
+//     rejected and reported as conflicts.
 //   - Flattens redirect chains (e.g., /a → /b → /c becomes /a → /c).
 //   - Detects and marks cyclic redirects as stale (e.g., /a → /b → /a).
 //   - Marks redirects for deletion if their targets no longer exist in the latest content tree
@@ -25,14 +27,17 @@ import (
 // Returns:
 //   - A slice of redirect definitions to upsert (insert or update).
 //   - A slice of redirect IDs that are considered obsolete and should be deleted.
+//   - A slice of conflicts for new redirects rejected in favour of manual or locked ones.
 func ConsolidateRedirectDefinitions(
 	l *zap.Logger,
 	newDefinitions []*storex.RedirectDefinition,
 	currentDefinitions storex.RedirectDefinitions,
 	newNodeMap map[string]*content.RepoNode,
-) ([]*storex.RedirectDefinition, []storex.EntityID) {
+) ([]*storex.RedirectDefinition, []storex.EntityID, []*storex.RedirectConflict) {
 	upserts := make(map[string]*storex.RedirectDefinition)
 	deletedIDs := []storex.EntityID{}
+	conflicts := []*storex.RedirectConflict{}
+	accepted := make([]*storex.RedirectDefinition, 0, len(newDefinitions))
 
 	// Step 1: Index current redirects by source
 	currentBySource := make(map[storex.RedirectSource]*storex.RedirectDefinition)
@@ -40,8 +45,26 @@ func ConsolidateRedirectDefinitions(
 		currentBySource[def.Source] = def
 	}
 
-	// Step 2: Process new redirects
+	// Step 2: Process new redirects, manual and locked redirects take precedence
 	for _, def := range newDefinitions {
+		if existing, ok := currentBySource[def.Source]; ok && existing.IsProtected() {
+			if existing.Target != def.Target || existing.Code != def.Code {
+				conflict := storex.NewRedirectConflict(existing, def)
+				l.Warn("Source is taken by a protected redirect, skipping automatic redirect",
+					zap.String("source", string(conflict.Source)),
+					zap.String("existingTarget", string(conflict.ExistingTarget)),
+					zap.String("rejectedTarget", string(conflict.RejectedTarget)),
+					zap.String("reason", string(conflict.Reason)),
+				)
+
+				conflicts = append(conflicts, conflict)
+			}
+
+			continue
+		}
+
+		accepted = append(accepted, def)
+
 		staleIfCyclic(l, def, currentBySource)
 		upserts[string(def.Source)] = def
 
@@ -53,32 +76,44 @@ func ConsolidateRedirectDefinitions(
 	// Step 3: Mark targets from content + new redirects as valid
 	availableTargets := make(map[string]struct{})
 	for _, node := range newNodeMap {
-		availableTargets[node.URI] = struct{}{}
+		availableTargets[canonicalURI(node.URI)] = struct{}{}
 	}
 
 	validTargets := make(map[string]struct{})
-	for _, def := range newDefinitions {
+	for _, def := range accepted {
 		validTargets[string(def.Target)] = struct{}{}
 	}
 
 	// Step 4: Process old redirects for flattening and cleanup
 	for _, def := range currentDefinitions {
-		if def.RedirectionType != storex.RedirectionTypeAutomatic {
+		if def.RedirectionType != storex.RedirectionTypeAutomatic || def.Locked {
 			continue
 		}
 
-		// Flatten if the current target is being redirected further
-		if next, ok := upserts[string(def.Target)]; ok {
-			if def.Source != storex.RedirectSource(next.Target) {
-				def.Target = next.Target
+		// Flatten if the current target is being redirected further,
+		// targets answered with 410 and hops with NoFlatten keep the chain so that the source is not dropped
+		if next, nextTarget := nextUpsert(def.Target, upserts); next != nil && !isGone(next) && !next.NoFlatten && !def.NoFlatten {
+			if def.Source != storex.RedirectSource(nextTarget) {
+				def.SetEffectiveTarget(nextTarget)
 			}
 		}
 
 		// Detect cycles after flattening
 		staleIfCyclic(l, def, currentBySource)
 
+		// Prefix definitions must not shadow content served below their source again
+		if def.IsPrefix() && !def.Stale && servesBelow(def.Source, availableTargets) {
+			l.Warn("Content is served below prefix redirect, marking redirect as stale",
+				zap.String("source", string(def.Source)),
+				zap.String("target", string(def.Target)),
+			)
+
+			def.Stale = true
+			def.NeedsReview = true
+		}
+
 		// Check if obsolete → delete
-		if isRedirectObsolete(def, upserts, availableTargets, validTargets) {
+		if isRedirectObsolete(def, upserts, currentBySource, availableTargets, validTargets) {
 			deletedIDs = append(deletedIDs, def.ID)
 			continue
 		}
@@ -86,7 +121,7 @@ func ConsolidateRedirectDefinitions(
 		upserts[string(def.Source)] = def
 	}
 
-	return mapsToSlice(upserts), deletedIDs
+	return mapsToSlice(upserts), deletedIDs, conflicts
 }
 
 func staleIfCyclic(
@@ -108,7 +143,21 @@ func updateRedirectTarget(
 	upsertRedirectsMap map[string]*storex.RedirectDefinition,
 ) {
 	if existingRedirect.RedirectionType == storex.RedirectionTypeAutomatic {
-		existingRedirect.Target = newRedirect.Target
+		existingRedirect.SetIntendedTarget(newRedirect.Target)
+		existingRedirect.MatchType = newRedirect.MatchType
+		// content deleted or revived since turns a redirect into a 410 definition and back
+		existingRedirect.Code = newRedirect.Code
+		existingRedirect.NeedsReview = newRedirect.NeedsReview
+		existingRedirect.Stale = newRedirect.Stale
+		// the automatic redirect policy may have changed since the redirect was created
+		existingRedirect.RespectParams = newRedirect.RespectParams
+		existingRedirect.TransferParams = newRedirect.TransferParams
+		existingRedirect.ParamPolicy = newRedirect.ParamPolicy
+
+		if !existingRedirect.Stale {
+			existingRedirect.Obsolete = ""
+		}
+
 		upsertRedirectsMap[string(existingRedirect.Source)] = existingRedirect
 	}
 }
@@ -116,9 +165,30 @@ func updateRedirectTarget(
 func isRedirectObsolete(
 	def *storex.RedirectDefinition,
 	upserts map[string]*storex.RedirectDefinition,
+	currentBySource map[storex.RedirectSource]*storex.RedirectDefinition,
 	availableTargets map[string]struct{},
 	validTargets map[string]struct{},
 ) bool {
+	// definitions for deleted content are obsolete once the uri is served again
+	if isGone(def) || def.NeedsReview {
+		if _, isSourceAvailable := availableTargets[string(def.Source)]; isSourceAvailable {
+			return true
+		}
+	}
+
+	// 410 definitions have no target and chains ending in them are kept
+	if isGone(def) {
+		return false
+	}
+
+	if next, ok := upserts[string(def.Target)]; ok && isGone(next) {
+		return false
+	}
+
+	if next, ok := currentBySource[storex.RedirectSource(def.Target)]; ok && isGone(next) {
+		return false
+	}
+
 	_, isStillUpserted := upserts[string(def.Source)]
 	_, isTargetValid := validTargets[string(def.Target)]
 	_, isTargetAvailable := availableTargets[string(def.Target)]
@@ -126,6 +196,34 @@ func isRedirectObsolete(
 	return !isStillUpserted && !isTargetValid && !isTargetAvailable
 }
 
+// nextUpsert returns the upserted definition the target is redirected to, either by the definition for the
+// target itself or by the longest prefix definition above it, along with the resulting target
+func nextUpsert(target storex.RedirectTarget, upserts map[string]*storex.RedirectDefinition) (*storex.RedirectDefinition, storex.RedirectTarget) {
+	if next, ok := upserts[string(target)]; ok {
+		return next, next.Target
+	}
+
+	return storex.ResolvePrefix(string(target), func(source storex.RedirectSource) *storex.RedirectDefinition {
+		return upserts[string(source)]
+	})
+}
+
+// servesBelow returns true if content is served at or below the source
+func servesBelow(source storex.RedirectSource, availableTargets map[string]struct{}) bool {
+	for uri := range availableTargets {
+		if storex.IsBelow(uri, string(source)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isGone returns true for definitions answering the source with 410
+func isGone(def *storex.RedirectDefinition) bool {
+	return def.Code == storex.RedirectCodeGone
+}
+
 func mapsToSlice(upsertRedirectsMap map[string]*storex.RedirectDefinition) []*storex.RedirectDefinition {
 	upsertRedirectDefinitions := make([]*storex.RedirectDefinition, 0, len(upsertRedirectsMap))
 	for _, redirect := range upsertRedirectsMap {
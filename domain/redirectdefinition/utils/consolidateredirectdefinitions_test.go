@@ -68,7 +68,7 @@ func Test_ConsolidateRedirectDefinitions(t *testing.T) {
 	expectedDeleted := []storex.EntityID{} // No deletions expected
 
 	// Run the function
-	updatedDefs, deletedIDs := utilsx.ConsolidateRedirectDefinitions(
+	updatedDefs, deletedIDs, _ := utilsx.ConsolidateRedirectDefinitions(
 		zap.L(),
 		newRedirects,
 		oldRedirects,
@@ -115,7 +115,7 @@ func Test_ConsolidateRedirectDefinitions_WithCycle(t *testing.T) {
 
 	currentNodes := map[string]*content.RepoNode{}
 
-	updatedDefs, deletedIDs := utilsx.ConsolidateRedirectDefinitions(
+	updatedDefs, deletedIDs, _ := utilsx.ConsolidateRedirectDefinitions(
 		zap.L(),
 		newRedirects,
 		oldRedirects,
@@ -160,7 +160,7 @@ func Test_ConsolidateRedirectDefinitions_NoCycle(t *testing.T) {
 	}
 
 	// Run the function
-	updatedDefs, deletedIDs := utilsx.ConsolidateRedirectDefinitions(
+	updatedDefs, deletedIDs, _ := utilsx.ConsolidateRedirectDefinitions(
 		zap.L(),
 		newRedirects,
 		oldRedirects,
@@ -213,7 +213,7 @@ func Test_ConsolidateRedirectDefinitions_SkipAndDeleteSelfRedirect(t *testing.T)
 	}
 
 	// Run consolidation
-	updatedDefs, deletedIDs := utilsx.ConsolidateRedirectDefinitions(
+	updatedDefs, deletedIDs, _ := utilsx.ConsolidateRedirectDefinitions(
 		zap.L(),
 		newRedirects,
 		oldRedirects,
@@ -242,6 +242,183 @@ func Test_ConsolidateRedirectDefinitions_SkipAndDeleteSelfRedirect(t *testing.T)
 
 // 🔹 Test Cases for HasCycle 🔹
 
+func Test_ConsolidateRedirectDefinitions_DeletedContent(t *testing.T) {
+	t.Parallel()
+
+	oldRedirects := storex.RedirectDefinitions{
+		"/sale":      {ID: "1", Source: "/sale", Code: storex.RedirectCodeGone, RedirectionType: storex.RedirectionTypeAutomatic, NeedsReview: true},
+		"/sale-2023": {ID: "2", Source: "/sale-2023", Target: "/sale", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeAutomatic},
+		"/press":     {ID: "3", Source: "/press", Code: storex.RedirectCodeGone, RedirectionType: storex.RedirectionTypeAutomatic, NeedsReview: true},
+		"/boots":     {ID: "4", Source: "/boots", Target: "/shoes", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeAutomatic, NeedsReview: true},
+	}
+
+	// /press is served again
+	currentNodes := map[string]*content.RepoNode{
+		"shoes": {ID: "shoes", URI: "/shoes"},
+		"press": {ID: "press", URI: "/press"},
+	}
+
+	updatedDefs, deletedIDs, _ := utilsx.ConsolidateRedirectDefinitions(zap.NewNop(), nil, oldRedirects, currentNodes)
+
+	assert.ElementsMatch(t, []storex.EntityID{"3"}, deletedIDs)
+	require.Len(t, updatedDefs, 3)
+
+	for _, def := range updatedDefs {
+		if def.Source == "/sale-2023" {
+			assert.Equal(t, storex.RedirectTarget("/sale"), def.Target, "chains ending in 410 are kept")
+		}
+	}
+}
+
+// existing automatic redirects become 410 definitions when their content is deleted and back when it is revived
+func Test_ConsolidateRedirectDefinitions_DeletedContentChangesCode(t *testing.T) {
+	t.Parallel()
+
+	oldRedirects := storex.RedirectDefinitions{
+		"/old":  {ID: "1", Source: "/old", Target: "/new", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeAutomatic},
+		"/sale": {ID: "2", Source: "/sale", Code: storex.RedirectCodeGone, RedirectionType: storex.RedirectionTypeAutomatic, NeedsReview: true},
+	}
+
+	newRedirects := []*storex.RedirectDefinition{
+		{ID: "3", Source: "/old", Code: storex.RedirectCodeGone, RedirectionType: storex.RedirectionTypeAutomatic, NeedsReview: true},
+		{ID: "4", Source: "/sale", Target: "/offers", Code: storex.RedirectCodePermanent, RedirectionType: storex.RedirectionTypeAutomatic},
+	}
+
+	currentNodes := map[string]*content.RepoNode{
+		"offers": {ID: "offers", URI: "/offers"},
+	}
+
+	updatedDefs, deletedIDs, _ := utilsx.ConsolidateRedirectDefinitions(zap.NewNop(), newRedirects, oldRedirects, currentNodes)
+	assert.Empty(t, deletedIDs)
+
+	bySource := map[storex.RedirectSource]*storex.RedirectDefinition{}
+	for _, def := range updatedDefs {
+		bySource[def.Source] = def
+	}
+
+	require.Len(t, bySource, 2)
+	assert.Equal(t, storex.EntityID("1"), bySource["/old"].ID)
+	assert.Equal(t, storex.RedirectCodeGone, bySource["/old"].Code)
+	assert.Empty(t, bySource["/old"].Target)
+	assert.True(t, bySource["/old"].NeedsReview)
+	assert.Equal(t, storex.EntityID("2"), bySource["/sale"].ID)
+	assert.Equal(t, storex.RedirectCodePermanent, bySource["/sale"].Code)
+	assert.Equal(t, storex.RedirectTarget("/offers"), bySource["/sale"].Target)
+	assert.False(t, bySource["/sale"].NeedsReview)
+}
+
+// existing automatic redirects pick up a changed automatic redirect policy
+func Test_ConsolidateRedirectDefinitions_PolicyChange(t *testing.T) {
+	t.Parallel()
+
+	oldNodes := map[string]*content.RepoNode{"root": {ID: "root", URI: "/"}, "a": {ID: "a", URI: "/a", MimeType: "article"}}
+	newNodes := map[string]*content.RepoNode{"root": {ID: "root", URI: "/"}, "a": {ID: "a", URI: "/b", MimeType: "article"}}
+
+	run := func(policy storex.AutoRedirectPolicy, current storex.RedirectDefinitions) []*storex.RedirectDefinition {
+		newDefinitions, err := utilsx.AutoCreateRedirectDefinitionsWithPolicy(zap.NewNop(), oldNodes, newNodes, "shop-de",
+			func(_ storex.Dimension, _ string) storex.AutoRedirectPolicy {
+				return policy
+			},
+		)
+		require.NoError(t, err)
+
+		updatedDefs, _, _ := utilsx.ConsolidateRedirectDefinitions(zap.NewNop(), newDefinitions, current, newNodes)
+		require.Len(t, updatedDefs, 1)
+
+		return updatedDefs
+	}
+
+	first := run(storex.DefaultAutoRedirectPolicy(false), storex.RedirectDefinitions{})
+
+	policy := storex.DefaultAutoRedirectPolicy(false)
+	policy.Code = storex.RedirectCodeFound
+	policy.RespectParams = !policy.RespectParams
+	policy.TransferParams = !policy.TransferParams
+	policy.ParamPolicy = &storex.ParamPolicy{Allow: []string{"utm_*"}}
+
+	second := run(policy, storex.RedirectDefinitions{first[0].Source: first[0]})
+
+	assert.Equal(t, first[0].ID, second[0].ID)
+	assert.Equal(t, storex.RedirectCodeFound, second[0].Code)
+	assert.Equal(t, policy.RespectParams, second[0].RespectParams)
+	assert.Equal(t, policy.TransferParams, second[0].TransferParams)
+	assert.Equal(t, policy.ParamPolicy, second[0].ParamPolicy)
+}
+
+func Test_ConsolidateRedirectDefinitions_Prefix(t *testing.T) {
+	t.Parallel()
+
+	oldRedirects := storex.RedirectDefinitions{
+		"/a":     {ID: "1", Source: "/a", Target: "/old/page", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/promo": {ID: "2", Source: "/promo", Target: "/aktion", MatchType: storex.MatchTypePrefix, RedirectionType: storex.RedirectionTypeAutomatic},
+	}
+
+	newRedirects := []*storex.RedirectDefinition{
+		{ID: "3", Source: "/old", Target: "/new", MatchType: storex.MatchTypePrefix, RedirectionType: storex.RedirectionTypeAutomatic},
+	}
+
+	// content is served below /promo again
+	currentNodes := map[string]*content.RepoNode{
+		"page":   {ID: "page", URI: "/new/page"},
+		"aktion": {ID: "aktion", URI: "/aktion"},
+		"promo":  {ID: "promo", URI: "/promo/winter"},
+	}
+
+	updatedDefs, deletedIDs, _ := utilsx.ConsolidateRedirectDefinitions(zap.NewNop(), newRedirects, oldRedirects, currentNodes)
+
+	assert.Empty(t, deletedIDs)
+	require.Len(t, updatedDefs, 3)
+
+	for _, def := range updatedDefs {
+		switch def.Source {
+		case "/a":
+			assert.Equal(t, storex.RedirectTarget("/new/page"), def.Target, "flattened through the prefix definition")
+		case "/promo":
+			assert.True(t, def.Stale)
+			assert.True(t, def.NeedsReview)
+		}
+	}
+}
+
+func Test_ConsolidateRedirectDefinitions_Precedence(t *testing.T) {
+	t.Parallel()
+
+	oldRedirects := storex.RedirectDefinitions{
+		"/manual": {ID: "1", Source: "/manual", Target: "/campaign", RedirectionType: storex.RedirectionTypeManual},
+		"/locked": {ID: "2", Source: "/locked", Target: "/old", RedirectionType: storex.RedirectionTypeAutomatic, Locked: true},
+		"/same":   {ID: "3", Source: "/same", Target: "/new-same", RedirectionType: storex.RedirectionTypeManual},
+		"/chain":  {ID: "4", Source: "/chain", Target: "/old", RedirectionType: storex.RedirectionTypeAutomatic, Locked: true},
+	}
+
+	newRedirects := []*storex.RedirectDefinition{
+		{ID: "5", Source: "/manual", Target: "/moved", RedirectionType: storex.RedirectionTypeAutomatic},
+		{ID: "6", Source: "/locked", Target: "/moved", RedirectionType: storex.RedirectionTypeAutomatic},
+		{ID: "7", Source: "/same", Target: "/new-same", RedirectionType: storex.RedirectionTypeAutomatic},
+		{ID: "8", Source: "/old", Target: "/new", RedirectionType: storex.RedirectionTypeAutomatic},
+	}
+
+	currentNodes := map[string]*content.RepoNode{
+		"moved": {ID: "moved", URI: "/moved"},
+		"new":   {ID: "new", URI: "/new"},
+	}
+
+	updatedDefs, deletedIDs, conflicts := utilsx.ConsolidateRedirectDefinitions(zap.NewNop(), newRedirects, oldRedirects, currentNodes)
+
+	assert.Empty(t, deletedIDs)
+	require.Len(t, updatedDefs, 1)
+	assert.Equal(t, storex.RedirectSource("/old"), updatedDefs[0].Source)
+
+	assert.Equal(t, storex.RedirectTarget("/campaign"), oldRedirects["/manual"].Target, "manual redirects are not changed")
+	assert.Equal(t, storex.RedirectTarget("/old"), oldRedirects["/locked"].Target, "locked redirects are not changed")
+	assert.Equal(t, storex.RedirectTarget("/old"), oldRedirects["/chain"].Target, "locked redirects are not flattened")
+
+	require.Len(t, conflicts, 2, "identical definitions are no conflict")
+	assert.ElementsMatch(t, []*storex.RedirectConflict{
+		{Source: "/manual", ExistingID: "1", ExistingTarget: "/campaign", RejectedTarget: "/moved", Reason: storex.ConflictReasonManual},
+		{Source: "/locked", ExistingID: "2", ExistingTarget: "/old", RejectedTarget: "/moved", Reason: storex.ConflictReasonLocked},
+	}, conflicts)
+}
+
 func Test_HasCycle_DetectsCycle(t *testing.T) {
 	t.Parallel()
 
@@ -333,3 +510,28 @@ func Test_HasCycle_RepeatingNodeButNoCycle(t *testing.T) {
 
 	assert.False(t, utilsx.HasCycle("/x", "/b", redirects))
 }
+
+func Test_ConsolidateRedirectDefinitions_NoFlatten(t *testing.T) {
+	t.Parallel()
+
+	oldRedirects := storex.RedirectDefinitions{
+		"/a": {ID: "1", Source: "/a", Target: "/b", RedirectionType: storex.RedirectionTypeAutomatic},
+		"/x": {ID: "2", Source: "/x", Target: "/y", RedirectionType: storex.RedirectionTypeAutomatic},
+	}
+
+	newRedirects := []*storex.RedirectDefinition{
+		{ID: "3", Source: "/b", Target: "/c", RedirectionType: storex.RedirectionTypeAutomatic, NoFlatten: true},
+		{ID: "4", Source: "/y", Target: "/z", RedirectionType: storex.RedirectionTypeAutomatic},
+	}
+
+	currentNodes := map[string]*content.RepoNode{
+		"c": {ID: "c", URI: "/c"},
+		"z": {ID: "z", URI: "/z"},
+	}
+
+	_, _, _ = utilsx.ConsolidateRedirectDefinitions(zap.NewNop(), newRedirects, oldRedirects, currentNodes)
+
+	assert.Equal(t, storex.RedirectTarget("/b"), oldRedirects["/a"].Target, "chains through hops with NoFlatten are kept")
+	assert.Equal(t, storex.RedirectTarget("/z"), oldRedirects["/x"].Target)
+	assert.Equal(t, storex.RedirectTarget("/y"), oldRedirects["/x"].OriginalTarget, "the intended target is kept")
+}
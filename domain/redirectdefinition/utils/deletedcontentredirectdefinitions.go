@@ -0,0 +1,135 @@
+package redirectdefinitionutils
+
+import (
+	"time"
+
+	"github.com/foomo/contentserver/content"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"go.uber.org/zap"
+)
+
+// DeletedContentRedirectDefinitions generates definitions for the URIs of nodes which are missing from the new content tree.
+// Depending on the policy the URI is redirected to the nearest surviving ancestor, to a fallback target or answered with 410.
+// The definitions are marked to be reviewed. As for moved nodes, the automatic redirect policy for the MimeType
+// of the deleted node sets the initial stale state and skips nodes.
+func DeletedContentRedirectDefinitions(
+	l *zap.Logger,
+	oldMap, newMap map[string]*content.RepoNode,
+	oldParentIDs map[string]string,
+	dimension storex.Dimension,
+	autoRedirectPolicyFunc providerx.AutoRedirectPolicyProviderFunc,
+	policyFunc providerx.DeletedContentPolicyProviderFunc,
+) []*storex.RedirectDefinition {
+	// without a new tree everything would look deleted
+	if len(oldMap) == 0 || len(newMap) == 0 || autoRedirectPolicyFunc == nil || policyFunc == nil {
+		return nil
+	}
+
+	newURIs := make(map[string]struct{}, len(newMap))
+	for _, node := range newMap {
+		newURIs[canonicalURI(node.URI)] = struct{}{}
+	}
+
+	redirects := []*storex.RedirectDefinition{}
+
+	for oldNodeID, oldNode := range oldMap {
+		if _, ok := newMap[oldNodeID]; ok || oldNode.URI == "" {
+			continue
+		}
+
+		source := canonicalURI(oldNode.URI)
+		if _, ok := newURIs[source]; ok {
+			// the uri is served by another node now
+			continue
+		}
+
+		autoRedirectPolicy := autoRedirectPolicyFunc(dimension, oldNode.MimeType)
+		if autoRedirectPolicy.Skip {
+			continue
+		}
+
+		policy := policyFunc(dimension, oldNode.MimeType)
+		if policy.Strategy == storex.DeletedContentStrategyNone {
+			continue
+		}
+
+		target := policy.FallbackTarget
+		if policy.Strategy == storex.DeletedContentStrategyAncestor {
+			if ancestor := nearestSurvivingAncestor(oldNodeID, oldParentIDs, newMap); ancestor != nil {
+				target = storex.RedirectTarget(canonicalURI(ancestor.URI))
+			}
+		}
+
+		if policy.Strategy == storex.DeletedContentStrategyGone {
+			target = ""
+		}
+
+		code := policy.RedirectCode()
+		if target == "" {
+			code = storex.RedirectCodeGone
+		}
+
+		l.Debug("creating redirect for deleted content",
+			zap.String("contentId", oldNodeID),
+			zap.String("source", source),
+			zap.String("target", string(target)),
+			zap.Int("code", int(code)),
+		)
+
+		redirects = append(redirects, &storex.RedirectDefinition{
+			ID:              storex.NewEntityID(),
+			ContentID:       oldNodeID,
+			Source:          storex.RedirectSource(source),
+			Target:          target,
+			Code:            code,
+			RespectParams:   true,
+			TransferParams:  code.IsRedirection(),
+			RedirectionType: storex.RedirectionTypeAutomatic,
+			Dimension:       dimension,
+			Updated:         storex.NewDateTime(time.Now()),
+			LastUpdatedBy:   "System",
+			Stale:           autoRedirectPolicy.Stale,
+			NeedsReview:     true,
+		})
+	}
+
+	return redirects
+}
+
+// nearestSurvivingAncestor walks up the old tree and returns the first ancestor in the new tree
+func nearestSurvivingAncestor(nodeID string, oldParentIDs map[string]string, newMap map[string]*content.RepoNode) *content.RepoNode {
+	visited := map[string]struct{}{}
+
+	for parentID, ok := oldParentIDs[nodeID]; ok; parentID, ok = oldParentIDs[parentID] {
+		if _, seen := visited[parentID]; seen {
+			return nil
+		}
+
+		visited[parentID] = struct{}{}
+
+		if node, exists := newMap[parentID]; exists && node.URI != "" {
+			return node
+		}
+	}
+
+	return nil
+}
+
+// CreateParentIDMap recursively maps the IDs of all nodes of the tree to the ID of their parent.
+func CreateParentIDMap(node *content.RepoNode, parentIDs map[string]string) map[string]string {
+	if node == nil {
+		return parentIDs
+	}
+
+	for _, child := range node.Nodes {
+		if child == nil {
+			continue
+		}
+
+		parentIDs[child.ID] = node.ID
+		parentIDs = CreateParentIDMap(child, parentIDs)
+	}
+
+	return parentIDs
+}
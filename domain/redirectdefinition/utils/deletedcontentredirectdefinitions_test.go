@@ -0,0 +1,108 @@
+package redirectdefinitionutils_test
+
+import (
+	"testing"
+
+	"github.com/foomo/contentserver/content"
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	utilsx "github.com/foomo/redirects/v2/domain/redirectdefinition/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_DeletedContentRedirectDefinitions(t *testing.T) {
+	t.Parallel()
+
+	oldTree := &content.RepoNode{
+		ID:  "root",
+		URI: "/",
+		Nodes: map[string]*content.RepoNode{
+			"shoes": {ID: "shoes", URI: "/shoes", MimeType: "category", Nodes: map[string]*content.RepoNode{
+				"sneakers": {ID: "sneakers", URI: "/shoes/sneakers", MimeType: "category", Nodes: map[string]*content.RepoNode{
+					"red": {ID: "red", URI: "/shoes/sneakers/red", MimeType: "product"},
+				}},
+				"boots": {ID: "boots", URI: "/shoes/boots", MimeType: "category"},
+			}},
+			"press": {ID: "press", URI: "/press", MimeType: "article"},
+			"legal": {ID: "legal", URI: "/legal", MimeType: "article"},
+			"sale":  {ID: "sale", URI: "/sale", MimeType: "campaign"},
+			"promo": {ID: "promo", URI: "/promo", MimeType: "teaser"},
+		},
+	}
+	newTree := &content.RepoNode{
+		ID:  "root",
+		URI: "/",
+		Nodes: map[string]*content.RepoNode{
+			"shoes":   {ID: "shoes", URI: "/schuhe", MimeType: "category"},
+			"imprint": {ID: "imprint", URI: "/legal", MimeType: "article"},
+		},
+	}
+
+	definitions := utilsx.DeletedContentRedirectDefinitions(
+		zap.NewNop(),
+		utilsx.CreateFlatRepoNodeMap(oldTree, map[string]*content.RepoNode{}),
+		utilsx.CreateFlatRepoNodeMap(newTree, map[string]*content.RepoNode{}),
+		utilsx.CreateParentIDMap(oldTree, map[string]string{}),
+		"shop-de",
+		func(dimension storex.Dimension, mimeType string) storex.AutoRedirectPolicy {
+			assert.Equal(t, storex.Dimension("shop-de"), dimension)
+
+			policy := storex.DefaultAutoRedirectPolicy(false)
+			policy.Stale = mimeType == "article"
+			policy.Skip = mimeType == "teaser"
+
+			return policy
+		},
+		func(dimension storex.Dimension, mimeType string) storex.DeletedContentPolicy {
+			assert.Equal(t, storex.Dimension("shop-de"), dimension)
+
+			switch mimeType {
+			case "category", "product":
+				return storex.DeletedContentPolicy{Strategy: storex.DeletedContentStrategyAncestor}
+			case "article":
+				return storex.DeletedContentPolicy{Strategy: storex.DeletedContentStrategyFallback, FallbackTarget: "/", Code: storex.RedirectCodeFound}
+			case "campaign", "teaser":
+				return storex.DeletedContentPolicy{Strategy: storex.DeletedContentStrategyGone}
+			default:
+				return storex.DeletedContentPolicy{}
+			}
+		},
+	)
+
+	bySource := map[storex.RedirectSource]*storex.RedirectDefinition{}
+	for _, def := range definitions {
+		assert.True(t, def.NeedsReview)
+		assert.Equal(t, storex.RedirectionTypeAutomatic, def.RedirectionType)
+		bySource[def.Source] = def
+	}
+
+	// /legal is served by another node, the moved /shoes is handled by the automatic redirects
+	// and /promo is skipped by the automatic redirect policy
+	require.Len(t, bySource, 5)
+	assert.Equal(t, storex.RedirectTarget("/schuhe"), bySource["/shoes/sneakers"].Target)
+	assert.Equal(t, storex.RedirectTarget("/schuhe"), bySource["/shoes/sneakers/red"].Target)
+	assert.Equal(t, storex.RedirectTarget("/schuhe"), bySource["/shoes/boots"].Target)
+	assert.Equal(t, storex.RedirectCodePermanent, bySource["/shoes/boots"].Code)
+	assert.Equal(t, storex.RedirectTarget("/"), bySource["/press"].Target)
+	assert.Equal(t, storex.RedirectCodeFound, bySource["/press"].Code)
+	assert.True(t, bySource["/press"].Stale)
+	assert.False(t, bySource["/shoes/boots"].Stale)
+	assert.Equal(t, storex.RedirectTarget(""), bySource["/sale"].Target)
+	assert.Equal(t, storex.RedirectCodeGone, bySource["/sale"].Code)
+
+	// no new tree, nothing is considered deleted
+	assert.Empty(t, utilsx.DeletedContentRedirectDefinitions(
+		zap.NewNop(),
+		utilsx.CreateFlatRepoNodeMap(oldTree, map[string]*content.RepoNode{}),
+		map[string]*content.RepoNode{},
+		utilsx.CreateParentIDMap(oldTree, map[string]string{}),
+		"shop-de",
+		func(_ storex.Dimension, _ string) storex.AutoRedirectPolicy {
+			return storex.DefaultAutoRedirectPolicy(false)
+		},
+		func(_ storex.Dimension, _ string) storex.DeletedContentPolicy {
+			return storex.DeletedContentPolicy{Strategy: storex.DeletedContentStrategyGone}
+		},
+	))
+}
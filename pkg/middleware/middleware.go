@@ -0,0 +1,67 @@
+package redirectmiddleware
+
+import (
+	"net/http"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+)
+
+// Middleware returns a standard net/http middleware performing the redirects of the provider
+func Middleware(provider providerx.RedirectsProviderInterface, opts ...Option) func(next http.Handler) http.Handler {
+	o := NewOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// only get request will ever be in need of redirects
+			if r.Method == http.MethodGet {
+				redirect, err := provider.Process(r)
+				if err != nil {
+					o.onError(r, err)
+				}
+
+				if redirect != nil {
+					o.onRedirect(r, redirect)
+					o.writeRedirect(w, r, redirect)
+
+					return
+				}
+
+				if err == nil {
+					o.onMiss(r)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Handler returns a standalone handler performing the redirects of the provider,
+// requests without redirect are answered with 404
+func Handler(provider providerx.RedirectsProviderInterface, opts ...Option) http.Handler {
+	return Middleware(provider, opts...)(http.NotFoundHandler())
+}
+
+func (o *Options) writeRedirect(w http.ResponseWriter, r *http.Request, redirect *storex.Redirect) {
+	for key, values := range o.ResponseHeaders[redirect.Code] {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	if o.RedirectIDHeader && redirect.DefinitionID != "" {
+		w.Header().Set(HeaderRedirectID, string(redirect.DefinitionID))
+	}
+
+	// codes like 410 gone are answered without location
+	if !redirect.Code.IsRedirection() {
+		w.WriteHeader(int(redirect.Code))
+		return
+	}
+
+	http.Redirect(w, r, string(redirect.Response), int(redirect.Code))
+}
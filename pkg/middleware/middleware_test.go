@@ -0,0 +1,90 @@
+package redirectmiddleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	middlewarex "github.com/foomo/redirects/v2/pkg/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+type providerMock map[string]*storex.Redirect
+
+func (p providerMock) Start(_ context.Context) error { return nil }
+func (p providerMock) Close(_ context.Context) error { return nil }
+func (p providerMock) Process(r *http.Request) (*storex.Redirect, error) {
+	if r.URL.Path == "/error" {
+		return nil, errors.New("error")
+	}
+
+	return p[r.URL.Path], nil
+}
+
+func Test_Middleware(t *testing.T) {
+	t.Parallel()
+
+	provider := providerMock{
+		"/old":  {Response: "/new", Code: storex.RedirectCodePermanent, DefinitionID: "1"},
+		"/gone": {Code: storex.RedirectCodeGone, DefinitionID: "2"},
+	}
+
+	var redirects, misses, errs int
+
+	handler := middlewarex.Middleware(provider,
+		middlewarex.WithCacheControl(storex.RedirectCodePermanent, "max-age=3600"),
+		middlewarex.WithRedirectIDHeader(),
+		middlewarex.WithOnRedirect(func(_ *http.Request, _ *storex.Redirect) { redirects++ }),
+		middlewarex.WithOnMiss(func(_ *http.Request) { misses++ }),
+		middlewarex.WithOnError(func(_ *http.Request, _ error) { errs++ }),
+	)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/old", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/new", w.Header().Get("Location"))
+	assert.Equal(t, "max-age=3600", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "1", w.Header().Get(middlewarex.HeaderRedirectID))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gone", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/old", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.Equal(t, 2, redirects)
+	assert.Equal(t, 1, misses)
+	assert.Equal(t, 1, errs)
+}
+
+func Test_Handler(t *testing.T) {
+	t.Parallel()
+
+	handler := middlewarex.Handler(providerMock{
+		"/old": {Response: "/new", Code: storex.RedirectCodeFound},
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/old", nil))
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,113 @@
+package redirectmiddleware
+
+import (
+	"net/http"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"go.uber.org/zap"
+)
+
+const HeaderRedirectID = "X-Redirect-Id"
+
+type (
+	// OnRedirectFunc is called before a redirect is written
+	OnRedirectFunc func(r *http.Request, redirect *storex.Redirect)
+	// OnMissFunc is called if no redirect is necessary
+	OnMissFunc func(r *http.Request)
+	// OnErrorFunc is called if the redirect processing failed, the request is passed on
+	OnErrorFunc func(r *http.Request, err error)
+)
+
+type Options struct {
+	Logger           *zap.Logger
+	ResponseHeaders  map[storex.RedirectCode]http.Header
+	RedirectIDHeader bool
+	OnRedirect       []OnRedirectFunc
+	OnMiss           []OnMissFunc
+	OnError          []OnErrorFunc
+}
+
+type Option func(o *Options)
+
+// NewOptions returns the default options
+func NewOptions() *Options {
+	return &Options{
+		Logger:          zap.NewNop(),
+		ResponseHeaders: map[storex.RedirectCode]http.Header{},
+	}
+}
+
+// WithLogger sets the logger used for errors and debug output
+func WithLogger(l *zap.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// WithResponseHeader adds a header to all responses with the given code
+func WithResponseHeader(code storex.RedirectCode, key, value string) Option {
+	return func(o *Options) {
+		if _, ok := o.ResponseHeaders[code]; !ok {
+			o.ResponseHeaders[code] = http.Header{}
+		}
+
+		o.ResponseHeaders[code].Add(key, value)
+	}
+}
+
+// WithCacheControl sets the Cache-Control header for all responses with the given code
+func WithCacheControl(code storex.RedirectCode, value string) Option {
+	return WithResponseHeader(code, "Cache-Control", value)
+}
+
+// WithRedirectIDHeader adds the id of the matched definition as X-Redirect-Id header
+func WithRedirectIDHeader() Option {
+	return func(o *Options) {
+		o.RedirectIDHeader = true
+	}
+}
+
+// WithOnRedirect adds a callback called with the request and the redirect before the redirect is written
+func WithOnRedirect(fn OnRedirectFunc) Option {
+	return func(o *Options) {
+		o.OnRedirect = append(o.OnRedirect, fn)
+	}
+}
+
+// WithOnMiss adds a callback called with the request if no redirect is necessary
+func WithOnMiss(fn OnMissFunc) Option {
+	return func(o *Options) {
+		o.OnMiss = append(o.OnMiss, fn)
+	}
+}
+
+// WithOnError adds a callback called with the request and the error if processing the redirect failed
+func WithOnError(fn OnErrorFunc) Option {
+	return func(o *Options) {
+		o.OnError = append(o.OnError, fn)
+	}
+}
+
+func (o *Options) onRedirect(r *http.Request, redirect *storex.Redirect) {
+	o.Logger.Debug("performing redirect", zap.String("target", string(redirect.Response)), zap.Int("code", int(redirect.Code)))
+
+	for _, fn := range o.OnRedirect {
+		fn(r, redirect)
+	}
+}
+
+func (o *Options) onMiss(r *http.Request) {
+	for _, fn := range o.OnMiss {
+		fn(r)
+	}
+}
+
+func (o *Options) onError(r *http.Request, err error) {
+	// just log the error and continue with the rest of the middlewares
+	// redirect problems should never impair the gateway
+	o.Logger.Info("error occurred during redirect processing", zap.Error(err), zap.String("uri", r.URL.RequestURI()))
+
+	for _, fn := range o.OnError {
+		fn(r, err)
+	}
+}
@@ -87,7 +87,13 @@ func (c *UpdateSignal) Subscribe() (chan *nats.Msg, error) {
 	return c.messages, nil
 }
 
+// Publish notifies the subscribers about an update, it is a no-op on a nil signal,
+// e.g. for tools running without NATS
 func (c *UpdateSignal) Publish() error {
+	if c == nil {
+		return nil
+	}
+
 	payload, err := json.Marshal(struct{}{})
 	if err != nil {
 		return err
@@ -0,0 +1,205 @@
+package redirectprovider
+
+import (
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/pkg/errors"
+)
+
+// ExclusionScope defines which part of the redirect processing an exclusion rule applies to
+type ExclusionScope string
+
+const (
+	// ExclusionScopeAll excludes the request from definition matching and standard redirects
+	ExclusionScopeAll ExclusionScope = "all"
+	// ExclusionScopeDefinitions excludes the request from definition matching only
+	ExclusionScopeDefinitions ExclusionScope = "definitions"
+	// ExclusionScopeStandard excludes the request from standard redirects only
+	ExclusionScopeStandard ExclusionScope = "standard"
+)
+
+func (s ExclusionScope) Valid() bool {
+	switch s {
+	case ExclusionScopeAll, ExclusionScopeDefinitions, ExclusionScopeStandard:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s ExclusionScope) definitions() bool {
+	return s == ExclusionScopeAll || s == ExclusionScopeDefinitions
+}
+
+func (s ExclusionScope) standard() bool {
+	return s == ExclusionScopeAll || s == ExclusionScopeStandard
+}
+
+// HeaderCondition matches a request header, a nil Value only requires the header to be present
+type HeaderCondition struct {
+	Name  string
+	Value *regexp.Regexp
+}
+
+// ExclusionRule defines requests the redirect processing should leave alone.
+// A rule matches if any of its conditions matches.
+type ExclusionRule struct {
+	// Scope of the rule, defaults to ExclusionScopeAll
+	Scope ExclusionScope
+	// Homepage excludes requests to "/"
+	Homepage bool
+	// Prefixes are matched against the request uri
+	Prefixes []string
+	// Contains are matched against the request uri
+	Contains []string
+	// Globs are matched against the request path, see path.Match
+	Globs []string
+	// Regexes are matched against the request uri
+	Regexes []*regexp.Regexp
+	// Extensions are matched against the file extension of the request path, e.g. ".js"
+	Extensions []string
+	// Headers are matched against the request headers
+	Headers []HeaderCondition
+}
+
+// DefaultExclusionRules returns the rules applied if no exclusion rules are configured
+func DefaultExclusionRules() []ExclusionRule {
+	return []ExclusionRule{
+		{
+			Scope:    ExclusionScopeAll,
+			Homepage: true,
+			Prefixes: []string{"/services", "/gateway"},
+			Contains: []string{"/_next/"},
+		},
+	}
+}
+
+// StaticAssetExtensions is a list of common file extensions of static assets,
+// to be used with ExclusionRule.Extensions
+func StaticAssetExtensions() []string {
+	return []string{
+		".css", ".js", ".map", ".json", ".xml", ".txt",
+		".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".avif", ".ico",
+		".woff", ".woff2", ".ttf", ".eot",
+	}
+}
+
+func (e ExclusionRule) validate() error {
+	if e.Scope != "" && !e.Scope.Valid() {
+		return errors.Errorf("invalid exclusion scope '%s'", e.Scope)
+	}
+
+	for _, glob := range e.Globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return errors.Wrapf(err, "invalid exclusion glob '%s'", glob)
+		}
+	}
+
+	for _, regex := range e.Regexes {
+		if regex == nil {
+			return errors.New("invalid exclusion regex: nil")
+		}
+	}
+
+	for _, header := range e.Headers {
+		if header.Name == "" {
+			return errors.New("invalid exclusion header condition: missing name")
+		}
+	}
+
+	return nil
+}
+
+func (e ExclusionRule) scope() ExclusionScope {
+	if e.Scope == "" {
+		return ExclusionScopeAll
+	}
+
+	return e.Scope
+}
+
+// Matches returns true if the request matches any condition of the rule
+func (e ExclusionRule) Matches(r *http.Request) bool {
+	request := storex.RedirectRequest(r.URL.RequestURI())
+	requestPath := r.URL.Path
+
+	if e.Homepage {
+		if isHome, err := request.IsHomepage(); err == nil && isHome {
+			return true
+		}
+	}
+
+	if request.HasPrefix(e.Prefixes) || request.Contains(e.Contains) {
+		return true
+	}
+
+	for _, glob := range e.Globs {
+		if matched, _ := path.Match(glob, requestPath); matched {
+			return true
+		}
+	}
+
+	for _, regex := range e.Regexes {
+		if regex.MatchString(request.String()) {
+			return true
+		}
+	}
+
+	if ext := strings.ToLower(path.Ext(requestPath)); ext != "" {
+		for _, extension := range e.Extensions {
+			if strings.ToLower(extension) == ext {
+				return true
+			}
+		}
+	}
+
+	for _, header := range e.Headers {
+		values := r.Header.Values(header.Name)
+		for _, value := range values {
+			if header.Value == nil || header.Value.MatchString(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// WithExclusionRules replaces the DefaultExclusionRules with the given rules
+func WithExclusionRules(rules ...ExclusionRule) RedirectsProviderOption {
+	return func(provider *RedirectsProvider) error {
+		for _, rule := range rules {
+			if err := rule.validate(); err != nil {
+				return err
+			}
+		}
+
+		provider.exclusionRules = rules
+
+		return nil
+	}
+}
+
+// isExcluded checks the exclusion rules and returns whether the request is excluded
+// from definition matching and from standard redirects
+func (p *RedirectsProvider) isExcluded(r *http.Request) (bool, bool) {
+	var excludeDefinitions, excludeStandard bool
+
+	for _, rule := range p.exclusionRules {
+		scope := rule.scope()
+		if (excludeDefinitions || !scope.definitions()) && (excludeStandard || !scope.standard()) {
+			continue
+		}
+
+		if rule.Matches(r) {
+			excludeDefinitions = excludeDefinitions || scope.definitions()
+			excludeStandard = excludeStandard || scope.standard()
+		}
+	}
+
+	return excludeDefinitions, excludeStandard
+}
@@ -0,0 +1,87 @@
+package redirectprovider_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ExclusionRule_Matches(t *testing.T) {
+	t.Parallel()
+
+	rule := providerx.ExclusionRule{
+		Homepage:   true,
+		Prefixes:   []string{"/services"},
+		Contains:   []string{"/_next/"},
+		Globs:      []string{"/preview/*"},
+		Regexes:    []*regexp.Regexp{regexp.MustCompile(`^/api/v[0-9]+/`)},
+		Extensions: providerx.StaticAssetExtensions(),
+		Headers: []providerx.HeaderCondition{
+			{Name: "X-Purpose", Value: regexp.MustCompile("^preview$")},
+			{Name: "X-No-Redirect"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		uri     string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "homepage", uri: "/", want: true},
+		{name: "prefix", uri: "/services/foo", want: true},
+		{name: "contains", uri: "/foo/_next/static", want: true},
+		{name: "glob", uri: "/preview/page", want: true},
+		{name: "glob no match", uri: "/preview/page/child", want: false},
+		{name: "regex", uri: "/api/v2/foo", want: true},
+		{name: "extension", uri: "/assets/Logo.PNG", want: true},
+		{name: "header value", uri: "/foo", headers: map[string]string{"X-Purpose": "preview"}, want: true},
+		{name: "header value no match", uri: "/foo", headers: map[string]string{"X-Purpose": "prefetch"}, want: false},
+		{name: "header present", uri: "/foo", headers: map[string]string{"X-No-Redirect": "1"}, want: true},
+		{name: "no match", uri: "/foo/bar", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, tt.uri, nil)
+			for key, value := range tt.headers {
+				r.Header.Set(key, value)
+			}
+
+			assert.Equal(t, tt.want, rule.Matches(r))
+		})
+	}
+}
+
+func Test_ExclusionRules_Scope(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider(t,
+		providerx.WithUseStandardRedirects(),
+		providerx.WithExclusionRules(
+			providerx.ExclusionRule{
+				Scope:    providerx.ExclusionScopeStandard,
+				Prefixes: []string{"/Products"},
+			},
+		),
+	)
+
+	// excluded from standard redirects only
+	r := httptest.NewRequest(http.MethodGet, "/Products/ABC", nil)
+	redirect, err := provider.Process(r)
+	assert.NoError(t, err)
+	assert.Nil(t, redirect)
+
+	// not excluded
+	r = httptest.NewRequest(http.MethodGet, "/About", nil)
+	redirect, err = provider.Process(r)
+	assert.NoError(t, err)
+	assert.Equal(t, storex.RedirectResponse("/about"), redirect.Response)
+}
@@ -0,0 +1,24 @@
+package redirectprovider
+
+import (
+	"context"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+)
+
+// FilteredRedirectsProviderFunc loads the redirects in the scope of the filter in the compact format,
+// e.g. HTTPInternalServiceGoTSRPCClient.GetFilteredRedirects
+type FilteredRedirectsProviderFunc func(ctx context.Context, filter *storex.RedirectsFilter) (storex.CompactRedirects, error, error)
+
+// NewFilteredRedirectsProviderFunc returns a RedirectsProviderFunc loading only the dimensions or the site
+// the DimensionProviderFunc of the gateway can produce
+func NewFilteredRedirectsProviderFunc(providerFunc FilteredRedirectsProviderFunc, filter storex.RedirectsFilter) RedirectsProviderFunc {
+	return func(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+		redirects, err, clientErr := providerFunc(ctx, &filter)
+		if err != nil || clientErr != nil {
+			return nil, err, clientErr
+		}
+
+		return redirects.Definitions(), nil, nil
+	}
+}
@@ -0,0 +1,48 @@
+package redirectprovider_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_NewFilteredRedirectsProviderFunc(t *testing.T) {
+	t.Parallel()
+
+	var requested *storex.RedirectsFilter
+
+	provider := providerx.NewProvider(
+		zap.NewNop(),
+		providerx.NewFilteredRedirectsProviderFunc(
+			func(_ context.Context, filter *storex.RedirectsFilter) (storex.CompactRedirects, error, error) {
+				requested = filter
+
+				return storex.NewCompactRedirects(map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+					"shop-de": {
+						"/alt": {ID: "1", Source: "/alt", Target: "/neu", Code: storex.RedirectCodePermanent, Dimension: "shop-de"},
+					},
+				}), nil, nil
+			},
+			storex.RedirectsFilter{Site: "shop"},
+		),
+		func(_ *http.Request) (storex.Dimension, error) {
+			return "shop-de", nil
+		},
+		nil,
+	)
+	require.NoError(t, provider.Start(t.Context()))
+	assert.Equal(t, &storex.RedirectsFilter{Site: "shop"}, requested)
+
+	redirect, err := provider.Process(httptest.NewRequest(http.MethodGet, "/alt", nil))
+	require.NoError(t, err)
+	require.NotNil(t, redirect)
+	assert.Equal(t, storex.RedirectResponse("/neu"), redirect.Response)
+	assert.Equal(t, storex.EntityID("1"), redirect.DefinitionID)
+}
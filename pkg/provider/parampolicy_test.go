@@ -0,0 +1,87 @@
+package redirectprovider_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_ParamPolicy(t *testing.T) {
+	t.Parallel()
+
+	definitions := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+		"shop-de": {
+			"/sale": {
+				ID: "1", Source: "/sale", Target: "/offers?campaign=summer", Code: storex.RedirectCodePermanent,
+				RespectParams: true, TransferParams: true,
+			},
+			"/deals": {
+				ID: "2", Source: "/deals", Target: "/offers?campaign=summer", Code: storex.RedirectCodePermanent,
+				RespectParams: true, TransferParams: true,
+				ParamPolicy: &storex.ParamPolicy{MergeStrategy: storex.ParamMergeStrategyRequestWins},
+			},
+			"/shoes?color=red": {
+				ID: "3", Source: "/shoes?color=red", Target: "/red-shoes", Code: storex.RedirectCodePermanent,
+				RespectParams: true, TransferParams: true,
+				ParamPolicy: &storex.ParamPolicy{MatchKeys: []string{"color"}, Deny: []string{"color"}},
+			},
+			"/%C3%BCbersicht?color=red": {
+				ID: "4", Source: "/%C3%BCbersicht?color=red", Target: "/overview", Code: storex.RedirectCodePermanent,
+				RespectParams: true,
+				ParamPolicy:   &storex.ParamPolicy{MatchKeys: []string{"color"}},
+			},
+		},
+	}
+
+	provider := providerx.NewProvider(
+		zap.NewNop(),
+		func(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+			return definitions, nil, nil
+		},
+		func(_ *http.Request) (storex.Dimension, error) {
+			return "shop-de", nil
+		},
+		nil,
+		providerx.WithParamPolicyProvider(func(_ storex.Dimension) *storex.ParamPolicy {
+			return &storex.ParamPolicy{Allow: []string{"utm_*", "campaign"}, Deny: []string{"utm_id"}}
+		}),
+	)
+	require.NoError(t, provider.Start(t.Context()))
+
+	tests := []struct {
+		name     string
+		uri      string
+		response storex.RedirectResponse
+	}{
+		{name: "allow and deny", uri: "/sale?utm_source=a&utm_id=1&sid=2", response: "/offers?campaign=summer&utm_source=a"},
+		{name: "target wins", uri: "/sale?campaign=winter", response: "/offers?campaign=summer"},
+		{name: "request wins", uri: "/deals?campaign=winter", response: "/offers?campaign=winter"},
+		{name: "match keys", uri: "/shoes?utm_source=a&color=red", response: "/red-shoes?utm_source=a"},
+		{name: "match keys no match", uri: "/shoes?color=blue"},
+		{name: "match keys escaped path", uri: "/%C3%BCbersicht?utm_source=a&color=red", response: "/overview"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			redirect, err := provider.Process(httptest.NewRequest(http.MethodGet, tt.uri, nil))
+			require.NoError(t, err)
+
+			if tt.response == "" {
+				assert.Nil(t, redirect)
+				return
+			}
+
+			require.NotNil(t, redirect)
+			assert.Equal(t, tt.response, redirect.Response)
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package redirectprovider_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_Process_Prefix(t *testing.T) {
+	t.Parallel()
+
+	provider := providerx.NewProvider(
+		zap.NewNop(),
+		func(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+			return map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+				"shop-de": {
+					"/damen/kleidung":        {Source: "/damen/kleidung", Target: "/damen/bekleidung", Code: storex.RedirectCodePermanent, MatchType: storex.MatchTypePrefix, TransferParams: true},
+					"/damen/kleidung/roecke": {Source: "/damen/kleidung/roecke", Target: "/damen/bekleidung/damenroecke", Code: storex.RedirectCodePermanent},
+				},
+			}, nil, nil
+		},
+		func(_ *http.Request) (storex.Dimension, error) {
+			return "shop-de", nil
+		},
+		nil,
+	)
+	require.NoError(t, provider.Start(t.Context()))
+
+	for uri, expected := range map[string]storex.RedirectResponse{
+		"/damen/kleidung":                 "/damen/bekleidung",
+		"/damen/kleidung/hosen/":          "/damen/bekleidung/hosen",
+		"/damen/kleidung/hosen?farbe=rot": "/damen/bekleidung/hosen?farbe=rot",
+		"/damen/kleidung/roecke":          "/damen/bekleidung/damenroecke",
+		"/damen/kleidungsstuecke":         "",
+	} {
+		redirect, err := provider.Process(httptest.NewRequest(http.MethodGet, uri, nil))
+		require.NoError(t, err)
+
+		if expected == "" {
+			assert.Nil(t, redirect, uri)
+			continue
+		}
+
+		require.NotNil(t, redirect, uri)
+		assert.Equal(t, expected, redirect.Response, uri)
+	}
+}
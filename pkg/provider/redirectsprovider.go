@@ -2,13 +2,16 @@ package redirectprovider
 
 import (
 	"context"
+	"maps"
 	"net/http"
-	"net/url"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	keellog "github.com/foomo/keel/log"
 	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	telemetryx "github.com/foomo/redirects/v2/pkg/telemetry"
 	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
@@ -24,8 +27,13 @@ type SiteIdentifierProviderFunc func(r *http.Request) (storex.Site, error)
 type RestrictedSourcesProviderFunc func() []string
 type IsAutomaticRedirectInitiallyStaleProviderFunc func() bool
 type UserProviderFunc func(ctx context.Context) string
+type PrivilegedUserProviderFunc func(ctx context.Context) bool
 type RedirectsProviderFunc func(ctx context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error)
 type MatcherFunc func(r *http.Request) (*storex.RedirectDefinition, error)
+type AllowedHostsProviderFunc func(dimension storex.Dimension) storex.AllowedHosts
+type ParamPolicyProviderFunc func(dimension storex.Dimension) *storex.ParamPolicy
+type AutoRedirectPolicyProviderFunc func(dimension storex.Dimension, mimeType string) storex.AutoRedirectPolicy
+type DeletedContentPolicyProviderFunc func(dimension storex.Dimension, mimeType string) storex.DeletedContentPolicy
 
 type RedirectsProviderOption func(provider *RedirectsProvider) error
 
@@ -33,13 +41,18 @@ type RedirectsProvider struct {
 	sync.RWMutex
 	l                     *zap.Logger
 	redirects             map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition
+	matchKeys             map[storex.Dimension][][]string
 	redirectsProviderFunc RedirectsProviderFunc
 	dimensionProviderFunc DimensionProviderFunc
 	updateChannel         chan *nats.Msg
 
 	// optional features
-	matcherFuncs         []MatcherFunc
-	useStandardRedirects bool
+	matcherFuncs             []MatcherFunc
+	standardRedirectRuleSets []StandardRedirectRuleSet
+	exclusionRules           []ExclusionRule
+	allowedHostsProviderFunc AllowedHostsProviderFunc
+	paramPolicyProviderFunc  ParamPolicyProviderFunc
+	metrics                  *providerMetrics
 }
 
 func NewProvider(
@@ -54,7 +67,9 @@ func NewProvider(
 		redirectsProviderFunc: providerFunc,
 		dimensionProviderFunc: dimensionProviderFunc,
 		updateChannel:         updateChannel,
+		exclusionRules:        DefaultExclusionRules(),
 	}
+	provider.metrics = newProviderMetrics(provider)
 
 	for _, opt := range options {
 		if err := opt(provider); err != nil {
@@ -78,13 +93,39 @@ func WithMatcherFuncs(matcherFuncs ...MatcherFunc) RedirectsProviderOption {
 	}
 }
 
-func WithUseStandardRedirects() RedirectsProviderOption {
+// WithAllowedHostsProvider sets the hosts absolute redirect targets may point to,
+// without it absolute targets are only logged, see storex.AllowedHosts.WarnOnly
+func WithAllowedHostsProvider(allowedHostsProviderFunc AllowedHostsProviderFunc) RedirectsProviderOption {
 	return func(provider *RedirectsProvider) error {
-		provider.useStandardRedirects = true
+		if allowedHostsProviderFunc == nil {
+			return errors.New("no allowed hosts provider func provided")
+		}
+
+		provider.allowedHostsProviderFunc = allowedHostsProviderFunc
+
 		return nil
 	}
 }
 
+// WithParamPolicyProvider sets the default parameter policy per dimension,
+// used for definitions without a parameter policy of their own
+func WithParamPolicyProvider(paramPolicyProviderFunc ParamPolicyProviderFunc) RedirectsProviderOption {
+	return func(provider *RedirectsProvider) error {
+		if paramPolicyProviderFunc == nil {
+			return errors.New("no param policy provider func provided")
+		}
+
+		provider.paramPolicyProviderFunc = paramPolicyProviderFunc
+
+		return nil
+	}
+}
+
+// WithUseStandardRedirects enables standard redirects with the DefaultStandardRedirectRuleSet
+func WithUseStandardRedirects() RedirectsProviderOption {
+	return WithStandardRedirectRuleSets(DefaultStandardRedirectRuleSet())
+}
+
 func (p *RedirectsProvider) Start(ctx context.Context) error {
 	if err := p.loadRedirects(ctx); err != nil {
 		return err
@@ -107,77 +148,98 @@ func (p *RedirectsProvider) Start(ctx context.Context) error {
 }
 
 func (p *RedirectsProvider) Close(_ context.Context) error {
-	return nil
+	return p.metrics.unregister()
 }
 
 func (p *RedirectsProvider) Process(r *http.Request) (*storex.Redirect, error) {
+	ctx, span := telemetryx.Tracer().Start(r.Context(), "RedirectsProvider.Process")
+	defer span.End()
+
+	start := time.Now()
+	redirect, result, err := p.process(r.WithContext(ctx))
+	p.metrics.recordProcess(ctx, span, redirect, result, err, time.Since(start))
+
+	return redirect, err
+}
+
+// process finds the redirect for the request, the result describes how for telemetry
+func (p *RedirectsProvider) process(r *http.Request) (*storex.Redirect, processResult, error) {
 	l := keellog.With(p.l, zap.String("method", "Process"))
 
+	var result processResult
+
 	dimension, err := p.dimensionProviderFunc(r)
 	if err != nil {
-		return nil, err
+		return nil, result, err
 	}
 
+	result.dimension = dimension
+
 	// normalize the incoming request
 	// a-z order of get-parameters
 	request, err := normalizeRedirectRequest(r)
 	if err != nil {
 		keellog.WithError(l, err).Error("could not normalize redirect request")
-		return nil, err
+		return nil, result, err
 	}
 
-	// check if the request is on the blacklist
-	if isBlacklisted(request) {
-		l.Debug("request is on black list")
-		return nil, nil //nolint:nilnil // needs refactoring
+	// check if the request is excluded by the exclusion rules
+	excludeDefinitions, excludeStandard := p.isExcluded(request)
+	if excludeDefinitions && excludeStandard {
+		l.Debug("request is excluded")
+
+		result.excluded = true
+
+		return nil, result, nil
 	}
 
-	definition, err := p.matchRedirectDefinition(request, dimension)
-	if err != nil {
-		keellog.WithError(l, err).Error("could not match redirect definition")
-		return nil, err
+	var definition *storex.RedirectDefinition
+	if !excludeDefinitions {
+		definition, result.matchKind, err = p.matchRedirectDefinition(request, dimension)
+		if err != nil {
+			keellog.WithError(l, err).Error("could not match redirect definition")
+			return nil, result, err
+		}
 	}
 
 	// we found a redirect definition and process to create the response
 	if definition != nil {
-		redirect, err := p.createRedirect(request, definition)
+		redirect, err := p.createRedirect(request, definition, dimension)
 		if err != nil {
 			keellog.WithError(l, err).Error("could not create redirect response")
-			return nil, err
+			return nil, result, err
 		}
 
-		return redirect, nil
+		return redirect, result, nil
 	}
 
 	// if we do not find a specific redirect we check if we need to redirect
-	// base on generic rules - no trailing slash/lowercased
+	// based on the standard rules configured for the dimension
 	// only if enabled
-	if p.useStandardRedirects {
-		definition, err = p.checkForStandardRedirect(request)
-		if err != nil {
-			keellog.WithError(l, err).Error("could not check for standard redirect")
-			return nil, err
-		}
+	if len(p.standardRedirectRuleSets) > 0 && !excludeStandard {
+		// the original request is used as the normalized one has no trailing slash
+		definition = p.checkForStandardRedirect(r, dimension)
+		result.matchKind = telemetryx.MatchKindStandard
 	}
 
 	if definition == nil {
 		l.Debug("no redirect necessary")
-		return nil, nil //nolint:nilnil // needs refactoring
+		return nil, result, nil
 	}
 
-	redirect, err := p.createRedirect(request, definition)
+	redirect, err := p.createRedirect(request, definition, dimension)
 	if err != nil {
 		keellog.WithError(l, err).Error("could not create redirect response")
-		return nil, err
+		return nil, result, err
 	}
 
 	l.Debug("redirect based on standard rules")
 
-	return redirect, nil
+	return redirect, result, nil
 }
 
 // matchRedirectDefinition checks if there is a redirect definition matching the request
-func (p *RedirectsProvider) matchRedirectDefinition(r *http.Request, dimension storex.Dimension) (*storex.RedirectDefinition, error) {
+func (p *RedirectsProvider) matchRedirectDefinition(r *http.Request, dimension storex.Dimension) (*storex.RedirectDefinition, telemetryx.MatchKind, error) {
 	l := keellog.With(
 		p.l,
 		zap.String("method", "matchRedirectDefinition"),
@@ -189,15 +251,22 @@ func (p *RedirectsProvider) matchRedirectDefinition(r *http.Request, dimension s
 	// 1. full url from cache
 	definition := p.definitionForDimensionAndSource(dimension, storex.RedirectSource(r.URL.RequestURI()))
 	if definition != nil {
-		return definition, nil
+		return definition, telemetryx.MatchKindExact, nil
 	}
 
-	l.Debug("no cached definition found for full URL, checking without query parameters")
+	l.Debug("no cached definition found for full URL, checking with match keys")
 
 	if strings.Contains(r.URL.RequestURI(), "?") {
-		definition := p.definitionForDimensionAndSource(dimension, storex.RedirectSource(r.URL.Path))
+		definition := p.definitionForMatchKeys(r, dimension)
+		if definition != nil {
+			return definition, telemetryx.MatchKindParams, nil
+		}
+
+		l.Debug("no cached definition found for match keys, checking without query parameters")
+
+		definition = p.definitionForDimensionAndSource(dimension, storex.RedirectSource(r.URL.EscapedPath()))
 		if definition != nil && definition.RespectParams {
-			return definition, nil
+			return definition, telemetryx.MatchKindPath, nil
 		}
 
 		l.Debug("no cached definition found for path with respect to parameters, on check without query parameters")
@@ -205,19 +274,24 @@ func (p *RedirectsProvider) matchRedirectDefinition(r *http.Request, dimension s
 		l.Debug("no query parameters in request, using path only for matching")
 	}
 
-	// 2. full url against regex
+	// 2. longest prefix definition above the path
+	definition = p.definitionForPrefix(dimension, r.URL.EscapedPath())
+	if definition != nil {
+		return definition, telemetryx.MatchKindPrefix, nil
+	}
+
+	// 3. full url against regex
 	definition, err := p.execMatcherFuncs(r)
 	if err != nil {
 		// no need to log anything here as logging is already done in .matcherFuncs
-		return nil, err
+		return nil, "", err
 	}
 
-	return definition, nil
+	return definition, telemetryx.MatchKindMatcher, nil
 }
 
 // definitionForDimensionAndSource retrieves the redirect definition for a given dimension and source
-// it also tries to unescape the source if it is not found directly
-// this is useful for cases where the source might have been URL-encoded - see r.RequestURI() (escaped) vs r.URL.Path (unescaped)
+// sources are stored in canonical form (see storex.CanonicalizeURI), as is the RequestURI() of the normalized request
 func (p *RedirectsProvider) definitionForDimensionAndSource(dimension storex.Dimension, source storex.RedirectSource) *storex.RedirectDefinition {
 	p.RLock()
 	defer p.RUnlock()
@@ -228,43 +302,52 @@ func (p *RedirectsProvider) definitionForDimensionAndSource(dimension storex.Dim
 		return nil
 	}
 
-	// first try to find the definition with the exact source
-	definition, ok := definitions[source]
-	if ok {
-		return definition
-	}
+	return definitions[source]
+}
 
-	// if not found, try to unescape the source and find it again
-	unescapedSource, err := url.PathUnescape(string(source))
-	if err != nil {
+// definitionForPrefix retrieves the longest prefix definition above the path, the returned copy holds the target for the path
+func (p *RedirectsProvider) definitionForPrefix(dimension storex.Dimension, path string) *storex.RedirectDefinition {
+	p.RLock()
+	definitions := p.redirects[dimension]
+	p.RUnlock()
+
+	definition, target := storex.ResolvePrefix(path, func(source storex.RedirectSource) *storex.RedirectDefinition {
+		return definitions[source]
+	})
+	if definition == nil {
 		return nil
 	}
 
-	definition, ok = definitions[storex.RedirectSource(unescapedSource)]
-	if ok {
-		return definition
-	}
+	resolved := *definition
+	resolved.Target = target
 
-	return nil
+	return &resolved
 }
 
-// isBlacklisted define a series of paths/... redirection should leave alone
-func isBlacklisted(r *http.Request) bool {
-	request := storex.RedirectRequest(r.URL.RequestURI())
+// definitionForMatchKeys retrieves the redirect definition for the request query limited to
+// the match keys of the parameter policies, only definitions respecting params are returned
+func (p *RedirectsProvider) definitionForMatchKeys(r *http.Request, dimension storex.Dimension) *storex.RedirectDefinition {
+	p.RLock()
+	matchKeys := p.matchKeys[dimension]
+	p.RUnlock()
 
-	isHome, err := request.IsHomepage()
-	if err != nil {
-		return false
-	}
+	query := r.URL.Query()
+
+	for _, keys := range matchKeys {
+		policy := storex.ParamPolicy{MatchKeys: keys}
 
-	prefixes := []string{"/services", "/gateway"}
+		source := r.URL.EscapedPath()
+		if matchQuery := policy.MatchQuery(query); matchQuery != "" {
+			source += "?" + matchQuery
+		}
 
-	contains := []string{"/_next/"}
-	if isHome || request.HasPrefix(prefixes) || request.Contains(contains) {
-		return true
+		definition := p.definitionForDimensionAndSource(dimension, storex.RedirectSource(source))
+		if definition != nil && definition.RespectParams && slices.Equal(sortedKeys(p.paramPolicy(definition, dimension).MatchKeys), keys) {
+			return definition
+		}
 	}
 
-	return false
+	return nil
 }
 
 // execMatcherFuncs executes the matcher functions
@@ -284,9 +367,10 @@ func (p *RedirectsProvider) execMatcherFuncs(r *http.Request) (*storex.RedirectD
 }
 
 // createRedirect creates a redirect response based on the definition
-func (p *RedirectsProvider) createRedirect(r *http.Request, definition *storex.RedirectDefinition) (*storex.Redirect, error) {
+func (p *RedirectsProvider) createRedirect(r *http.Request, definition *storex.RedirectDefinition, dimension storex.Dimension) (*storex.Redirect, error) {
 	redirect := &storex.Redirect{
-		Code: definition.Code,
+		Code:         definition.Code,
+		DefinitionID: definition.ID,
 	}
 	// if no transfer of parameters is allowed OR the request holds no query
 	// the response is the definition's target
@@ -294,7 +378,7 @@ func (p *RedirectsProvider) createRedirect(r *http.Request, definition *storex.R
 		redirect.Response = storex.RedirectResponse(definition.Target)
 	} else {
 		// merge query strings of the request and the target
-		response, err := mergeQueryStringsFromURLs(r.URL.RequestURI(), string(definition.Target))
+		response, err := mergeQueryStringsFromURLs(r.URL.RequestURI(), string(definition.Target), p.paramPolicy(definition, dimension))
 		if err != nil {
 			keellog.WithError(p.l, err).Error("could not merge the query strings of the requests")
 			return nil, err
@@ -303,34 +387,84 @@ func (p *RedirectsProvider) createRedirect(r *http.Request, definition *storex.R
 		redirect.Response = storex.RedirectResponse(response)
 	}
 
+	// guard against open redirects, e.g. protocol-relative or external targets
+	if redirect.Response != "" || redirect.Code.IsRedirection() {
+		allowedHosts := p.allowedHosts(dimension)
+		if _, err := allowedHosts.ValidateTarget(storex.RedirectTarget(redirect.Response)); errors.Is(err, storex.ErrHostNotAllowed) && allowedHosts.WarnOnly {
+			p.l.Warn("redirecting to a host which is not allowed",
+				zap.String("dimension", string(dimension)),
+				zap.String("source", string(definition.Source)),
+				zap.String("response", string(redirect.Response)),
+			)
+		} else if err != nil {
+			return nil, errors.Wrap(err, "redirect target not allowed")
+		}
+	}
+
 	return redirect, nil
 }
 
-// checkForStandardRedirect checks if the request needs to be redirected based on generic rules
-// it's possible to get no error and also to have no definition value, so the value needs to be checket after the method is called.
-func (p *RedirectsProvider) checkForStandardRedirect(r *http.Request) (*storex.RedirectDefinition, error) {
-	redirectRequest := storex.RedirectRequest(r.URL.RequestURI())
+// paramPolicy returns the parameter policy of the definition, falling back to the dimension's default
+func (p *RedirectsProvider) paramPolicy(definition *storex.RedirectDefinition, dimension storex.Dimension) storex.ParamPolicy {
+	if definition.ParamPolicy != nil {
+		return *definition.ParamPolicy
+	}
 
-	newRequest, redirectNeeded, err := redirectRequest.GenericTransform()
-	if err != nil {
-		return nil, err
+	if p.paramPolicyProviderFunc != nil {
+		if policy := p.paramPolicyProviderFunc(dimension); policy != nil {
+			return *policy
+		}
+	}
+
+	return storex.ParamPolicy{}
+}
+
+// allowedHosts returns the allowed hosts of the dimension, without provider absolute targets are only logged
+func (p *RedirectsProvider) allowedHosts(dimension storex.Dimension) storex.AllowedHosts {
+	if p.allowedHostsProviderFunc == nil {
+		return storex.AllowedHosts{WarnOnly: true}
+	}
+
+	return p.allowedHostsProviderFunc(dimension)
+}
+
+// checkForStandardRedirect checks if the request needs to be redirected based on the standard rules of the dimension
+// it's possible to have no definition value, so the value needs to be checked after the method is called.
+func (p *RedirectsProvider) checkForStandardRedirect(r *http.Request, dimension storex.Dimension) *storex.RedirectDefinition {
+	ruleSet, ok := p.standardRedirectRuleSet(dimension)
+	if !ok || !ruleSet.inScope(r.URL.Path) {
+		return nil
 	}
 
-	if redirectNeeded {
-		return &storex.RedirectDefinition{
-			ID:             "",
-			Source:         storex.RedirectSource(redirectRequest),
-			Target:         storex.RedirectTarget(newRequest),
-			Code:           storex.RedirectCodePermanent,
-			RespectParams:  false,
-			TransferParams: false,
-		}, nil
+	newPath, redirectNeeded := storex.ApplyStandardRedirectRules(r.URL.Path, ruleSet.Rules)
+	if !redirectNeeded {
+		return nil
 	}
 
-	return nil, nil //nolint:nilnil // needs refactoring
+	target := *r.URL
+	target.Path = newPath
+	target.RawPath = ""
+
+	return &storex.RedirectDefinition{
+		ID:             "",
+		Source:         storex.RedirectSource(r.URL.RequestURI()),
+		Target:         storex.RedirectTarget(target.RequestURI()),
+		Code:           ruleSet.code(),
+		RespectParams:  false,
+		TransferParams: false,
+	}
 }
 
-func (p *RedirectsProvider) loadRedirects(ctx context.Context) error {
+func (p *RedirectsProvider) loadRedirects(ctx context.Context) (err error) {
+	ctx, span := telemetryx.Tracer().Start(ctx, "RedirectsProvider.loadRedirects")
+	defer span.End()
+
+	start := time.Now()
+
+	defer func() {
+		p.metrics.recordReload(ctx, span, err, time.Since(start))
+	}()
+
 	redirectDefinitions, err, clientErr := p.redirectsProviderFunc(ctx)
 	if err != nil {
 		return err
@@ -341,8 +475,13 @@ func (p *RedirectsProvider) loadRedirects(ctx context.Context) error {
 	}
 
 	if redirectDefinitions != nil {
+		// stale definitions are never answered, e.g. if the filter of the provider func includes them
+		redirectDefinitions = withoutStale(redirectDefinitions)
+		matchKeys := p.collectMatchKeys(redirectDefinitions)
+
 		p.Lock()
 		p.redirects = redirectDefinitions
+		p.matchKeys = matchKeys
 		p.Unlock()
 
 		return nil
@@ -350,3 +489,68 @@ func (p *RedirectsProvider) loadRedirects(ctx context.Context) error {
 
 	return errors.New("no redirects loaded")
 }
+
+// withoutStale returns the definitions without the stale ones, the dimensions without stale definitions are shared
+func withoutStale(
+	redirectDefinitions map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition,
+) map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition {
+	active := make(map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, len(redirectDefinitions))
+
+	for dimension, definitions := range redirectDefinitions {
+		active[dimension] = definitions
+		cloned := false
+
+		for source, definition := range definitions {
+			if !definition.Stale {
+				continue
+			}
+
+			if !cloned {
+				active[dimension] = maps.Clone(definitions)
+				cloned = true
+			}
+
+			delete(active[dimension], source)
+		}
+	}
+
+	return active
+}
+
+// collectMatchKeys collects the distinct match keys of the parameter policies per dimension
+func (p *RedirectsProvider) collectMatchKeys(
+	redirectDefinitions map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition,
+) map[storex.Dimension][][]string {
+	matchKeys := make(map[storex.Dimension][][]string, len(redirectDefinitions))
+
+	for dimension, definitions := range redirectDefinitions {
+		seen := map[string]struct{}{}
+
+		for _, definition := range definitions {
+			if !definition.RespectParams {
+				continue
+			}
+
+			keys := sortedKeys(p.paramPolicy(definition, dimension).MatchKeys)
+			if len(keys) == 0 {
+				continue
+			}
+
+			if _, ok := seen[strings.Join(keys, "&")]; ok {
+				continue
+			}
+
+			seen[strings.Join(keys, "&")] = struct{}{}
+			matchKeys[dimension] = append(matchKeys[dimension], keys)
+		}
+	}
+
+	return matchKeys
+}
+
+func sortedKeys(keys []string) []string {
+	sorted := slices.Clone(keys)
+	slices.Sort(sorted)
+
+	return sorted
+}
@@ -0,0 +1,128 @@
+package redirectprovider_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_Process_Canonical(t *testing.T) {
+	t.Parallel()
+
+	provider := providerx.NewProvider(
+		zap.NewNop(),
+		func(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+			return map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+				"shop-de": {
+					"/%C3%BCber-uns":       {Source: "/%C3%BCber-uns", Target: "/about", Code: storex.RedirectCodePermanent},
+					"/suche?q=rote+schuhe": {Source: "/suche?q=rote+schuhe", Target: "/search", Code: storex.RedirectCodePermanent},
+					"/a%2Fb":               {Source: "/a%2Fb", Target: "/ab", Code: storex.RedirectCodePermanent},
+				},
+			}, nil, nil
+		},
+		func(_ *http.Request) (storex.Dimension, error) {
+			return "shop-de", nil
+		},
+		nil,
+	)
+	require.NoError(t, provider.Start(t.Context()))
+
+	for _, uri := range []string{
+		"/%C3%BCber-uns",
+		"/%c3%bcber-uns/",
+		"/u%CC%88ber-uns", // NFD
+		"/suche?q=rote%20schuhe",
+		"/suche?q=rote+schuhe",
+		"/a%2fb",
+	} {
+		redirect, err := provider.Process(httptest.NewRequest(http.MethodGet, uri, nil))
+		require.NoError(t, err)
+		assert.NotNil(t, redirect, uri)
+	}
+
+	// an encoded slash is not the same path
+	redirect, err := provider.Process(httptest.NewRequest(http.MethodGet, "/a/b", nil))
+	require.NoError(t, err)
+	assert.Nil(t, redirect)
+}
+
+func Test_Process_AllowedHosts(t *testing.T) {
+	t.Parallel()
+
+	newProvider := func(options ...providerx.RedirectsProviderOption) *providerx.RedirectsProvider {
+		provider := providerx.NewProvider(
+			zap.NewNop(),
+			func(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+				return map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+					"shop-de": {
+						"/partner":  {Source: "/partner", Target: "https://partner.com/", Code: storex.RedirectCodePermanent},
+						"/evil":     {Source: "/evil", Target: "https://evil.com/", Code: storex.RedirectCodePermanent},
+						"/relative": {Source: "/relative", Target: "//evil.com/", Code: storex.RedirectCodePermanent},
+					},
+				}, nil, nil
+			},
+			func(_ *http.Request) (storex.Dimension, error) {
+				return "shop-de", nil
+			},
+			nil,
+			options...,
+		)
+		require.NoError(t, provider.Start(t.Context()))
+
+		return provider
+	}
+
+	// without allowed hosts absolute targets are only logged
+	provider := newProvider()
+	for uri, allowed := range map[string]bool{"/partner": true, "/evil": true, "/relative": false} {
+		_, err := provider.Process(httptest.NewRequest(http.MethodGet, uri, nil))
+		assert.Equal(t, allowed, err == nil, uri)
+	}
+
+	provider = newProvider(providerx.WithAllowedHostsProvider(func(_ storex.Dimension) storex.AllowedHosts {
+		return storex.AllowedHosts{External: []string{"partner.com"}}
+	}))
+	for uri, allowed := range map[string]bool{"/partner": true, "/evil": false, "/relative": false} {
+		_, err := provider.Process(httptest.NewRequest(http.MethodGet, uri, nil))
+		assert.Equal(t, allowed, err == nil, uri)
+	}
+}
+
+func Test_Process_SkipsStale(t *testing.T) {
+	t.Parallel()
+
+	definitions := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{
+		"shop-de": {
+			"/active": {Source: "/active", Target: "/target", Code: storex.RedirectCodePermanent},
+			"/stale":  {Source: "/stale", Target: "/target", Code: storex.RedirectCodePermanent, Stale: true},
+			"/shop":   {Source: "/shop", Target: "/store", Code: storex.RedirectCodePermanent, MatchType: storex.MatchTypePrefix, Stale: true},
+		},
+	}
+	provider := providerx.NewProvider(
+		zap.NewNop(),
+		func(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+			return definitions, nil, nil
+		},
+		func(_ *http.Request) (storex.Dimension, error) {
+			return "shop-de", nil
+		},
+		nil,
+	)
+	require.NoError(t, provider.Start(t.Context()))
+
+	for uri, found := range map[string]bool{"/active": true, "/stale": false, "/shop/shoes": false} {
+		redirect, err := provider.Process(httptest.NewRequest(http.MethodGet, uri, nil))
+		require.NoError(t, err)
+		assert.Equal(t, found, redirect != nil, uri)
+	}
+
+	// the definitions of the provider func are not changed
+	assert.Len(t, definitions["shop-de"], 3)
+}
@@ -0,0 +1,25 @@
+package redirectprovider
+
+import (
+	"context"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+)
+
+// NewSnapshotRedirectsProviderFunc returns a RedirectsProviderFunc serving the definitions of the snapshot,
+// e.g. read from a file to compare it with another environment
+func NewSnapshotRedirectsProviderFunc(snapshot *storex.Snapshot) RedirectsProviderFunc {
+	return func(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+		ret := map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{}
+
+		for _, def := range snapshot.InScope() {
+			if _, ok := ret[def.Dimension]; !ok {
+				ret[def.Dimension] = map[storex.RedirectSource]*storex.RedirectDefinition{}
+			}
+
+			ret[def.Dimension][def.Source] = def
+		}
+
+		return ret, nil, nil
+	}
+}
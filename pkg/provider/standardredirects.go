@@ -0,0 +1,123 @@
+package redirectprovider
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	"github.com/pkg/errors"
+)
+
+// StandardRedirectRuleSet configures the generic redirects applied when no
+// redirect definition matches the request
+type StandardRedirectRuleSet struct {
+	// Dimensions the rule set applies to, an empty list marks the default rule set
+	Dimensions []storex.Dimension
+	// Rules to apply to the request path
+	Rules []storex.StandardRedirectRule
+	// Code of the resulting redirect, defaults to 301
+	Code storex.RedirectCode
+	// PathPrefixes limits the rule set to paths with the given prefixes, empty means all paths
+	PathPrefixes []string
+}
+
+// DefaultStandardRedirectRuleSet lowercases the path and strips the trailing slash
+func DefaultStandardRedirectRuleSet() StandardRedirectRuleSet {
+	return StandardRedirectRuleSet{
+		Rules: []storex.StandardRedirectRule{
+			storex.StandardRedirectRuleLowercase,
+			storex.StandardRedirectRuleStripTrailingSlash,
+		},
+		Code: storex.RedirectCodePermanent,
+	}
+}
+
+func (s StandardRedirectRuleSet) validate() error {
+	if len(s.Rules) == 0 {
+		return errors.New("no standard redirect rules provided")
+	}
+
+	for _, rule := range s.Rules {
+		if !rule.Valid() {
+			return fmt.Errorf("invalid standard redirect rule '%s'", rule)
+		}
+	}
+
+	if slices.Contains(s.Rules, storex.StandardRedirectRuleStripTrailingSlash) &&
+		slices.Contains(s.Rules, storex.StandardRedirectRuleEnforceTrailingSlash) {
+		return errors.New("standard redirect rules cannot strip and enforce the trailing slash at the same time")
+	}
+
+	if s.Code != 0 && !s.Code.Valid() {
+		return fmt.Errorf("invalid standard redirect code '%d'", s.Code)
+	}
+
+	return nil
+}
+
+func (s StandardRedirectRuleSet) code() storex.RedirectCode {
+	if s.Code == 0 {
+		return storex.RedirectCodePermanent
+	}
+
+	return s.Code
+}
+
+func (s StandardRedirectRuleSet) inScope(p string) bool {
+	if len(s.PathPrefixes) == 0 {
+		return true
+	}
+
+	for _, prefix := range s.PathPrefixes {
+		if strings.HasPrefix(p, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithStandardRedirectRuleSets enables standard redirects with the given rule sets.
+// A rule set without dimensions is used for all dimensions without a rule set of their own.
+func WithStandardRedirectRuleSets(ruleSets ...StandardRedirectRuleSet) RedirectsProviderOption {
+	return func(provider *RedirectsProvider) error {
+		if len(ruleSets) == 0 {
+			return errors.New("no standard redirect rule sets provided")
+		}
+
+		for _, ruleSet := range ruleSets {
+			if err := ruleSet.validate(); err != nil {
+				return err
+			}
+		}
+
+		provider.standardRedirectRuleSets = ruleSets
+
+		return nil
+	}
+}
+
+// standardRedirectRuleSet returns the rule set for the dimension, falling back to the default rule set
+func (p *RedirectsProvider) standardRedirectRuleSet(dimension storex.Dimension) (StandardRedirectRuleSet, bool) {
+	var (
+		fallback    StandardRedirectRuleSet
+		hasFallback bool
+	)
+
+	for _, ruleSet := range p.standardRedirectRuleSets {
+		if len(ruleSet.Dimensions) == 0 {
+			if !hasFallback {
+				fallback, hasFallback = ruleSet, true
+			}
+
+			continue
+		}
+
+		if slices.Contains(ruleSet.Dimensions, dimension) {
+			return ruleSet, true
+		}
+	}
+
+	return fallback, hasFallback
+}
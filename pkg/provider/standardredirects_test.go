@@ -0,0 +1,104 @@
+package redirectprovider_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	providerx "github.com/foomo/redirects/v2/pkg/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestProvider(t *testing.T, options ...providerx.RedirectsProviderOption) *providerx.RedirectsProvider {
+	t.Helper()
+
+	provider := providerx.NewProvider(
+		zap.NewNop(),
+		func(_ context.Context) (map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition, error, error) {
+			return map[storex.Dimension]map[storex.RedirectSource]*storex.RedirectDefinition{}, nil, nil
+		},
+		func(r *http.Request) (storex.Dimension, error) {
+			return storex.Dimension(r.Header.Get("X-Dimension")), nil
+		},
+		nil,
+		options...,
+	)
+	require.NoError(t, provider.Start(t.Context()))
+
+	return provider
+}
+
+func Test_StandardRedirects(t *testing.T) {
+	t.Parallel()
+
+	provider := newTestProvider(t,
+		providerx.WithStandardRedirectRuleSets(
+			providerx.DefaultStandardRedirectRuleSet(),
+			providerx.StandardRedirectRuleSet{
+				Dimensions: []storex.Dimension{"shop-de"},
+				Rules: []storex.StandardRedirectRule{
+					storex.StandardRedirectRuleEnforceTrailingSlash,
+					storex.StandardRedirectRuleCollapseSlashes,
+					storex.StandardRedirectRuleStripIndexFile,
+				},
+				Code:         storex.RedirectCodeFound,
+				PathPrefixes: []string{"/products"},
+			},
+		),
+	)
+
+	tests := []struct {
+		name      string
+		dimension string
+		uri       string
+		response  storex.RedirectResponse
+		code      storex.RedirectCode
+	}{
+		{name: "default lowercase", dimension: "shop-en", uri: "/Foo", response: "/foo", code: storex.RedirectCodePermanent},
+		{name: "default trailing slash", dimension: "shop-en", uri: "/foo/?a=1", response: "/foo?a=1", code: storex.RedirectCodePermanent},
+		{name: "default no redirect", dimension: "shop-en", uri: "/foo"},
+		{name: "enforce trailing slash", dimension: "shop-de", uri: "/products/ABC", response: "/products/ABC/", code: storex.RedirectCodeFound},
+		{name: "collapse slashes", dimension: "shop-de", uri: "/products//ABC/", response: "/products/ABC/", code: storex.RedirectCodeFound},
+		{name: "strip index file", dimension: "shop-de", uri: "/products/index.html", response: "/products/", code: storex.RedirectCodeFound},
+		{name: "keep files", dimension: "shop-de", uri: "/products/file.pdf"},
+		{name: "out of scope", dimension: "shop-de", uri: "/About"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, tt.uri, nil)
+			r.Header.Set("X-Dimension", tt.dimension)
+
+			redirect, err := provider.Process(r)
+			require.NoError(t, err)
+
+			if tt.response == "" {
+				assert.Nil(t, redirect)
+				return
+			}
+
+			require.NotNil(t, redirect)
+			assert.Equal(t, tt.response, redirect.Response)
+			assert.Equal(t, tt.code, redirect.Code)
+		})
+	}
+}
+
+func Test_WithStandardRedirectRuleSets_Invalid(t *testing.T) {
+	t.Parallel()
+
+	provider := &providerx.RedirectsProvider{}
+	err := providerx.WithStandardRedirectRuleSets(providerx.StandardRedirectRuleSet{
+		Rules: []storex.StandardRedirectRule{
+			storex.StandardRedirectRuleStripTrailingSlash,
+			storex.StandardRedirectRuleEnforceTrailingSlash,
+		},
+	})(provider)
+	require.Error(t, err)
+}
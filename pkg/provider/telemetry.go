@@ -0,0 +1,133 @@
+package redirectprovider
+
+import (
+	"context"
+	"time"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
+	telemetryx "github.com/foomo/redirects/v2/pkg/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	resultHit      = "hit"
+	resultMiss     = "miss"
+	resultExcluded = "excluded"
+	resultError    = "error"
+)
+
+// processResult describes how a request was processed
+type processResult struct {
+	dimension storex.Dimension
+	matchKind telemetryx.MatchKind
+	excluded  bool
+}
+
+type providerMetrics struct {
+	requests        metric.Int64Counter
+	errors          metric.Int64Counter
+	processDuration metric.Float64Histogram
+	reloadDuration  metric.Float64Histogram
+	registration    metric.Registration
+}
+
+func newProviderMetrics(p *RedirectsProvider) *providerMetrics {
+	m := &providerMetrics{
+		requests:        telemetryx.Int64Counter("redirects.provider.requests", "Number of processed requests by result"),
+		errors:          telemetryx.Int64Counter("redirects.provider.errors", "Number of errors by operation"),
+		processDuration: telemetryx.DurationHistogram("redirects.provider.process.duration", "Duration of processing a request"),
+		reloadDuration:  telemetryx.DurationHistogram("redirects.provider.reload.duration", "Duration of reloading the redirects"),
+	}
+
+	// rule counts per dimension of the currently loaded redirects
+	definitions, err := telemetryx.Meter().Int64ObservableGauge(
+		"redirects.provider.definitions",
+		metric.WithDescription("Number of loaded redirect definitions per dimension"),
+	)
+	if err != nil {
+		otel.Handle(err)
+		return m
+	}
+
+	// the callback references the provider, it is unregistered on close
+	m.registration, err = telemetryx.Meter().RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		p.RLock()
+		defer p.RUnlock()
+
+		for dimension, defs := range p.redirects {
+			o.ObserveInt64(definitions, int64(len(defs)), metric.WithAttributes(telemetryx.AttributeDimension.String(string(dimension))))
+		}
+
+		return nil
+	}, definitions)
+	if err != nil {
+		otel.Handle(err)
+	}
+
+	return m
+}
+
+// unregister stops observing the loaded definitions
+func (m *providerMetrics) unregister() error {
+	if m.registration == nil {
+		return nil
+	}
+
+	err := m.registration.Unregister()
+	m.registration = nil
+
+	return err
+}
+
+func (m *providerMetrics) recordProcess(
+	ctx context.Context,
+	span trace.Span,
+	redirect *storex.Redirect,
+	result processResult,
+	err error,
+	duration time.Duration,
+) {
+	attributes := []attribute.KeyValue{
+		telemetryx.AttributeDimension.String(string(result.dimension)),
+	}
+
+	switch {
+	case err != nil:
+		attributes = append(attributes, telemetryx.AttributeResult.String(resultError))
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		m.errors.Add(ctx, 1, metric.WithAttributes(telemetryx.AttributeOperation.String("process")))
+	case redirect != nil:
+		attributes = append(attributes,
+			telemetryx.AttributeResult.String(resultHit),
+			telemetryx.AttributeMatchKind.String(string(result.matchKind)),
+			telemetryx.AttributeCode.Int(int(redirect.Code)),
+		)
+	case result.excluded:
+		attributes = append(attributes, telemetryx.AttributeResult.String(resultExcluded))
+	default:
+		attributes = append(attributes, telemetryx.AttributeResult.String(resultMiss))
+	}
+
+	span.SetAttributes(attributes...)
+	m.requests.Add(ctx, 1, metric.WithAttributes(attributes...))
+	m.processDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(attributes[:2]...))
+}
+
+func (m *providerMetrics) recordReload(ctx context.Context, span trace.Span, err error, duration time.Duration) {
+	result := "ok"
+	if err != nil {
+		result = resultError
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		m.errors.Add(ctx, 1, metric.WithAttributes(telemetryx.AttributeOperation.String("reload")))
+	}
+
+	m.reloadDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(telemetryx.AttributeResult.String(result)))
+}
@@ -1,15 +1,30 @@
 package redirectprovider
 
 import (
-	"maps"
 	"net/http"
 	"net/url"
 	"strings"
+
+	storex "github.com/foomo/redirects/v2/domain/redirectdefinition/store"
 )
 
 func normalizeRedirectRequest(r *http.Request) (*http.Request, error) {
 	request := r.Clone(r.Context())
-	request.URL.Path = strings.TrimSuffix(request.URL.Path, "/")
+
+	// use the canonical path so that RequestURI() matches the canonical sources,
+	// the raw path keeps encoded slashes
+	rawPath, err := storex.CanonicalizeEscapedPath(strings.TrimSuffix(request.URL.EscapedPath(), "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return nil, err
+	}
+
+	request.URL.Path = path
+	request.URL.RawPath = rawPath
 
 	query, err := normalizeQueryString(request.URL.RawQuery)
 	if err != nil {
@@ -22,15 +37,10 @@ func normalizeRedirectRequest(r *http.Request) (*http.Request, error) {
 }
 
 func normalizeQueryString(rawQueryString string) (string, error) {
-	parsedQuery, err := url.ParseQuery(rawQueryString)
-	if err != nil {
-		return "", err
-	}
-
-	return parsedQuery.Encode(), nil
+	return storex.CanonicalizeQuery(rawQueryString)
 }
 
-func mergeQueryStrings(base string, override string) (string, error) {
+func mergeQueryStrings(base string, override string, policy storex.ParamPolicy) (string, error) {
 	baseValues, err := url.ParseQuery(base)
 	if err != nil {
 		return "", err
@@ -41,12 +51,7 @@ func mergeQueryStrings(base string, override string) (string, error) {
 		return "", err
 	}
 
-	newValues := url.Values{}
-	maps.Copy(newValues, baseValues)
-
-	maps.Copy(newValues, overrideValues)
-
-	query, err := normalizeQueryString(newValues.Encode())
+	query, err := normalizeQueryString(policy.Merge(baseValues, overrideValues).Encode())
 	if err != nil {
 		return "", err
 	}
@@ -54,7 +59,7 @@ func mergeQueryStrings(base string, override string) (string, error) {
 	return query, nil
 }
 
-func mergeQueryStringsFromURLs(base string, override string) (string, error) {
+func mergeQueryStringsFromURLs(base string, override string, policy storex.ParamPolicy) (string, error) {
 	urlBase, err := url.Parse(base)
 	if err != nil {
 		return "", err
@@ -65,12 +70,17 @@ func mergeQueryStringsFromURLs(base string, override string) (string, error) {
 		return "", err
 	}
 
-	query, err := mergeQueryStrings(urlBase.RawQuery, urlOverride.RawQuery)
+	query, err := mergeQueryStrings(urlBase.RawQuery, urlOverride.RawQuery, policy)
 	if err != nil {
 		return "", err
 	}
 
 	urlOverride.RawQuery = query
 
+	// keep scheme and host of absolute targets
+	if urlOverride.IsAbs() {
+		return urlOverride.String(), nil
+	}
+
 	return urlOverride.RequestURI(), nil
 }
@@ -0,0 +1,71 @@
+package redirecttelemetry
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of all spans and metrics
+const ScopeName = "github.com/foomo/redirects/v2"
+
+// MatchKind describes how a redirect was found
+type MatchKind string
+
+const (
+	// MatchKindExact full request uri matched a source
+	MatchKindExact MatchKind = "exact"
+	// MatchKindParams request path and the match keys of a parameter policy matched a source
+	MatchKindParams MatchKind = "params"
+	// MatchKindPath request path matched a source respecting params
+	MatchKindPath MatchKind = "path"
+	// MatchKindPrefix request path is below the source of a prefix definition
+	MatchKindPrefix MatchKind = "prefix"
+	// MatchKindMatcher a matcher func returned a definition
+	MatchKindMatcher MatchKind = "matcher"
+	// MatchKindStandard a standard redirect rule applied
+	MatchKindStandard MatchKind = "standard"
+)
+
+const (
+	AttributeDimension = attribute.Key("redirects.dimension")
+	AttributeMatchKind = attribute.Key("redirects.match_kind")
+	AttributeCode      = attribute.Key("redirects.code")
+	AttributeResult    = attribute.Key("redirects.result")
+	AttributeOperation = attribute.Key("redirects.operation")
+	AttributeCommand   = attribute.Key("redirects.command")
+)
+
+// Tracer returns the tracer of the global trace provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(ScopeName)
+}
+
+// Meter returns the meter of the global meter provider
+func Meter() metric.Meter {
+	return otel.Meter(ScopeName)
+}
+
+// Int64Counter creates a counter, falling back to a noop instrument on error
+func Int64Counter(name, description string) metric.Int64Counter {
+	counter, err := Meter().Int64Counter(name, metric.WithDescription(description))
+	if err != nil {
+		otel.Handle(err)
+		return noop.Int64Counter{}
+	}
+
+	return counter
+}
+
+// DurationHistogram creates a histogram in seconds, falling back to a noop instrument on error
+func DurationHistogram(name, description string) metric.Float64Histogram {
+	histogram, err := Meter().Float64Histogram(name, metric.WithDescription(description), metric.WithUnit("s"))
+	if err != nil {
+		otel.Handle(err)
+		return noop.Float64Histogram{}
+	}
+
+	return histogram
+}